@@ -0,0 +1,413 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/config"
+	"wedding-photo-backend/internal/weddingphoto/logging"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/service"
+)
+
+var (
+	// errUsage indica argomenti non validi, già segnalati stampando l'uso del comando
+	errUsage = errors.New("argomenti non validi")
+	// errHelp indica che è stato richiesto l'aiuto del comando con -h
+	errHelp = errors.New("aiuto richiesto")
+)
+
+// command è un sottocomando del binario; serve e worker restano in esecuzione fino a SIGINT o
+// SIGTERM, gli altri terminano al completamento dell'operazione
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(args []string) error
+}
+
+// commands elenca i sottocomandi nell'ordine mostrato dall'uso
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "", "avvia il server HTTP con i worker (comando predefinito)", serve},
+		{"worker", "[-workers N]", "elabora la coda Redis senza server HTTP", runWorker},
+		{"reindex", "", "ricostruisce i metadati dalle foto su disco", runReindex},
+		{"regenerate", "[-missing] [-since DATA] [-uploader NOME] [foto...]", "accoda la rigenerazione di thumbnail e preview", runRegenerate},
+		{"verify", "", "controlla originali, thumbnail, preview e metadati", runVerify},
+		{"import", "[-uploader NOME] <directory>", "importa le immagini di una directory", runImport},
+		{"export", "-o FILE [-album ID] [-manifest json|csv] [-layout flat|uploader]", "scrive l'archivio ZIP degli originali", runExport},
+		{"queue", "inspect [-limit N] [corsia...] | requeue | purge <corsia|failed|all>...", "ispeziona e gestisce la coda di elaborazione", runQueue},
+		{"config", "print", "stampa la configurazione effettiva", runConfig},
+	}
+}
+
+// runCommand esegue il sottocomando indicato dal primo argomento e termina il processo con codice 1
+// in caso di errore e 2 in caso di argomenti non validi
+func runCommand(args []string) {
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(args[1:])
+		switch {
+		case err == nil, errors.Is(err, errHelp):
+			return
+		case errors.Is(err, errUsage):
+			os.Exit(2)
+		default:
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Comando sconosciuto: %s\n\n", args[0])
+	printUsage()
+	os.Exit(2)
+}
+
+// printUsage stampa l'elenco dei sottocomandi
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Uso: %s [comando] [opzioni]\n\nComandi:\n", program())
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	w.Flush()
+	fmt.Fprintf(os.Stderr, "\nUsare \"%s <comando> -h\" per le opzioni di un comando.\n", program())
+}
+
+// program restituisce il nome del binario
+func program() string {
+	return filepath.Base(os.Args[0])
+}
+
+// newFlagSet crea il parser delle opzioni di un sottocomando, che in caso di errore stampa l'uso
+func newFlagSet(name, args string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Uso: %s %s %s\n", program(), name, args)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// usageError converte l'errore di flags.Parse in errUsage o errHelp; senza errore di parsing, per
+// argomenti posizionali non validi, stampa l'uso del comando
+func usageError(flags *flag.FlagSet, err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return errHelp
+	}
+	if err == nil {
+		flags.Usage()
+	}
+	return errUsage
+}
+
+// commandContext restituisce il context dei sottocomandi, annullato da SIGINT o SIGTERM, con un
+// identificativo riportato nei log e nei job accodati come quello delle richieste HTTP
+func commandContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	return logging.WithRequestID(ctx, logging.NewRequestID()), cancel
+}
+
+// runWorker elabora la coda Redis con più worker, per affiancare le istanze avviate con QUEUE_WORKERS=0
+func runWorker(args []string) error {
+	flags := newFlagSet("worker", "[-workers N]")
+	workers := flags.Int("workers", 0, "numero di worker, QUEUE_WORKERS se 0")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 || *workers < 0 {
+		return usageError(flags, err)
+	}
+
+	// Log su stdout come il server
+	app := newApplication(os.Stdout)
+	if app.cfg.Queue.Backend != config.QueueBackendRedis {
+		app.close()
+		return fmt.Errorf("il comando worker richiede QUEUE_BACKEND=%s: la coda %s è elaborata dal server", config.QueueBackendRedis, app.cfg.Queue.Backend)
+	}
+	if *workers == 0 {
+		*workers = max(app.cfg.Queue.Workers, 1)
+	}
+
+	for i := 1; i <= *workers; i++ {
+		app.lifecycle.Go(fmt.Sprintf("elaborazione-%d", i), app.processingService.RunWorker)
+	}
+	slog.Info("Worker di elaborazione avviati", "workers", *workers)
+	return app.lifecycle.Wait()
+}
+
+// runReindex ricostruisce i metadati dalle foto su disco
+func runReindex(args []string) error {
+	flags := newFlagSet("reindex", "")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return usageError(flags, err)
+	}
+
+	app := newApplication(os.Stderr)
+	defer app.close()
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	result, err := app.maintenanceService.Reindex(ctx)
+	if result != nil {
+		fmt.Printf("Foto registrate: %d\nMetadati eliminati: %d\nFoto aggiornate: %d\n", result.Added, result.Removed, result.Updated)
+	}
+	return err
+}
+
+// runRegenerate accoda nella corsia background la rigenerazione delle versioni ridotte
+func runRegenerate(args []string) error {
+	flags := newFlagSet("regenerate", "[-missing] [-since DATA] [-uploader NOME] [foto...]")
+	missing := flags.Bool("missing", false, "solo thumbnail e preview mancanti")
+	since := flags.String("since", "", "solo foto caricate da questa data, YYYY-MM-DD o RFC 3339")
+	uploader := flags.String("uploader", "", "solo foto caricate da chi ha questo nome")
+	if err := flags.Parse(args); err != nil {
+		return usageError(flags, err)
+	}
+	options := service.RegenerateOptions{
+		ImageNames:  flags.Args(),
+		MissingOnly: *missing,
+		Uploader:    *uploader,
+	}
+	if *since != "" {
+		sinceTime, err := parseSince(*since)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return usageError(flags, nil)
+		}
+		options.Since = sinceTime
+	}
+
+	app := newApplication(os.Stderr)
+	defer app.close()
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	queued, err := app.maintenanceService.Regenerate(ctx, options)
+	fmt.Printf("Foto accodate nella corsia %s: %d\n", manager.LaneBackground, queued)
+	return err
+}
+
+// parseSince interpreta una data YYYY-MM-DD, nel fuso orario locale, o un istante RFC 3339
+func parseSince(value string) (time.Time, error) {
+	if since, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return since, nil
+	}
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("data %q non valida, formato atteso YYYY-MM-DD o RFC 3339", value)
+	}
+	return since, nil
+}
+
+// runVerify controlla tutte le foto ed esce con errore se trova problemi
+func runVerify(args []string) error {
+	flags := newFlagSet("verify", "")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return usageError(flags, err)
+	}
+
+	app := newApplication(os.Stderr)
+	defer app.close()
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	checked, problems, err := app.maintenanceService.Verify(ctx)
+	for _, problem := range problems {
+		fmt.Printf("%s: %s\n", problem.ImageName, problem.Problem)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Foto controllate: %d, problemi: %d\n", checked, len(problems))
+	if len(problems) > 0 {
+		return fmt.Errorf("trovati %d problemi", len(problems))
+	}
+	return nil
+}
+
+// runImport importa le immagini di una directory nella corsia bulk
+func runImport(args []string) error {
+	flags := newFlagSet("import", "[-uploader NOME] <directory>")
+	uploader := flags.String("uploader", "", "nome riportato come autore delle foto importate")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return usageError(flags, err)
+	}
+
+	app := newApplication(os.Stderr)
+	defer app.close()
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	result, err := app.maintenanceService.Import(ctx, flags.Arg(0), service.ImportOptions{UploaderName: *uploader})
+	for _, failure := range result.Failed {
+		fmt.Printf("%s: %s\n", failure.Path, failure.Reason)
+	}
+	fmt.Printf("Foto importate: %d, file ignorati: %d, file non importati: %d\n", result.Imported, result.Skipped, len(result.Failed))
+	if err != nil {
+		return err
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d file non importati", len(result.Failed))
+	}
+	return nil
+}
+
+// runExport scrive su file l'archivio ZIP degli originali, come il download dell'API di esportazione
+func runExport(args []string) error {
+	flags := newFlagSet("export", "-o FILE [-album ID] [-manifest json|csv] [-layout flat|uploader]")
+	output := flags.String("o", "", "file ZIP da scrivere")
+	albumID := flags.Int64("album", 0, "album da esportare, l'intera galleria se 0")
+	manifest := flags.String("manifest", "", "manifest da includere: json o csv")
+	layout := flags.String("layout", service.ExportLayoutFlat, "struttura delle cartelle: flat o uploader")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 || *output == "" {
+		return usageError(flags, err)
+	}
+
+	app := newApplication(os.Stderr)
+	defer app.close()
+
+	archive, err := app.exportService.PrepareArchive(service.ExportOptions{
+		AlbumID:  *albumID,
+		Manifest: *manifest,
+		Layout:   *layout,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Scrive su un file temporaneo rinominato solo al termine, per non lasciare archivi troncati
+	tmp, err := os.CreateTemp(filepath.Dir(*output), ".export-*")
+	if err != nil {
+		return fmt.Errorf("errore nella creazione dell'archivio: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := archive.Write(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("errore nella scrittura dell'archivio: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("errore nella scrittura dell'archivio: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), *output); err != nil {
+		return fmt.Errorf("errore nella scrittura dell'archivio: %v", err)
+	}
+	fmt.Printf("Archivio scritto in %s (%d byte)\n", *output, archive.Size)
+	return nil
+}
+
+// runQueue esegue i sottocomandi della coda di elaborazione
+func runQueue(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "inspect":
+			return runQueueInspect(args[1:])
+		case "requeue":
+			return runQueueRequeue(args[1:])
+		case "purge":
+			return runQueuePurge(args[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Uso: %s queue inspect [-limit N] [corsia...] | requeue | purge <corsia|failed|all>...\n", program())
+	return errUsage
+}
+
+// runQueueInspect stampa lunghezza e primi job delle corsie e dei job non riusciti
+func runQueueInspect(args []string) error {
+	flags := newFlagSet("queue inspect", "[-limit N] [corsia...]")
+	limit := flags.Int("limit", 20, "numero massimo di job mostrati per corsia")
+	if err := flags.Parse(args); err != nil || *limit < 0 {
+		return usageError(flags, err)
+	}
+
+	app := newApplication(os.Stderr)
+	defer app.close()
+
+	lanes, err := app.processingService.InspectQueue(flags.Args(), *limit)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, lane := range lanes {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s: %d job\n", lane.Name, lane.Length)
+		if len(lane.Jobs) == 0 {
+			continue
+		}
+		fmt.Fprintln(w, "  FOTO\tOPERAZIONI\tTENTATIVO\tACCODATO\tRICHIESTA\tERRORE")
+		for _, job := range lane.Jobs {
+			enqueuedAt := ""
+			if !job.EnqueuedAt.IsZero() {
+				enqueuedAt = job.EnqueuedAt.Local().Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%d\t%s\t%s\t%s\n", job.PhotoID, strings.Join(job.Operations, ","), job.Attempt, enqueuedAt, job.RequestID, job.Error)
+		}
+	}
+	return w.Flush()
+}
+
+// runQueueRequeue rimette in coda i job non riusciti
+func runQueueRequeue(args []string) error {
+	flags := newFlagSet("queue requeue", "")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return usageError(flags, err)
+	}
+
+	app := newApplication(os.Stderr)
+	defer app.close()
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	requeued, skipped, err := app.processingService.RequeueFailed(ctx)
+	fmt.Printf("Job rimessi in coda: %d\n", requeued)
+	if skipped > 0 {
+		fmt.Printf("Job non validi rimasti tra i non riusciti: %d (queue inspect failed, queue purge failed)\n", skipped)
+	}
+	return err
+}
+
+// runQueuePurge elimina i job in attesa nelle corsie indicate; all indica tutte le corsie e i job
+// non riusciti
+func runQueuePurge(args []string) error {
+	flags := newFlagSet("queue purge", "<corsia|failed|all>...")
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		return usageError(flags, err)
+	}
+	lanes := flags.Args()
+	if len(lanes) == 1 && lanes[0] == "all" {
+		lanes = append(append([]string(nil), manager.QueueLanes...), manager.QueueFailed)
+	}
+
+	app := newApplication(os.Stderr)
+	defer app.close()
+
+	removed, err := app.processingService.PurgeQueue(lanes)
+	fmt.Printf("Job eliminati: %d\n", removed)
+	return err
+}
+
+// runConfig stampa la configurazione anche se non valida, elencando poi i problemi
+func runConfig(args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		fmt.Fprintf(os.Stderr, "Uso: %s config print\n", program())
+		return errUsage
+	}
+	cfg, err := config.Load(config.Options{Checks: configChecks})
+	if printErr := cfg.Print(os.Stdout); printErr != nil {
+		return printErr
+	}
+	return err
+}
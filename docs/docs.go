@@ -32,35 +32,82 @@ var doc = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/photos": {
+        "/api/admin/rate-limits": {
             "get": {
-                "description": "Ottiene tutte le foto caricate sul server con paginazione",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Restituisce per ogni budget (upload, read) le richieste consentite per ospite e per indirizzo IP, riservato agli amministratori",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "photos"
+                    "admin"
                 ],
-                "summary": "Recupera la lista delle foto",
+                "summary": "Recupera i limiti di richieste",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RateLimitsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/rate-limits/{budget}": {
+            "put": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Sostituisce per questo evento il limite configurato di un budget, ad esempio per consentire più upload durante la festa.\nLa modifica vale per tutte le istanze entro pochi secondi, riservato agli amministratori.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Modifica un limite di richieste",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Numero pagina (default: 1)",
-                        "name": "page",
-                        "in": "query"
+                        "enum": [
+                            "upload",
+                            "read"
+                        ],
+                        "type": "string",
+                        "description": "Budget",
+                        "name": "budget",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Elementi per pagina (default: 10, max: 100)",
-                        "name": "per_page",
-                        "in": "query"
+                        "description": "Nuovo limite",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UpdateRateLimitRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.GetPhotosResponse"
+                            "$ref": "#/definitions/model.RateLimit"
                         }
                     },
                     "400": {
@@ -69,6 +116,123 @@ var doc = `{
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Annulla la modifica del limite di un budget, tornando al valore della configurazione, riservato agli amministratori",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Ripristina un limite di richieste",
+                "parameters": [
+                    {
+                        "enum": [
+                            "upload",
+                            "read"
+                        ],
+                        "type": "string",
+                        "description": "Budget",
+                        "name": "budget",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RateLimit"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/status": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Mostra verifiche di prontezza, lunghezza della coda di elaborazione, worker attivi, foto in attesa di thumbnail e preview e spazio su disco",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Stato dell'istanza",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.StatusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/albums": {
+            "get": {
+                "description": "Ottiene tutti gli album dell'evento con la relativa copertina",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Recupera la lista degli album",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GetAlbumsResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -78,37 +242,128 @@ var doc = `{
                 }
             },
             "post": {
-                "description": "Carica una nuova foto sul server",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Crea un nuovo album, riservato agli amministratori",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "photos"
+                    "albums"
                 ],
-                "summary": "Upload di una foto",
+                "summary": "Crea un album",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "File immagine da caricare",
-                        "name": "fiimagele",
-                        "in": "formData",
+                        "description": "Dati dell'album",
+                        "name": "album",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AddAlbumRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.AlbumResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/albums/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Recupera un album",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Identificativo dell'album",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AlbumResponse"
+                        }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
                     {
-                        "type": "string",
-                        "description": "Nome personalizzato per l'immagine",
-                        "name": "imageName",
-                        "in": "formData"
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Modifica titolo, descrizione, copertina o permessi di upload di un album, riservato agli amministratori",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Modifica un album",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Identificativo dell'album",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Campi da modificare",
+                        "name": "album",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UpdateAlbumRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.AddPhotoResponse"
+                            "$ref": "#/definitions/model.AlbumResponse"
                         }
                     },
                     "400": {
@@ -116,87 +371,2896 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "model.AddPhotoResponse": {
-            "type": "object",
-            "required": [
-                "photo"
-            ],
-            "properties": {
-                "photo": {
-                    "description": "Nome della foto aggiunta",
-                    "$ref": "#/definitions/model.Photo"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Elimina un album senza eliminare le foto che contiene, riservato agli amministratori",
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Elimina un album",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Identificativo dell'album",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "model.ErrorResponse": {
-            "type": "object",
-            "required": [
-                "message"
-            ],
-            "properties": {
-                "message": {
-                    "description": "Messaggio di errore",
-                    "type": "string"
-                }
-            }
+        "/api/albums/{id}/export.zip": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Scarica in streaming un archivio ZIP con le foto originali di un album, riservato agli amministratori.\nSupporta le richieste Range per riprendere un download interrotto.",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "exports"
+                ],
+                "summary": "Esporta un album",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Identificativo dell'album",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Manifest da includere nell'archivio",
+                        "name": "manifest",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "flat",
+                            "uploader"
+                        ],
+                        "type": "string",
+                        "description": "Struttura delle cartelle",
+                        "name": "layout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Intervallo di byte da scaricare",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "416": {
+                        "description": "Requested Range Not Satisfiable",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/albums/{id}/photos": {
+            "get": {
+                "description": "Ottiene le foto di un album nell'ordine scelto dagli sposi, per pagina (page/per_page) o con i cursori after/before che restano stabili quando vengono aggiunte foto",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Recupera le foto di un album",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Identificativo dell'album",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Numero pagina (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Elementi per pagina (default: 10, max: 100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursore next_cursor: restituisce le foto successive, al posto di page",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursore prev_cursor: restituisce le foto precedenti, al posto di page",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GetPhotosResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Aggiunge in coda all'album foto già caricate, riservato agli amministratori",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Aggiunge foto a un album",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Identificativo dell'album",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Foto da aggiungere",
+                        "name": "photos",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AlbumPhotosRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/albums/{id}/photos/order": {
+            "put": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Porta in testa all'album le foto indicate nell'ordine dato, le altre seguono; riservato agli amministratori",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Riordina le foto di un album",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Identificativo dell'album",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nuovo ordine delle foto",
+                        "name": "photos",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AlbumPhotosRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/albums/{id}/photos/{name}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Rimuove la foto dall'album senza eliminarla, riservato agli amministratori",
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Rimuove una foto da un album",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Identificativo dell'album",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nome della foto",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/events/stream": {
+            "get": {
+                "description": "Invia come Server-Sent Events gli eventi photo.added, photo.processed, photo.deleted e photo.approved; dopo una disconnessione il client riceve gli eventi persi indicando l'ultimo ricevuto in Last-Event-ID",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Eventi in tempo reale (SSE)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Identificativo dell'ultimo evento ricevuto",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Alternativa all'header Last-Event-ID",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/events/ws": {
+            "get": {
+                "description": "Equivalente WebSocket di /api/events/stream: ogni messaggio è un evento in JSON; per riprendere dopo una disconnessione indicare l'ultimo evento ricevuto in last_event_id",
+                "tags": [
+                    "events"
+                ],
+                "summary": "Eventi in tempo reale (WebSocket)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Identificativo dell'ultimo evento ricevuto",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/model.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/export.zip": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Scarica in streaming un archivio ZIP con tutte le foto originali, riservato agli amministratori.\nSupporta le richieste Range per riprendere un download interrotto.",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "exports"
+                ],
+                "summary": "Esporta tutte le foto",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Manifest da includere nell'archivio",
+                        "name": "manifest",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "flat",
+                            "uploader"
+                        ],
+                        "type": "string",
+                        "description": "Struttura delle cartelle",
+                        "name": "layout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Intervallo di byte da scaricare",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "416": {
+                        "description": "Requested Range Not Satisfiable",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/exports": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Prepara l'archivio ZIP in background, per gallerie troppo grandi da scaricare in una richiesta.\nAl termine viene pubblicato un evento export.ready o export.failed; l'archivio resta disponibile per 24 ore.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exports"
+                ],
+                "summary": "Avvia un'esportazione asincrona",
+                "parameters": [
+                    {
+                        "description": "Opzioni dell'esportazione",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreateExportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/model.ExportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/exports/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exports"
+                ],
+                "summary": "Stato di un'esportazione",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Identificativo dell'esportazione",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ExportJob"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/exports/{id}/download": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Scarica l'archivio ZIP di un'esportazione asincrona completata, con supporto alle richieste Range",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "exports"
+                ],
+                "summary": "Scarica un'esportazione",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Identificativo dell'esportazione",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos": {
+            "get": {
+                "description": "Ottiene tutte le foto caricate sul server, per pagina (page/per_page) o con i cursori after/before che restano stabili durante i nuovi caricamenti",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Recupera la lista delle foto",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Numero pagina (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Elementi per pagina (default: 10, max: 100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ordinamento: recent (default) o popular",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filtra per tag, ripetibile",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filtra per persona, ripetibile",
+                        "name": "person",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Combinazione dei filtri: and (default, tutti) o or (almeno uno)",
+                        "name": "tag_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursore next_cursor: restituisce le foto successive, al posto di page",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursore prev_cursor: restituisce le foto precedenti, al posto di page",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GetPhotosResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Carica una nuova foto sul server",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Upload di una foto",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "File immagine da caricare",
+                        "name": "fiimagele",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nome personalizzato per l'immagine",
+                        "name": "imageName",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Didascalia della foto",
+                        "name": "caption",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nome di chi carica la foto",
+                        "name": "uploader_name",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Album in cui caricare la foto",
+                        "name": "album_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token che identifica l'ospite che carica la foto",
+                        "name": "X-Guest-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AddPhotoResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos/pending": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Ottiene con paginazione le foto già elaborate in attesa di approvazione, riservato agli amministratori",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Recupera le foto da approvare",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Numero pagina (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Elementi per pagina (default: 10, max: 100)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GetPhotosResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos/{name}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Elimina una foto con thumbnail, preview, tag, commenti e reazioni, riservato agli amministratori",
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Elimina una foto",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nome della foto",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos/{name}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Rende visibile nella galleria una foto in attesa di approvazione, riservato agli amministratori",
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Approva una foto",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nome della foto",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos/{name}/comments": {
+            "get": {
+                "description": "Ottiene i commenti di una foto organizzati in thread, in ordine cronologico",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Recupera i commenti di una foto",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nome della foto",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token che identifica l'ospite, per riconoscere i propri commenti",
+                        "name": "X-Guest-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GetCommentsResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Aggiunge un commento a una foto o una risposta a un commento esistente",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Commenta una foto",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nome della foto",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token che identifica l'ospite",
+                        "name": "X-Guest-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Commento",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AddCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.CommentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos/{name}/comments/{id}": {
+            "delete": {
+                "description": "Elimina un commento e le sue risposte; consentito all'autore e agli amministratori",
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Elimina un commento",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nome della foto",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Identificativo del commento",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token che identifica l'ospite",
+                        "name": "X-Guest-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos/{name}/original": {
+            "get": {
+                "description": "Scarica l'immagine originale con il nome del file caricato dall'ospite, con supporto alle richieste Range.\nGli amministratori e l'ospite che ha caricato la foto (header X-Guest-Token) possono sempre scaricarla;\ngli altri ospiti solo se la foto è visibile in galleria e ALLOW_ORIGINAL_DOWNLOAD è attivo.",
+                "produces": [
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif",
+                    "image/webp"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Scarica la foto originale",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nome della foto",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token dell'ospite",
+                        "name": "X-Guest-Token",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Intervallo di byte da scaricare",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "416": {
+                        "description": "Requested Range Not Satisfiable",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos/{name}/reactions": {
+            "post": {
+                "description": "Registra la reazione dell'ospite a una foto; ogni ospite può lasciare una sola reazione per tipo",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reactions"
+                ],
+                "summary": "Aggiunge una reazione",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nome della foto",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token che identifica l'ospite",
+                        "name": "X-Guest-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Reazione",
+                        "name": "reaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AddReactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ReactionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Rimuove la reazione dell'ospite del tipo indicato",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reactions"
+                ],
+                "summary": "Rimuove una reazione",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nome della foto",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tipo di reazione",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token che identifica l'ospite",
+                        "name": "X-Guest-Token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ReactionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos/{name}/tags": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Recupera i tag di una foto",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nome della foto",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PhotoTagsResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Aggiunge tag liberi e persone a una foto; consentito a chi ha caricato la foto e agli amministratori",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Aggiunge tag a una foto",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nome della foto",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token dell'ospite che ha caricato la foto",
+                        "name": "X-Guest-Token",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Tag e persone da aggiungere",
+                        "name": "tags",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PhotoTagsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PhotoTagsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/photos/{name}/tags/{tag}": {
+            "delete": {
+                "description": "Rimuove un tag libero o una persona da una foto; consentito a chi ha caricato la foto e agli amministratori",
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Rimuove un tag da una foto",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nome della foto",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tag o persona da rimuovere",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tipo: tag (default) o person",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token dell'ospite che ha caricato la foto",
+                        "name": "X-Guest-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reactions": {
+            "get": {
+                "description": "Ottiene l'elenco delle reazioni che gli ospiti possono lasciare sulle foto",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reactions"
+                ],
+                "summary": "Recupera i tipi di reazione",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GetReactionTypesResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search": {
+            "get": {
+                "description": "Cerca nelle didascalie, nei tag e negli autori delle foto, con filtri per autore, data, fotocamera e tipo di contenuto; restituisce anche i conteggi per filtro su tutti i risultati",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Cerca le foto",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Testo da cercare",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nome di chi ha caricato la foto",
+                        "name": "uploader",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data iniziale (AAAA-MM-GG o RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data finale, inclusa (AAAA-MM-GG o RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fotocamera",
+                        "name": "camera",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tipo di contenuto: image o video",
+                        "name": "media_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Numero pagina (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Elementi per pagina (default: 10, max: 100)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/slideshow": {
+            "get": {
+                "description": "Restituisce la foto da mostrare, il tempo rimanente e le prossime foto; lo stato è condiviso da tutti gli schermi. Le foto mai mostrate compaiono per prime dalla più recente, poi le altre in ordine casuale senza ripetizioni entro repeat_window slide, con una foto fissata ogni pin_every slide.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "slideshow"
+                ],
+                "summary": "Recupera lo stato dello slideshow",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Numero di prossime foto da restituire (default: 10, max: 100)",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SlideshowResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/slideshow/control": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Mette in pausa, riprende, salta la slide corrente, fissa o sblocca una foto, la esclude o la reinserisce nello slideshow; riservato agli amministratori",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "slideshow"
+                ],
+                "summary": "Controlla lo slideshow",
+                "parameters": [
+                    {
+                        "description": "Comando",
+                        "name": "control",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.SlideshowControlRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SlideshowResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/slideshow/settings": {
+            "put": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Modifica la durata delle slide, la frequenza delle foto fissate e la finestra di non ripetizione; riservato agli amministratori",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "slideshow"
+                ],
+                "summary": "Modifica le impostazioni dello slideshow",
+                "parameters": [
+                    {
+                        "description": "Impostazioni da modificare",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.SlideshowSettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SlideshowResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tags": {
+            "get": {
+                "description": "Restituisce i tag e le persone già usati che iniziano con il testo indicato, dai più usati",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Autocompletamento dei tag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Testo iniziale del tag",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tipo: tag o person (default: entrambi)",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Numero massimo di suggerimenti (default: 10, max: 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GetTagSuggestionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Risponde sempre 200 finché il processo è in grado di gestire richieste; non verifica le dipendenze",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness dell'istanza",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.LivenessResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Verifica Redis, scrittura nella directory delle foto, spazio libero su disco e database dei metadati; risponde 503 se una verifica non è superata o se l'istanza è in arresto",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness dell'istanza",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ReadinessResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/model.ReadinessResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "model.AddAlbumRequest": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "cover_photo": {
+                    "description": "Nome della foto di copertina",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "Descrizione dell'album",
+                    "type": "string"
+                },
+                "guest_uploads": {
+                    "description": "Consente l'upload agli ospiti (default: true)",
+                    "type": "boolean"
+                },
+                "title": {
+                    "description": "Titolo dell'album",
+                    "type": "string"
+                }
+            }
+        },
+        "model.AddCommentRequest": {
+            "type": "object",
+            "required": [
+                "body"
+            ],
+            "properties": {
+                "author_name": {
+                    "description": "Nome dell'autore",
+                    "type": "string"
+                },
+                "body": {
+                    "description": "Testo del commento",
+                    "type": "string"
+                },
+                "parent_id": {
+                    "description": "Commento a cui si risponde",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.AddPhotoResponse": {
+            "type": "object",
+            "required": [
+                "photo"
+            ],
+            "properties": {
+                "album_error": {
+                    "description": "Errore nell'aggiunta all'album indicato: la foto è comunque salvata e non va ricaricata",
+                    "$ref": "#/definitions/model.ErrorResponse"
+                },
+                "photo": {
+                    "description": "Nome della foto aggiunta",
+                    "$ref": "#/definitions/model.Photo"
+                }
+            }
+        },
+        "model.AddReactionRequest": {
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "type": {
+                    "description": "Tipo di reazione (es. heart, laugh)",
+                    "type": "string"
+                }
+            }
+        },
+        "model.Album": {
+            "type": "object",
+            "required": [
+                "created_at",
+                "guest_uploads",
+                "id",
+                "photo_count",
+                "title",
+                "updated_at"
+            ],
+            "properties": {
+                "cover_photo": {
+                    "description": "Foto di copertina",
+                    "$ref": "#/definitions/model.Photo"
+                },
+                "created_at": {
+                    "description": "Data di creazione",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "Descrizione dell'album",
+                    "type": "string"
+                },
+                "guest_uploads": {
+                    "description": "Indica se gli ospiti possono caricare foto nell'album",
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "Identificativo dell'album",
+                    "type": "integer"
+                },
+                "photo_count": {
+                    "description": "Numero di foto nell'album",
+                    "type": "integer"
+                },
+                "title": {
+                    "description": "Titolo dell'album",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "Data dell'ultima modifica",
+                    "type": "string"
+                }
+            }
+        },
+        "model.AlbumPhotosRequest": {
+            "type": "object",
+            "required": [
+                "image_names"
+            ],
+            "properties": {
+                "image_names": {
+                    "description": "Nomi delle foto, nell'ordine desiderato",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.AlbumResponse": {
+            "type": "object",
+            "required": [
+                "album"
+            ],
+            "properties": {
+                "album": {
+                    "description": "Album richiesto",
+                    "$ref": "#/definitions/model.Album"
+                }
+            }
+        },
+        "model.Comment": {
+            "type": "object",
+            "required": [
+                "body",
+                "created_at",
+                "id",
+                "is_mine",
+                "replies"
+            ],
+            "properties": {
+                "author_name": {
+                    "description": "Nome dell'autore",
+                    "type": "string"
+                },
+                "body": {
+                    "description": "Testo del commento",
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "Data di creazione",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Identificativo del commento",
+                    "type": "integer"
+                },
+                "is_mine": {
+                    "description": "Indica se il commento è dell'ospite che lo richiede",
+                    "type": "boolean"
+                },
+                "parent_id": {
+                    "description": "Commento a cui risponde",
+                    "type": "integer"
+                },
+                "replies": {
+                    "description": "Risposte al commento",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Comment"
+                    }
+                }
+            }
+        },
+        "model.CommentResponse": {
+            "type": "object",
+            "required": [
+                "comment"
+            ],
+            "properties": {
+                "comment": {
+                    "description": "Commento salvato",
+                    "$ref": "#/definitions/model.Comment"
+                }
+            }
+        },
+        "model.CreateExportRequest": {
+            "type": "object",
+            "properties": {
+                "album_id": {
+                    "description": "Album da esportare, 0 per l'intera galleria",
+                    "type": "integer"
+                },
+                "layout": {
+                    "description": "Struttura delle cartelle: flat (default) o uploader",
+                    "type": "string"
+                },
+                "manifest": {
+                    "description": "Manifest da includere: json, csv o vuoto",
+                    "type": "string"
+                }
+            }
+        },
+        "model.DiskUsage": {
+            "type": "object",
+            "properties": {
+                "free_bytes": {
+                    "description": "Spazio disponibile",
+                    "type": "integer"
+                },
+                "originals_bytes": {
+                    "description": "Spazio occupato dagli originali",
+                    "type": "integer"
+                },
+                "previews_bytes": {
+                    "description": "Spazio occupato dalle preview",
+                    "type": "integer"
+                },
+                "thumbnails_bytes": {
+                    "description": "Spazio occupato dalle thumbnail",
+                    "type": "integer"
+                },
+                "total_bytes": {
+                    "description": "Dimensione del filesystem",
+                    "type": "integer"
+                },
+                "used_bytes": {
+                    "description": "Spazio occupato, anche da altri file",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ErrorResponse": {
+            "type": "object",
+            "required": [
+                "code",
+                "message"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Codice stabile dell'errore, indipendente dalla lingua",
+                    "type": "string",
+                    "example": "photo_not_found"
+                },
+                "details": {
+                    "description": "Informazioni aggiuntive sull'errore, variabili per codice",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "message": {
+                    "description": "Messaggio di errore",
+                    "type": "string"
+                }
+            }
+        },
+        "model.Event": {
+            "type": "object",
+            "required": [
+                "created_at",
+                "id",
+                "type"
+            ],
+            "properties": {
+                "created_at": {
+                    "description": "Data dell'evento",
+                    "type": "string"
+                },
+                "export": {
+                    "description": "Esportazione, per gli eventi sulle esportazioni",
+                    "$ref": "#/definitions/model.ExportJob"
+                },
+                "id": {
+                    "description": "Identificativo crescente, da usare come Last-Event-ID",
+                    "type": "integer"
+                },
+                "image_name": {
+                    "description": "Nome della foto interessata, per gli eventi sulle foto",
+                    "type": "string"
+                },
+                "photo": {
+                    "description": "Foto, se già visibile nella galleria",
+                    "$ref": "#/definitions/model.Photo"
+                },
+                "type": {
+                    "description": "Tipo: photo.added, photo.processed, photo.deleted, photo.approved, export.ready, export.failed",
+                    "type": "string"
+                }
+            }
+        },
+        "model.ExportJob": {
+            "type": "object",
+            "required": [
+                "created_at",
+                "id",
+                "layout",
+                "status"
+            ],
+            "properties": {
+                "album_id": {
+                    "description": "Album esportato, assente per l'intera galleria",
+                    "type": "integer"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "download_url": {
+                    "description": "URL di download, quando pronto",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Motivo del fallimento",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Identificativo dell'esportazione",
+                    "type": "string"
+                },
+                "layout": {
+                    "description": "Struttura delle cartelle: flat o uploader",
+                    "type": "string"
+                },
+                "manifest": {
+                    "description": "Manifest incluso: json o csv",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "Dimensione dell'archivio in byte, quando pronto",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Stato: pending, running, ready, failed",
+                    "type": "string"
+                }
+            }
+        },
+        "model.FacetValue": {
+            "type": "object",
+            "required": [
+                "count",
+                "value"
+            ],
+            "properties": {
+                "count": {
+                    "description": "Numero di foto tra i risultati con questo valore",
+                    "type": "integer"
+                },
+                "value": {
+                    "description": "Valore del filtro",
+                    "type": "string"
+                }
+            }
+        },
+        "model.GetAlbumsResponse": {
+            "type": "object",
+            "required": [
+                "albums"
+            ],
+            "properties": {
+                "albums": {
+                    "description": "Lista degli album",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Album"
+                    }
+                }
+            }
+        },
+        "model.GetCommentsResponse": {
+            "type": "object",
+            "required": [
+                "comments",
+                "total_count"
+            ],
+            "properties": {
+                "comments": {
+                    "description": "Commenti principali, con le risposte annidate",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Comment"
+                    }
+                },
+                "total_count": {
+                    "description": "Numero totale di commenti, risposte incluse",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.GetPhotosResponse": {
+            "type": "object",
+            "required": [
+                "has_more",
+                "page",
+                "photos",
+                "total_count",
+                "total_pages"
+            ],
+            "properties": {
+                "has_more": {
+                    "description": "Indica se ci sono altre foto dopo quelle restituite",
+                    "type": "boolean"
+                },
+                "next_cursor": {
+                    "description": "Cursore da passare in after per le foto successive",
+                    "type": "string"
+                },
+                "page": {
+                    "description": "Pagina corrente, 0 se la richiesta usa un cursore",
+                    "type": "integer"
+                },
+                "photos": {
+                    "description": "Lista delle foto",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Photo"
+                    }
+                },
+                "prev_cursor": {
+                    "description": "Cursore da passare in before per le foto precedenti",
+                    "type": "string"
+                },
+                "total_count": {
+                    "description": "Numero totale di foto",
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "description": "Numero totale di pagine",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.GetReactionTypesResponse": {
+            "type": "object",
+            "required": [
+                "reaction_types"
+            ],
+            "properties": {
+                "reaction_types": {
+                    "description": "Tipi di reazione",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ReactionType"
+                    }
+                }
+            }
+        },
+        "model.GetTagSuggestionsResponse": {
+            "type": "object",
+            "required": [
+                "suggestions"
+            ],
+            "properties": {
+                "suggestions": {
+                    "description": "Tag suggeriti, dai più usati",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TagSuggestion"
+                    }
+                }
+            }
+        },
+        "model.HealthCheck": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "description": "Valori misurati, ad esempio lo spazio libero",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "duration_ms": {
+                    "description": "Durata della verifica, in millisecondi",
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Motivo dell'esito negativo",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Nome della verifica: redis, media, disk o metadata",
+                    "type": "string",
+                    "example": "redis"
+                },
+                "status": {
+                    "description": "ok oppure fail",
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "model.LivenessResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "uptime_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Photo": {
+            "type": "object",
+            "required": [
+                "comment_count",
+                "image_name",
+                "image_url",
+                "media_type",
+                "people",
+                "preview_url",
+                "reactions",
+                "tags",
+                "thumbnail_url"
+            ],
+            "properties": {
+                "camera": {
+                    "description": "Fotocamera, dai dati EXIF",
+                    "type": "string"
+                },
+                "caption": {
+                    "description": "Didascalia inserita da chi ha caricato la foto",
+                    "type": "string"
+                },
+                "comment_count": {
+                    "description": "Numero di commenti",
+                    "type": "integer"
+                },
+                "image_name": {
+                    "description": "Nome dell'immagine",
+                    "type": "string"
+                },
+                "image_url": {
+                    "description": "URL dell'immagine",
+                    "type": "string"
+                },
+                "media_type": {
+                    "description": "Tipo di contenuto (es. image)",
+                    "type": "string"
+                },
+                "people": {
+                    "description": "Persone riconosciute nella foto",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "preview_url": {
+                    "description": "URL dell'anteprima",
+                    "type": "string"
+                },
+                "reactions": {
+                    "description": "Numero di reazioni per tipo",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "tags": {
+                    "description": "Tag liberi",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "taken_at": {
+                    "description": "Data di scatto, dai dati EXIF",
+                    "type": "string"
+                },
+                "thumbnail_url": {
+                    "description": "URL del thumbnail",
+                    "type": "string"
+                },
+                "uploader_name": {
+                    "description": "Nome di chi ha caricato la foto",
+                    "type": "string"
+                }
+            }
         },
-        "model.GetPhotosResponse": {
+        "model.PhotoTagsRequest": {
+            "type": "object",
+            "properties": {
+                "people": {
+                    "description": "Persone da aggiungere",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tags": {
+                    "description": "Tag liberi da aggiungere",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.PhotoTagsResponse": {
+            "type": "object",
+            "required": [
+                "image_name",
+                "people",
+                "tags"
+            ],
+            "properties": {
+                "image_name": {
+                    "description": "Nome della foto",
+                    "type": "string"
+                },
+                "people": {
+                    "description": "Persone riconosciute nella foto",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tags": {
+                    "description": "Tag liberi",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.RateLimit": {
+            "type": "object",
+            "required": [
+                "budget",
+                "ip_requests",
+                "period_seconds",
+                "requests"
+            ],
+            "properties": {
+                "budget": {
+                    "description": "Budget: upload o read",
+                    "type": "string",
+                    "example": "upload"
+                },
+                "ip_requests": {
+                    "description": "Richieste consentite per indirizzo IP nel periodo",
+                    "type": "integer"
+                },
+                "overridden": {
+                    "description": "true se il limite è stato modificato rispetto alla configurazione",
+                    "type": "boolean"
+                },
+                "period_seconds": {
+                    "description": "Durata del periodo, in secondi",
+                    "type": "integer"
+                },
+                "requests": {
+                    "description": "Richieste consentite per ospite nel periodo",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.RateLimitsResponse": {
+            "type": "object",
+            "required": [
+                "limits"
+            ],
+            "properties": {
+                "limits": {
+                    "description": "Limiti per budget",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.RateLimit"
+                    }
+                }
+            }
+        },
+        "model.ReactionType": {
+            "type": "object",
+            "required": [
+                "emoji",
+                "type"
+            ],
+            "properties": {
+                "emoji": {
+                    "description": "Emoji da mostrare",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Identificativo della reazione",
+                    "type": "string"
+                }
+            }
+        },
+        "model.ReactionsResponse": {
+            "type": "object",
+            "required": [
+                "image_name",
+                "reactions"
+            ],
+            "properties": {
+                "image_name": {
+                    "description": "Nome della foto",
+                    "type": "string"
+                },
+                "reactions": {
+                    "description": "Numero di reazioni per tipo",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "model.ReadinessResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "Esito delle singole verifiche, assente durante l'arresto",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.HealthCheck"
+                    }
+                },
+                "status": {
+                    "description": "ready, not_ready oppure shutting_down",
+                    "type": "string",
+                    "example": "ready"
+                }
+            }
+        },
+        "model.SearchFacets": {
+            "type": "object",
+            "required": [
+                "cameras",
+                "media_types",
+                "tags",
+                "uploaders"
+            ],
+            "properties": {
+                "cameras": {
+                    "description": "Fotocamere",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.FacetValue"
+                    }
+                },
+                "media_types": {
+                    "description": "Tipi di contenuto",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.FacetValue"
+                    }
+                },
+                "tags": {
+                    "description": "Tag liberi",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.FacetValue"
+                    }
+                },
+                "uploaders": {
+                    "description": "Autori delle foto",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.FacetValue"
+                    }
+                }
+            }
+        },
+        "model.SearchResponse": {
             "type": "object",
             "required": [
+                "facets",
                 "page",
                 "photos",
+                "total_count",
                 "total_pages"
             ],
             "properties": {
+                "facets": {
+                    "description": "Conteggi per filtro su tutti i risultati",
+                    "$ref": "#/definitions/model.SearchFacets"
+                },
                 "page": {
                     "description": "Pagina corrente",
                     "type": "integer"
                 },
                 "photos": {
-                    "description": "Lista delle foto",
+                    "description": "Foto trovate nella pagina corrente",
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/model.Photo"
                     }
                 },
+                "total_count": {
+                    "description": "Numero totale di foto trovate",
+                    "type": "integer"
+                },
                 "total_pages": {
                     "description": "Numero totale di pagine",
                     "type": "integer"
                 }
             }
         },
-        "model.Photo": {
+        "model.SlideshowControlRequest": {
             "type": "object",
             "required": [
-                "image_name",
-                "image_url",
-                "preview_url",
-                "thumbnail_url"
+                "action"
             ],
             "properties": {
+                "action": {
+                    "description": "Comando: pause, resume, skip, pin, unpin, hide, unhide",
+                    "type": "string"
+                },
                 "image_name": {
-                    "description": "Nome dell'immagine",
+                    "description": "Foto per pin, unpin, hide e unhide; se assente la foto corrente",
                     "type": "string"
+                }
+            }
+        },
+        "model.SlideshowResponse": {
+            "type": "object",
+            "required": [
+                "hidden",
+                "paused",
+                "pin_every",
+                "pinned",
+                "remaining_ms",
+                "repeat_window",
+                "slide_duration",
+                "upcoming"
+            ],
+            "properties": {
+                "current": {
+                    "description": "Foto da mostrare, assente se non ci sono foto",
+                    "$ref": "#/definitions/model.Photo"
                 },
-                "image_url": {
-                    "description": "URL dell'immagine",
+                "hidden": {
+                    "description": "Foto escluse dallo slideshow",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "paused": {
+                    "description": "Slideshow in pausa",
+                    "type": "boolean"
+                },
+                "pin_every": {
+                    "description": "Ogni quante slide viene mostrata una foto fissata",
+                    "type": "integer"
+                },
+                "pinned": {
+                    "description": "Foto fissate",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "remaining_ms": {
+                    "description": "Millisecondi al cambio di slide",
+                    "type": "integer"
+                },
+                "repeat_window": {
+                    "description": "Numero di slide entro cui una foto non viene ripetuta",
+                    "type": "integer"
+                },
+                "slide_duration": {
+                    "description": "Durata di ogni slide, in secondi",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "description": "Inizio della slide corrente",
                     "type": "string"
                 },
-                "preview_url": {
-                    "description": "URL dell'anteprima",
+                "upcoming": {
+                    "description": "Prossime foto previste",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Photo"
+                    }
+                }
+            }
+        },
+        "model.SlideshowSettingsRequest": {
+            "type": "object",
+            "properties": {
+                "pin_every": {
+                    "description": "Ogni quante slide viene mostrata una foto fissata, 0 per non mostrarle",
+                    "type": "integer"
+                },
+                "repeat_window": {
+                    "description": "Numero di slide entro cui una foto non viene ripetuta",
+                    "type": "integer"
+                },
+                "slide_duration": {
+                    "description": "Durata di ogni slide, in secondi",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.StatusResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "Esito delle verifiche di prontezza",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.HealthCheck"
+                    }
+                },
+                "disk": {
+                    "description": "Spazio su disco, assente se non disponibile",
+                    "$ref": "#/definitions/model.DiskUsage"
+                },
+                "pending_renditions": {
+                    "description": "Foto di cui thumbnail e preview non sono ancora pronte",
+                    "type": "integer"
+                },
+                "queue_lanes": {
+                    "description": "Immagini in coda per corsia: interactive, bulk, background",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "queue_length": {
+                    "description": "Immagini in coda di elaborazione, -1 se la coda non è raggiungibile",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Stato di prontezza, come in /readyz",
+                    "type": "string",
+                    "example": "ready"
+                },
+                "uptime_seconds": {
+                    "type": "integer"
+                },
+                "worker_count": {
+                    "description": "Numero di worker attivi",
+                    "type": "integer"
+                },
+                "workers": {
+                    "description": "Worker in background attivi in questa istanza",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.TagSuggestion": {
+            "type": "object",
+            "required": [
+                "kind",
+                "name",
+                "photo_count"
+            ],
+            "properties": {
+                "kind": {
+                    "description": "Tipo: tag o person",
                     "type": "string"
                 },
-                "thumbnail_url": {
-                    "description": "URL del thumbnail",
+                "name": {
+                    "description": "Nome del tag",
+                    "type": "string"
+                },
+                "photo_count": {
+                    "description": "Numero di foto con il tag",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.UpdateAlbumRequest": {
+            "type": "object",
+            "properties": {
+                "cover_photo": {
+                    "description": "Nome della foto di copertina, vuoto per usare la prima foto",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "Descrizione dell'album",
+                    "type": "string"
+                },
+                "guest_uploads": {
+                    "description": "Consente l'upload agli ospiti",
+                    "type": "boolean"
+                },
+                "title": {
+                    "description": "Titolo dell'album",
                     "type": "string"
                 }
             }
+        },
+        "model.UpdateRateLimitRequest": {
+            "type": "object",
+            "required": [
+                "period_seconds",
+                "requests"
+            ],
+            "properties": {
+                "period_seconds": {
+                    "description": "Durata del periodo, in secondi",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "requests": {
+                    "description": "Richieste consentite per ospite nel periodo",
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -16,14 +16,19 @@ while true; do
     # Trova i file creati/modificati negli ultimi 240 minuti
     while IFS= read -r file; do
         filename=$(basename "$file")
-        previews_path="$PREVIEWS_DIR/$filename"
-        thumbnails_path="$THUMBNAILS_DIR/$filename"
+        # Come il backend, salva in JPEG le versioni ridotte delle foto WebP aggiungendo .jpg al nome
+        rendition="$filename"
+        if [[ "${filename,,}" == *.webp ]]; then
+            rendition="$filename.jpg"
+        fi
+        previews_path="$PREVIEWS_DIR/$rendition"
+        thumbnails_path="$THUMBNAILS_DIR/$rendition"
         file_path="$file"
 
         # Crea preview se non esiste
         if [[ ! -f "$previews_path" ]]; then
             echo "Creando preview per: $filename"
-            vipsthumbnail "$file_path" -s 1024x1024 -o "previews/$filename"
+            vipsthumbnail "$file_path" -s 1024x1024 -o "previews/$rendition"
             if [[ $? -eq 0 ]]; then
                 echo "Preview creata con successo: $filename"
             else
@@ -35,7 +40,7 @@ while true; do
         # Crea thumbnail se non esiste
         if [[ ! -f "$thumbnails_path" ]]; then
             echo "Creando thumbnail per: $filename"
-            vipsthumbnail "$file_path" -s 400x400 -o "thumbnails/$filename"
+            vipsthumbnail "$file_path" -s 400x400 -o "thumbnails/$rendition"
             if [[ $? -eq 0 ]]; then
                 echo "Thumbnail creata con successo: $filename"
             else
@@ -0,0 +1,157 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+
+	"wedding-photo-backend/internal/weddingphoto/model"
+)
+
+// Kind classifica un errore applicativo; ogni categoria corrisponde a uno status HTTP
+type Kind int
+
+const (
+	// KindInternal indica un errore imprevisto del server
+	KindInternal Kind = iota
+	// KindInvalid indica una richiesta con dati non validi
+	KindInvalid
+	// KindUnauthorized indica una richiesta senza le credenziali necessarie
+	KindUnauthorized
+	// KindForbidden indica un'operazione non consentita a chi la richiede
+	KindForbidden
+	// KindNotFound indica una risorsa inesistente
+	KindNotFound
+	// KindConflict indica un'operazione incompatibile con lo stato attuale della risorsa
+	KindConflict
+	// KindGone indica una risorsa non più disponibile
+	KindGone
+	// KindTooLarge indica un contenuto oltre i limiti consentiti
+	KindTooLarge
+	// KindUnsupported indica un formato di contenuto non supportato
+	KindUnsupported
+	// KindRangeNotSatisfiable indica un intervallo di byte non valido per la risorsa
+	KindRangeNotSatisfiable
+	// KindTooManyRequests indica un client che ha superato il limite di richieste
+	KindTooManyRequests
+	// KindUnavailable indica un servizio temporaneamente non disponibile
+	KindUnavailable
+)
+
+// CodeInternal è il codice restituito per gli errori non classificati
+const CodeInternal = "internal_error"
+
+// statusByKind traduce le categorie di errore negli status HTTP
+var statusByKind = map[Kind]int{
+	KindInternal:            http.StatusInternalServerError,
+	KindInvalid:             http.StatusBadRequest,
+	KindUnauthorized:        http.StatusUnauthorized,
+	KindForbidden:           http.StatusForbidden,
+	KindNotFound:            http.StatusNotFound,
+	KindConflict:            http.StatusConflict,
+	KindGone:                http.StatusGone,
+	KindTooLarge:            http.StatusRequestEntityTooLarge,
+	KindUnsupported:         http.StatusUnsupportedMediaType,
+	KindRangeNotSatisfiable: http.StatusRequestedRangeNotSatisfiable,
+	KindTooManyRequests:     http.StatusTooManyRequests,
+	KindUnavailable:         http.StatusServiceUnavailable,
+}
+
+// Error è un errore applicativo con un codice stabile, indipendente dal messaggio,
+// che i client possono usare per riconoscere l'errore e tradurne il messaggio
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+// New crea un errore applicativo, da usare come errore sentinella con errors.Is
+func New(kind Kind, code, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// Error restituisce il messaggio dell'errore
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// detailedError aggiunge a un errore informazioni strutturate per il client
+type detailedError struct {
+	err     error
+	details map[string]any
+}
+
+func (de *detailedError) Error() string {
+	return de.err.Error()
+}
+
+func (de *detailedError) Unwrap() error {
+	return de.err
+}
+
+// WithDetails aggiunge dettagli a un errore mantenendone messaggio e codice
+func WithDetails(err error, details map[string]any) error {
+	if err == nil || len(details) == 0 {
+		return err
+	}
+	return &detailedError{err: err, details: details}
+}
+
+// Details restituisce i dettagli aggiunti con WithDetails lungo tutta la catena dell'errore
+func Details(err error) map[string]any {
+	var details map[string]any
+	for err != nil {
+		if de, ok := err.(*detailedError); ok {
+			for key, value := range de.details {
+				if details == nil {
+					details = map[string]any{}
+				}
+				// I dettagli aggiunti più in alto nella catena hanno la precedenza
+				if _, exists := details[key]; !exists {
+					details[key] = value
+				}
+			}
+		}
+		err = errors.Unwrap(err)
+	}
+	return details
+}
+
+// Lookup restituisce l'errore applicativo contenuto in err, se presente
+func Lookup(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// Status restituisce lo status HTTP corrispondente all'errore, 500 per gli errori non classificati
+func Status(err error) int {
+	if appErr, ok := Lookup(err); ok {
+		if status, ok := statusByKind[appErr.Kind]; ok {
+			return status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// Code restituisce il codice dell'errore, CodeInternal per gli errori non classificati
+func Code(err error) string {
+	if appErr, ok := Lookup(err); ok {
+		return appErr.Code
+	}
+	return CodeInternal
+}
+
+// Response costruisce il corpo della risposta HTTP per un errore
+func Response(err error) model.ErrorResponse {
+	return model.ErrorResponse{
+		Code:    Code(err),
+		Message: err.Error(),
+		Details: Details(err),
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ByteSize è una dimensione in byte, indicata nella configurazione come 1048576, 512KB, 50MB o 1GiB
+type ByteSize int64
+
+// byteUnits associa i suffissi ammessi al numero di byte; KB, MB e GB sono multipli di 1024 come KiB, MiB e GiB
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+	{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize interpreta una dimensione con unità facoltativa, ad esempio 50MB o 1.5GB
+func ParseByteSize(value string) (ByteSize, error) {
+	text := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(text, unit.suffix) {
+			text = strings.TrimSpace(strings.TrimSuffix(text, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil || number < 0 || math.IsInf(number, 0) || number*float64(multiplier) > math.MaxInt64 {
+		return 0, fmt.Errorf("dimensione %q non valida, usare ad esempio 512KB, 50MB o 1GB", value)
+	}
+	return ByteSize(number * float64(multiplier)), nil
+}
+
+// String restituisce la dimensione con l'unità più grande che la rappresenta senza decimali
+func (bs ByteSize) String() string {
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}} {
+		if bs != 0 && int64(bs)%unit.size == 0 {
+			return strconv.FormatInt(int64(bs)/unit.size, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(int64(bs), 10) + "B"
+}
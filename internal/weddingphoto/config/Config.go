@@ -0,0 +1,280 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/logging"
+	"wedding-photo-backend/internal/weddingphoto/tracing"
+)
+
+const (
+	// QueueBackendRedis conserva la coda di elaborazione in Redis, condivisa tra più istanze e worker esterni
+	QueueBackendRedis = "redis"
+	// QueueBackendMemory conserva la coda nel processo, salvando i job su disco per non perderli al riavvio
+	QueueBackendMemory = "memory"
+)
+
+// Config contiene la configurazione del server. Ogni campo ha una chiave per il file di configurazione
+// (tag key, annidata nella sezione), la variabile d'ambiente corrispondente (tag env), il valore di
+// default e, per token e password, il tag secret che ne nasconde il valore in "config print"
+type Config struct {
+	Server     ServerConfig     `key:"server"`
+	Storage    StorageConfig    `key:"storage"`
+	Redis      RedisConfig      `key:"redis"`
+	Queue      QueueConfig      `key:"queue"`
+	Admin      AdminConfig      `key:"admin"`
+	Upload     UploadConfig     `key:"upload"`
+	Moderation ModerationConfig `key:"moderation"`
+	Media      MediaConfig      `key:"media"`
+	RateLimit  RateLimitConfig  `key:"rate_limit"`
+	Cors       CorsConfig       `key:"cors"`
+	Health     HealthConfig     `key:"health"`
+	Metrics    MetricsConfig    `key:"metrics"`
+	Log        LogConfig        `key:"log"`
+	Tracing    TracingConfig    `key:"tracing"`
+
+	// file è il file di configurazione letto, sources l'origine del valore di ogni chiave,
+	// invalid le chiavi con un valore che non è stato possibile interpretare e checks le verifiche
+	// indicate in Options
+	file    string
+	sources map[string]Source
+	invalid map[string]bool
+	checks  []Check
+}
+
+// Check verifica il valore di una chiave con le regole del package che lo interpreta, ad esempio il
+// formato delle chiavi di firma degli URL, senza che config dipenda da quel package
+type Check struct {
+	Key   string
+	Check func(c *Config) error
+}
+
+// ServerConfig contiene indirizzo e URL pubblico del server HTTP, i proxy fidati e i tempi dell'arresto.
+// Solo dai proxy fidati si accettano X-Forwarded-For e X-Real-IP come indirizzo del client
+type ServerConfig struct {
+	Host            string        `key:"host" env:"HOST" default:"0.0.0.0"`
+	Port            int           `key:"port" env:"PORT" default:"8739"`
+	BaseURL         string        `key:"base_url" env:"BASE_URL" default:"http://localhost:8739"`
+	TrustedProxies  []string      `key:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	ShutdownDelay   time.Duration `key:"shutdown_delay" env:"SHUTDOWN_DELAY" default:"0s"`
+	ShutdownTimeout time.Duration `key:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"30s"`
+}
+
+// StorageConfig contiene le directory di foto, database e traduzioni aggiuntive
+type StorageConfig struct {
+	PhotosDir  string `key:"photos_dir" env:"PHOTOS_DIR" default:"media"`
+	DataDir    string `key:"data_dir" env:"DATA_DIR" default:"data"`
+	LocalesDir string `key:"locales_dir" env:"LOCALES_DIR"`
+}
+
+// RedisConfig contiene la connessione a Redis
+type RedisConfig struct {
+	Addr     string `key:"addr" env:"REDIS_ADDR" default:"localhost:6379"`
+	Password string `key:"password" env:"REDIS_PASSWORD" secret:"true"`
+	DB       int    `key:"db" env:"REDIS_DB" default:"0"`
+}
+
+// QueueConfig contiene la coda di elaborazione delle foto: redis, condivisa tra più istanze, o memory,
+// interna al processo e salvata su disco, per le installazioni con una sola istanza
+type QueueConfig struct {
+	Backend string `key:"backend" env:"QUEUE_BACKEND" default:"redis"`
+	Dir     string `key:"dir" env:"QUEUE_DIR"`
+	Workers int    `key:"workers" env:"QUEUE_WORKERS" default:"1"`
+}
+
+// AdminConfig contiene il token degli amministratori, vuoto per disattivare l'autenticazione
+type AdminConfig struct {
+	Token string `key:"token" env:"ADMIN_TOKEN" secret:"true"`
+}
+
+// UploadConfig contiene i limiti dei caricamenti
+type UploadConfig struct {
+	MaxSize ByteSize `key:"max_size" env:"UPLOAD_MAX_SIZE" default:"50MB"`
+}
+
+// ModerationConfig contiene il filtro dei testi e l'approvazione delle foto
+type ModerationConfig struct {
+	BlockedWords    []string `key:"blocked_words" env:"BLOCKED_WORDS"`
+	RequireApproval bool     `key:"require_approval" env:"REQUIRE_APPROVAL" default:"false"`
+}
+
+// MediaConfig contiene l'accesso agli originali e la firma degli URL di thumbnail e preview
+type MediaConfig struct {
+	AllowOriginalDownload bool          `key:"allow_original_download" env:"ALLOW_ORIGINAL_DOWNLOAD" default:"true"`
+	SigningKeys           string        `key:"signing_keys" env:"MEDIA_SIGNING_KEYS" secret:"true"`
+	URLTTL                time.Duration `key:"url_ttl" env:"MEDIA_URL_TTL" default:"12h"`
+}
+
+// RateLimitConfig contiene i limiti di richieste per ospite nel formato richieste/durata, "off" per disattivarli
+type RateLimitConfig struct {
+	Upload       string `key:"upload" env:"RATE_LIMIT_UPLOAD" default:"30/10m"`
+	Read         string `key:"read" env:"RATE_LIMIT_READ" default:"300/1m"`
+	IPMultiplier int    `key:"ip_multiplier" env:"RATE_LIMIT_IP_MULTIPLIER" default:"10"`
+}
+
+// CorsConfig contiene le origini autorizzate per le API e per i media
+type CorsConfig struct {
+	AllowedOrigins      []string      `key:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" default:"*"`
+	AllowCredentials    bool          `key:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" default:"false"`
+	AllowedHeaders      []string      `key:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders      []string      `key:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	MaxAge              time.Duration `key:"max_age" env:"CORS_MAX_AGE" default:"10m"`
+	MediaAllowedOrigins []string      `key:"media_allowed_origins" env:"CORS_MEDIA_ALLOWED_ORIGINS" default:"*"`
+}
+
+// HealthConfig contiene le soglie delle verifiche di prontezza
+type HealthConfig struct {
+	MinFreeDisk  ByteSize      `key:"min_free_disk" env:"HEALTH_MIN_FREE_DISK" default:"1GB"`
+	CheckTimeout time.Duration `key:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+}
+
+// MetricsConfig contiene l'esposizione delle metriche Prometheus su /metrics
+type MetricsConfig struct {
+	Enabled bool   `key:"enabled" env:"METRICS_ENABLED" default:"true"`
+	Token   string `key:"token" env:"METRICS_TOKEN" secret:"true"`
+}
+
+// LogConfig contiene il livello minimo e il formato dei log
+type LogConfig struct {
+	Level  string `key:"level" env:"LOG_LEVEL" default:"info"`
+	Format string `key:"format" env:"LOG_FORMAT" default:"json"`
+}
+
+// TracingConfig contiene l'esportazione delle tracce OpenTelemetry: none, otlp verso un collector
+// o stdout
+type TracingConfig struct {
+	Exporter    string  `key:"exporter" env:"TRACING_EXPORTER" default:"none"`
+	Endpoint    string  `key:"endpoint" env:"TRACING_OTLP_ENDPOINT" default:"localhost:4318"`
+	Insecure    bool    `key:"insecure" env:"TRACING_OTLP_INSECURE" default:"true"`
+	SampleRatio float64 `key:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1"`
+	ServiceName string  `key:"service_name" env:"TRACING_SERVICE_NAME" default:"wedding-photo-backend"`
+}
+
+// Address restituisce l'indirizzo su cui il server resta in ascolto
+func (sc ServerConfig) Address() string {
+	return fmt.Sprintf("%s:%d", sc.Host, sc.Port)
+}
+
+// Directory restituisce la directory dei job della coda memory, per default la sottodirectory queue
+// della directory dei dati
+func (qc QueueConfig) Directory(dataDir string) string {
+	if qc.Dir != "" {
+		return qc.Dir
+	}
+	return filepath.Join(dataDir, "queue")
+}
+
+// Validate verifica la configurazione e restituisce un errore che elenca tutti i problemi trovati,
+// tralasciando le chiavi con valori già segnalati come non validi da Load
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(key, format string, args ...any) {
+		if c.invalid[key] {
+			return
+		}
+		problems = append(problems, c.describe(key)+": "+fmt.Sprintf(format, args...))
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		add("server.port", "la porta deve essere compresa tra 1 e 65535")
+	}
+	if parsed, err := url.Parse(c.Server.BaseURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		add("server.base_url", "URL %q non valido, formato atteso http(s)://dominio[:porta][/percorso]", c.Server.BaseURL)
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			add("server.trusted_proxies", "proxy %q non valido, indicare un indirizzo IP o una rete CIDR", proxy)
+		}
+	}
+	if c.Server.ShutdownDelay < 0 {
+		add("server.shutdown_delay", "l'attesa prima dell'arresto non può essere negativa")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		add("server.shutdown_timeout", "il tempo massimo per l'arresto deve essere positivo")
+	}
+	if c.Storage.PhotosDir == "" {
+		add("storage.photos_dir", "la directory delle foto è obbligatoria")
+	}
+	if c.Storage.DataDir == "" {
+		add("storage.data_dir", "la directory dei dati è obbligatoria")
+	}
+	if c.Redis.Addr == "" {
+		add("redis.addr", "l'indirizzo di Redis è obbligatorio")
+	}
+	if c.Redis.DB < 0 {
+		add("redis.db", "il database di Redis non può essere negativo")
+	}
+	switch c.Queue.Backend {
+	case QueueBackendRedis, QueueBackendMemory:
+	default:
+		add("queue.backend", "coda %q non valida, valori ammessi redis, memory", c.Queue.Backend)
+	}
+	if c.Queue.Workers < 0 {
+		add("queue.workers", "il numero di worker non può essere negativo")
+	}
+	if c.Upload.MaxSize <= 0 {
+		add("upload.max_size", "la dimensione massima deve essere positiva")
+	}
+
+	if c.Media.URLTTL <= 0 {
+		add("media.url_ttl", "la durata degli URL firmati deve essere positiva")
+	}
+	if c.RateLimit.IPMultiplier < 1 {
+		add("rate_limit.ip_multiplier", "il moltiplicatore deve essere un numero intero positivo")
+	}
+
+	if c.Health.MinFreeDisk < 0 {
+		add("health.min_free_disk", "lo spazio libero minimo non può essere negativo")
+	}
+	if c.Health.CheckTimeout <= 0 {
+		add("health.check_timeout", "il tempo massimo delle verifiche deve essere positivo")
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		add("log.level", "%v", err)
+	}
+	if c.Log.Format != logging.FormatJSON && c.Log.Format != logging.FormatText {
+		add("log.format", "formato %q non valido, valori ammessi json, text", c.Log.Format)
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout:
+	case tracing.ExporterOTLP:
+		if c.Tracing.Endpoint == "" {
+			add("tracing.endpoint", "l'indirizzo del collector è obbligatorio con l'exporter otlp")
+		}
+	default:
+		add("tracing.exporter", "exporter %q non valido, valori ammessi none, otlp, stdout", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio", "la frazione delle tracce registrate deve essere compresa tra 0 e 1")
+	}
+	if c.Tracing.ServiceName == "" {
+		add("tracing.service_name", "il nome del servizio è obbligatorio")
+	}
+
+	for _, check := range c.checks {
+		if err := check.Check(c); err != nil {
+			add(check.Key, "%v", err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ValidationError elenca tutti i problemi trovati nella configurazione
+type ValidationError struct {
+	Problems []string
+}
+
+func (ve *ValidationError) Error() string {
+	return "configurazione non valida:\n  - " + strings.Join(ve.Problems, "\n  - ")
+}
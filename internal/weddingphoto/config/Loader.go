@@ -0,0 +1,285 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/util"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Source indica da dove proviene il valore di una chiave
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceDotEnv  Source = ".env"
+	SourceEnv     Source = "env"
+)
+
+// DefaultFiles sono i file di configurazione cercati nella directory corrente se CONFIG_FILE non è impostato
+var DefaultFiles = []string{"config.yaml", "config.yml", "config.toml"}
+
+// Options indica i file da cui leggere la configurazione; i campi vuoti usano i valori di default
+type Options struct {
+	// File è il file YAML o TOML, altrimenti CONFIG_FILE o il primo tra DefaultFiles presente
+	File string
+	// DotEnv è il file con le variabili d'ambiente, .env se vuoto
+	DotEnv string
+	// Checks sono le verifiche dei valori interpretati da altri package, eseguite da Validate insieme
+	// alle altre
+	Checks []Check
+}
+
+// field è un campo della configurazione con la chiave completa e i metadati dei tag
+type field struct {
+	key    string
+	env    string
+	def    string
+	secret bool
+	value  reflect.Value
+}
+
+// Load legge la configurazione applicando in ordine i valori di default, il file di configurazione,
+// il file .env e le variabili d'ambiente; le variabili vuote sono ignorate. La configurazione viene
+// poi verificata con Validate: se ci sono problemi l'errore è un *ValidationError che li elenca tutti,
+// e la configurazione restituita contiene comunque i valori letti correttamente
+func Load(opts Options) (*Config, error) {
+	cfg := &Config{sources: map[string]Source{}, invalid: map[string]bool{}, checks: opts.Checks}
+	var problems []string
+
+	dotEnvPath := opts.DotEnv
+	if dotEnvPath == "" {
+		dotEnvPath = ".env"
+	}
+	dotEnv, err := godotenv.Read(dotEnvPath)
+	if err != nil {
+		if opts.DotEnv != "" || !errors.Is(err, os.ErrNotExist) {
+			problems = append(problems, fmt.Sprintf("impossibile leggere %s: %v", dotEnvPath, err))
+		}
+		dotEnv = map[string]string{}
+	}
+	lookup := func(name string) (string, Source, bool) {
+		if value := os.Getenv(name); value != "" {
+			return value, SourceEnv, true
+		}
+		if value := dotEnv[name]; value != "" {
+			return value, SourceDotEnv, true
+		}
+		return "", "", false
+	}
+
+	cfg.file = opts.File
+	if cfg.file == "" {
+		cfg.file, _, _ = lookup("CONFIG_FILE")
+	}
+	if cfg.file == "" {
+		for _, candidate := range DefaultFiles {
+			if _, err := os.Stat(candidate); err == nil {
+				cfg.file = candidate
+				break
+			}
+		}
+	}
+	fileValues := map[string]string{}
+	if cfg.file != "" {
+		if fileValues, err = readFile(cfg.file); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	fields := cfg.fields()
+	known := map[string]bool{}
+	for _, f := range fields {
+		known[f.key] = true
+
+		raw, source := f.def, SourceDefault
+		if value, ok := fileValues[f.key]; ok {
+			raw, source = value, SourceFile
+		}
+		if value, envSource, ok := lookup(f.env); ok {
+			raw, source = value, envSource
+		}
+
+		if err := setValue(f.value, raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: valore %q non valido (%s): %v", cfg.describe(f.key), raw, source, err))
+			cfg.invalid[f.key] = true
+			continue
+		}
+		cfg.sources[f.key] = source
+	}
+
+	var unknown []string
+	for key := range fileValues {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		problems = append(problems, fmt.Sprintf("%s: chiave %q sconosciuta", cfg.file, key))
+	}
+
+	var validationErr *ValidationError
+	if errors.As(cfg.Validate(), &validationErr) {
+		problems = append(problems, validationErr.Problems...)
+	}
+
+	if len(problems) > 0 {
+		return cfg, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// File restituisce il file di configurazione letto, vuoto se non ce n'è uno
+func (c *Config) File() string {
+	return c.file
+}
+
+// fields elenca i campi della configurazione nell'ordine di dichiarazione
+func (c *Config) fields() []field {
+	var fields []field
+	var walk func(value reflect.Value, prefix string)
+	walk = func(value reflect.Value, prefix string) {
+		for i := 0; i < value.NumField(); i++ {
+			structField := value.Type().Field(i)
+			key, ok := structField.Tag.Lookup("key")
+			if !ok {
+				continue
+			}
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			if structField.Type.Kind() == reflect.Struct {
+				walk(value.Field(i), key)
+				continue
+			}
+			fields = append(fields, field{
+				key:    key,
+				env:    structField.Tag.Get("env"),
+				def:    structField.Tag.Get("default"),
+				secret: structField.Tag.Get("secret") == "true",
+				value:  value.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(c).Elem(), "")
+	return fields
+}
+
+// describe restituisce la chiave seguita dalla variabile d'ambiente corrispondente
+func (c *Config) describe(key string) string {
+	for _, f := range c.fields() {
+		if f.key == key && f.env != "" {
+			return fmt.Sprintf("%s (%s)", key, f.env)
+		}
+	}
+	return key
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	byteSizeType = reflect.TypeOf(ByteSize(0))
+)
+
+// setValue converte il testo nel tipo del campo
+func setValue(value reflect.Value, raw string) error {
+	switch {
+	case value.Type() == durationType:
+		duration, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			return errors.New("durata non valida, usare ad esempio 30s, 10m o 12h")
+		}
+		value.SetInt(int64(duration))
+	case value.Type() == byteSizeType:
+		size, err := ParseByteSize(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(size))
+	case value.Kind() == reflect.String:
+		value.SetString(raw)
+	case value.Kind() == reflect.Int:
+		number, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return errors.New("atteso un numero intero")
+		}
+		value.SetInt(int64(number))
+	case value.Kind() == reflect.Float64:
+		number, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return errors.New("atteso un numero")
+		}
+		value.SetFloat(number)
+	case value.Kind() == reflect.Bool:
+		flag, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return errors.New("atteso true o false")
+		}
+		value.SetBool(flag)
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String:
+		value.Set(reflect.ValueOf(util.SplitList(raw)))
+	default:
+		return fmt.Errorf("tipo %s non supportato", value.Type())
+	}
+	return nil
+}
+
+// readFile legge un file YAML o TOML e ne restituisce i valori per chiave completa (sezione.chiave)
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("impossibile leggere il file di configurazione %s: %w", path, err)
+	}
+
+	document := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &document)
+	case ".toml":
+		err = toml.Unmarshal(data, &document)
+	default:
+		return nil, fmt.Errorf("file di configurazione %s non supportato, usare .yaml, .yml o .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("file di configurazione %s non valido: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flatten(document, "", values)
+	return values, nil
+}
+
+// flatten converte le sezioni annidate in chiavi separate da punto e gli elenchi in testo separato da virgole
+func flatten(document map[string]any, prefix string, values map[string]string) {
+	for key, value := range document {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch typed := value.(type) {
+		case map[string]any:
+			flatten(typed, key, values)
+		case []any:
+			items := make([]string, len(typed))
+			for i, item := range typed {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(typed)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redacted sostituisce il valore dei segreti impostati
+const redacted = "********"
+
+// Print scrive la configurazione effettiva in formato TOML, riutilizzabile come file di configurazione;
+// ogni valore è seguito dalla sua origine e i segreti sono nascosti
+func (c *Config) Print(w io.Writer) error {
+	var out strings.Builder
+	if c.file != "" {
+		fmt.Fprintf(&out, "# file di configurazione: %s\n", c.file)
+	}
+
+	section := ""
+	for _, f := range c.fields() {
+		prefix, name, _ := strings.Cut(f.key, ".")
+		if prefix != section {
+			section = prefix
+			fmt.Fprintf(&out, "\n[%s]\n", section)
+		}
+
+		value := formatValue(f.value.Interface())
+		if f.secret && !f.value.IsZero() {
+			value = strconv.Quote(redacted)
+		}
+
+		source := c.sources[f.key]
+		if source == "" {
+			source = "non valido"
+		}
+		comment := string(source)
+		if f.env != "" {
+			comment += ", " + f.env
+		}
+		fmt.Fprintf(&out, "%s = %s # %s\n", name, value, comment)
+	}
+
+	_, err := io.WriteString(w, strings.TrimPrefix(out.String(), "\n"))
+	return err
+}
+
+// formatValue scrive un valore nella sintassi TOML
+func formatValue(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return strconv.Quote(typed)
+	case time.Duration:
+		return strconv.Quote(typed.String())
+	case ByteSize:
+		return strconv.Quote(typed.String())
+	case []string:
+		items := make([]string, len(typed))
+		for i, item := range typed {
+			items[i] = strconv.Quote(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(typed)
+	}
+}
@@ -0,0 +1,315 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"wedding-photo-backend/internal/weddingphoto/middleware"
+	"wedding-photo-backend/internal/weddingphoto/model"
+	"wedding-photo-backend/internal/weddingphoto/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlbumController gestisce le operazioni sugli album
+type AlbumController struct {
+	albumService *service.AlbumService
+	adminAuth    *middleware.AdminAuth
+}
+
+// NewAlbumController crea una nuova istanza del controller
+func NewAlbumController(albumService *service.AlbumService, adminAuth *middleware.AdminAuth) *AlbumController {
+	return &AlbumController{
+		albumService: albumService,
+		adminAuth:    adminAuth,
+	}
+}
+
+// GetAlbums restituisce la lista degli album
+// @Summary Recupera la lista degli album
+// @Description Ottiene tutti gli album dell'evento con la relativa copertina
+// @Tags albums
+// @Produce json
+// @Success 200 {object} model.GetAlbumsResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/albums [get]
+func (ac *AlbumController) GetAlbums(c *gin.Context) {
+	albums, err := ac.albumService.GetAlbums()
+	if err != nil {
+		respondError(c, fmt.Errorf("Errore nel recupero degli album: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.GetAlbumsResponse{
+		Albums: albums,
+	})
+}
+
+// GetAlbum restituisce un singolo album
+// @Summary Recupera un album
+// @Tags albums
+// @Produce json
+// @Param id path int true "Identificativo dell'album"
+// @Success 200 {object} model.AlbumResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /api/albums/{id} [get]
+func (ac *AlbumController) GetAlbum(c *gin.Context) {
+	id, ok := ac.albumID(c)
+	if !ok {
+		return
+	}
+
+	album, err := ac.albumService.GetAlbum(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.AlbumResponse{
+		Album: *album,
+	})
+}
+
+// AddAlbum crea un nuovo album
+// @Summary Crea un album
+// @Description Crea un nuovo album, riservato agli amministratori
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param album body model.AddAlbumRequest true "Dati dell'album"
+// @Success 201 {object} model.AlbumResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Router /api/albums [post]
+func (ac *AlbumController) AddAlbum(c *gin.Context) {
+	var request model.AddAlbumRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, invalidRequest(err))
+		return
+	}
+
+	album, err := ac.albumService.CreateAlbum(request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.AlbumResponse{
+		Album: *album,
+	})
+}
+
+// UpdateAlbum modifica un album
+// @Summary Modifica un album
+// @Description Modifica titolo, descrizione, copertina o permessi di upload di un album, riservato agli amministratori
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "Identificativo dell'album"
+// @Param album body model.UpdateAlbumRequest true "Campi da modificare"
+// @Success 200 {object} model.AlbumResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /api/albums/{id} [put]
+func (ac *AlbumController) UpdateAlbum(c *gin.Context) {
+	id, ok := ac.albumID(c)
+	if !ok {
+		return
+	}
+
+	var request model.UpdateAlbumRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, invalidRequest(err))
+		return
+	}
+
+	album, err := ac.albumService.UpdateAlbum(id, request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.AlbumResponse{
+		Album: *album,
+	})
+}
+
+// DeleteAlbum elimina un album
+// @Summary Elimina un album
+// @Description Elimina un album senza eliminare le foto che contiene, riservato agli amministratori
+// @Tags albums
+// @Security AdminToken
+// @Param id path int true "Identificativo dell'album"
+// @Success 204
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /api/albums/{id} [delete]
+func (ac *AlbumController) DeleteAlbum(c *gin.Context) {
+	id, ok := ac.albumID(c)
+	if !ok {
+		return
+	}
+
+	if err := ac.albumService.DeleteAlbum(id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetAlbumPhotos restituisce le foto di un album con paginazione
+// @Summary Recupera le foto di un album
+// @Description Ottiene le foto di un album nell'ordine scelto dagli sposi, per pagina (page/per_page) o con i cursori after/before che restano stabili quando vengono aggiunte foto
+// @Tags albums
+// @Produce json
+// @Param id path int true "Identificativo dell'album"
+// @Param page query int false "Numero pagina (default: 1)"
+// @Param per_page query int false "Elementi per pagina (default: 10, max: 100)"
+// @Param after query string false "Cursore next_cursor: restituisce le foto successive, al posto di page"
+// @Param before query string false "Cursore prev_cursor: restituisce le foto precedenti, al posto di page"
+// @Success 200 {object} model.GetPhotosResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/albums/{id}/photos [get]
+func (ac *AlbumController) GetAlbumPhotos(c *gin.Context) {
+	id, ok := ac.albumID(c)
+	if !ok {
+		return
+	}
+
+	page, perPage := parsePagination(c)
+
+	// Un cursore non valido, o della galleria, è un errore 400 (invalid_cursor)
+	response, err := ac.albumService.GetAlbumPhotos(id, page, perPage, c.Query("after"), c.Query("before"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AddAlbumPhotos aggiunge foto esistenti a un album
+// @Summary Aggiunge foto a un album
+// @Description Aggiunge in coda all'album foto già caricate, riservato agli amministratori
+// @Tags albums
+// @Accept json
+// @Security AdminToken
+// @Param id path int true "Identificativo dell'album"
+// @Param photos body model.AlbumPhotosRequest true "Foto da aggiungere"
+// @Success 204
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /api/albums/{id}/photos [post]
+func (ac *AlbumController) AddAlbumPhotos(c *gin.Context) {
+	id, ok := ac.albumID(c)
+	if !ok {
+		return
+	}
+
+	var request model.AlbumPhotosRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, invalidRequest(err))
+		return
+	}
+
+	if err := ac.albumService.AddPhotos(id, request.ImageNames); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ReorderAlbumPhotos modifica l'ordine delle foto di un album
+// @Summary Riordina le foto di un album
+// @Description Porta in testa all'album le foto indicate nell'ordine dato, le altre seguono; riservato agli amministratori
+// @Tags albums
+// @Accept json
+// @Security AdminToken
+// @Param id path int true "Identificativo dell'album"
+// @Param photos body model.AlbumPhotosRequest true "Nuovo ordine delle foto"
+// @Success 204
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /api/albums/{id}/photos/order [put]
+func (ac *AlbumController) ReorderAlbumPhotos(c *gin.Context) {
+	id, ok := ac.albumID(c)
+	if !ok {
+		return
+	}
+
+	var request model.AlbumPhotosRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, invalidRequest(err))
+		return
+	}
+
+	if err := ac.albumService.ReorderPhotos(id, request.ImageNames); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveAlbumPhoto rimuove una foto da un album
+// @Summary Rimuove una foto da un album
+// @Description Rimuove la foto dall'album senza eliminarla, riservato agli amministratori
+// @Tags albums
+// @Security AdminToken
+// @Param id path int true "Identificativo dell'album"
+// @Param name path string true "Nome della foto"
+// @Success 204
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /api/albums/{id}/photos/{name} [delete]
+func (ac *AlbumController) RemoveAlbumPhoto(c *gin.Context) {
+	id, ok := ac.albumID(c)
+	if !ok {
+		return
+	}
+
+	if err := ac.albumService.RemovePhoto(id, c.Param("name")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// albumID legge l'identificativo dell'album dal path, rispondendo 400 se non è valido
+func (ac *AlbumController) albumID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		respondError(c, ErrInvalidAlbumID)
+		return 0, false
+	}
+	return id, true
+}
+
+// SetupRoutes configura tutte le route relative agli album
+func (ac *AlbumController) SetupRoutes(api *gin.RouterGroup) {
+	requireAdmin := ac.adminAuth.Require()
+
+	albums := api.Group("/albums")
+	{
+		albums.GET("", ac.GetAlbums)
+		albums.POST("", requireAdmin, ac.AddAlbum)
+		albums.GET("/:id", ac.GetAlbum)
+		albums.PUT("/:id", requireAdmin, ac.UpdateAlbum)
+		albums.DELETE("/:id", requireAdmin, ac.DeleteAlbum)
+		albums.GET("/:id/photos", ac.GetAlbumPhotos)
+		albums.POST("/:id/photos", requireAdmin, ac.AddAlbumPhotos)
+		albums.PUT("/:id/photos/order", requireAdmin, ac.ReorderAlbumPhotos)
+		albums.DELETE("/:id/photos/:name", requireAdmin, ac.RemoveAlbumPhoto)
+	}
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"wedding-photo-backend/internal/weddingphoto/middleware"
+	"wedding-photo-backend/internal/weddingphoto/model"
+	"wedding-photo-backend/internal/weddingphoto/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CommentController gestisce i commenti degli ospiti alle foto
+type CommentController struct {
+	commentService *service.CommentService
+	adminAuth      *middleware.AdminAuth
+}
+
+// NewCommentController crea una nuova istanza del controller
+func NewCommentController(commentService *service.CommentService, adminAuth *middleware.AdminAuth) *CommentController {
+	return &CommentController{
+		commentService: commentService,
+		adminAuth:      adminAuth,
+	}
+}
+
+// GetComments restituisce i commenti di una foto
+// @Summary Recupera i commenti di una foto
+// @Description Ottiene i commenti di una foto organizzati in thread, in ordine cronologico
+// @Tags comments
+// @Produce json
+// @Param name path string true "Nome della foto"
+// @Param X-Guest-Token header string false "Token che identifica l'ospite, per riconoscere i propri commenti"
+// @Success 200 {object} model.GetCommentsResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/photos/{name}/comments [get]
+func (cc *CommentController) GetComments(c *gin.Context) {
+	comments, totalCount, err := cc.commentService.GetComments(c.Param("name"), optionalGuestToken(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.GetCommentsResponse{
+		Comments:   comments,
+		TotalCount: totalCount,
+	})
+}
+
+// AddComment aggiunge un commento a una foto
+// @Summary Commenta una foto
+// @Description Aggiunge un commento a una foto o una risposta a un commento esistente
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param name path string true "Nome della foto"
+// @Param X-Guest-Token header string true "Token che identifica l'ospite"
+// @Param comment body model.AddCommentRequest true "Commento"
+// @Success 201 {object} model.CommentResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/photos/{name}/comments [post]
+func (cc *CommentController) AddComment(c *gin.Context) {
+	guestToken, ok := requireGuestToken(c)
+	if !ok {
+		return
+	}
+
+	var request model.AddCommentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, invalidRequest(err))
+		return
+	}
+
+	comment, err := cc.commentService.AddComment(c.Param("name"), guestToken, request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.CommentResponse{
+		Comment: *comment,
+	})
+}
+
+// DeleteComment elimina un commento
+// @Summary Elimina un commento
+// @Description Elimina un commento e le sue risposte; consentito all'autore e agli amministratori
+// @Tags comments
+// @Param name path string true "Nome della foto"
+// @Param id path int true "Identificativo del commento"
+// @Param X-Guest-Token header string false "Token che identifica l'ospite"
+// @Success 204
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/photos/{name}/comments/{id} [delete]
+func (cc *CommentController) DeleteComment(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		respondError(c, ErrInvalidCommentID)
+		return
+	}
+
+	err = cc.commentService.DeleteComment(c.Param("name"), id, optionalGuestToken(c), cc.adminAuth.IsAdmin(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SetupRoutes configura tutte le route relative ai commenti
+func (cc *CommentController) SetupRoutes(api *gin.RouterGroup) {
+	photos := api.Group("/photos")
+	{
+		photos.GET("/:name/comments", cc.GetComments)
+		photos.POST("/:name/comments", cc.AddComment)
+		photos.DELETE("/:name/comments/:id", cc.DeleteComment)
+	}
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/config"
+	"wedding-photo-backend/internal/weddingphoto/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrInvalidRequest indica un corpo della richiesta non leggibile o incompleto
+	ErrInvalidRequest = apperror.New(apperror.KindInvalid, "invalid_request", "Richiesta non valida")
+	// ErrMissingFile indica un upload senza il file dell'immagine
+	ErrMissingFile = apperror.New(apperror.KindInvalid, "missing_file", "Errore nel recupero del file")
+	// ErrInvalidAlbumID indica un identificativo di album non numerico
+	ErrInvalidAlbumID = apperror.New(apperror.KindInvalid, "invalid_album_id", "Identificativo dell'album non valido")
+	// ErrInvalidCommentID indica un identificativo di commento non numerico
+	ErrInvalidCommentID = apperror.New(apperror.KindInvalid, "invalid_comment_id", "Identificativo del commento non valido")
+	// ErrInvalidEventID indica un identificativo dell'ultimo evento ricevuto non valido
+	ErrInvalidEventID = apperror.New(apperror.KindInvalid, "invalid_event_id", "Identificativo dell'ultimo evento non valido")
+	// ErrInvalidTagMode indica una modalità di combinazione dei filtri non prevista
+	ErrInvalidTagMode = apperror.New(apperror.KindInvalid, "invalid_tag_mode", "tag_mode non valido, usare and oppure or")
+	// ErrGuestTokenRequired indica una richiesta senza un token dell'ospite valido
+	ErrGuestTokenRequired = apperror.New(apperror.KindInvalid, "guest_token_required", "Header X-Guest-Token mancante o non valido")
+	// ErrUploadTooLarge indica un file oltre la dimensione massima dei caricamenti
+	ErrUploadTooLarge = apperror.New(apperror.KindTooLarge, "upload_too_large", "File troppo grande")
+	// ErrRangeNotSatisfiable indica un header Range fuori dalla dimensione del contenuto
+	ErrRangeNotSatisfiable = apperror.New(apperror.KindRangeNotSatisfiable, "range_not_satisfiable", "Intervallo richiesto non valido")
+)
+
+// invalidRequest segnala un errore nella lettura del corpo della richiesta, riportandone il motivo nei dettagli
+func invalidRequest(err error) error {
+	return apperror.WithDetails(fmt.Errorf("%w: %v", ErrInvalidRequest, err), map[string]any{"reason": err.Error()})
+}
+
+// uploadFormOverhead è lo spazio concesso ai campi del form oltre al file caricato
+const uploadFormOverhead = 64 << 10
+
+// uploadTooLarge segnala un file troppo grande, riportando il limite nei dettagli
+func uploadTooLarge(maxSize int64) error {
+	return apperror.WithDetails(fmt.Errorf("%w: il limite è %s", ErrUploadTooLarge, config.ByteSize(maxSize)),
+		map[string]any{"max_size": config.ByteSize(maxSize).String(), "max_bytes": maxSize})
+}
+
+// respondError converte un errore nella risposta HTTP, con lo status e il codice associati all'errore
+// e il messaggio nella lingua della richiesta
+func respondError(c *gin.Context, err error) {
+	status := apperror.Status(err)
+	if status >= http.StatusInternalServerError {
+		// Il messaggio tradotto è generico: il dettaglio resta solo nel log
+		slog.ErrorContext(c.Request.Context(), "Errore nella richiesta", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+	}
+	c.JSON(status, middleware.ErrorResponse(c, err))
+}
@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/middleware"
+	"wedding-photo-backend/internal/weddingphoto/model"
+	"wedding-photo-backend/internal/weddingphoto/service"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// eventHeartbeatInterval è l'intervallo dei messaggi che mantengono aperta la connessione
+	eventHeartbeatInterval = 25 * time.Second
+	// eventWriteTimeout è il tempo massimo per l'invio di un messaggio WebSocket
+	eventWriteTimeout = 10 * time.Second
+	// eventRetryMillis è l'attesa suggerita ai client SSE prima di riconnettersi
+	eventRetryMillis = 3000
+)
+
+// EventController gestisce l'invio in tempo reale degli eventi sulle foto
+type EventController struct {
+	eventService *service.EventService
+	upgrader     websocket.Upgrader
+}
+
+// NewEventController crea una nuova istanza del controller
+func NewEventController(eventService *service.EventService, cors *middleware.Cors) *EventController {
+	return &EventController{
+		eventService: eventService,
+		upgrader: websocket.Upgrader{
+			// Il browser non applica CORS ai WebSocket: l'origine viene verificata con la policy CORS
+			// della route; i client che non sono browser non inviano Origin e sono accettati
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || cors.AllowsOrigin(r.URL.Path, origin)
+			},
+		},
+	}
+}
+
+// Stream invia gli eventi come Server-Sent Events
+// @Summary Eventi in tempo reale (SSE)
+// @Description Invia come Server-Sent Events gli eventi photo.added, photo.processed, photo.deleted e photo.approved; dopo una disconnessione il client riceve gli eventi persi indicando l'ultimo ricevuto in Last-Event-ID
+// @Tags events
+// @Produce text/event-stream
+// @Param Last-Event-ID header int false "Identificativo dell'ultimo evento ricevuto"
+// @Param last_event_id query int false "Alternativa all'header Last-Event-ID"
+// @Success 200 {object} model.Event
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/events/stream [get]
+func (ec *EventController) Stream(c *gin.Context) {
+	lastEventParam := c.GetHeader("Last-Event-ID")
+	if lastEventParam == "" {
+		lastEventParam = c.Query("last_event_id")
+	}
+	backlog, events, unsubscribe, ok := ec.subscribe(c, lastEventParam)
+	if !ok {
+		return
+	}
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteString("retry: " + strconv.Itoa(eventRetryMillis) + "\n\n")
+	c.Writer.Flush()
+
+	send := func(event model.Event) {
+		c.Render(-1, sse.Event{
+			Id:    strconv.FormatInt(event.ID, 10),
+			Event: event.Type,
+			Data:  event,
+		})
+		c.Writer.Flush()
+	}
+
+	lastSent := int64(0)
+	for _, event := range backlog {
+		send(event)
+		lastSent = event.ID
+	}
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			// Scarta gli eventi già inviati con lo storico
+			if event.ID <= lastSent {
+				continue
+			}
+			send(event)
+		case <-heartbeat.C:
+			// I commenti SSE sono ignorati dai client ma tengono aperte le connessioni dietro ai proxy
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// WebSocket invia gli eventi tramite WebSocket
+// @Summary Eventi in tempo reale (WebSocket)
+// @Description Equivalente WebSocket di /api/events/stream: ogni messaggio è un evento in JSON; per riprendere dopo una disconnessione indicare l'ultimo evento ricevuto in last_event_id
+// @Tags events
+// @Param last_event_id query int false "Identificativo dell'ultimo evento ricevuto"
+// @Success 101 {object} model.Event
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/events/ws [get]
+func (ec *EventController) WebSocket(c *gin.Context) {
+	backlog, events, unsubscribe, ok := ec.subscribe(c, c.Query("last_event_id"))
+	if !ok {
+		return
+	}
+	defer unsubscribe()
+
+	conn, err := ec.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade ha già inviato la risposta di errore
+		return
+	}
+	defer conn.Close()
+
+	// I messaggi del client non sono usati, ma vanno letti per rilevare la chiusura della connessione
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(event model.Event) bool {
+		conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+		return conn.WriteJSON(event) == nil
+	}
+
+	lastSent := int64(0)
+	for _, event := range backlog {
+		if !send(event) {
+			return
+		}
+		lastSent = event.ID
+	}
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-events:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(eventWriteTimeout))
+				return
+			}
+			if event.ID <= lastSent {
+				continue
+			}
+			if !send(event) {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// subscribe iscrive il client agli eventi, rispondendo con un errore se l'ultimo evento indicato non è valido
+func (ec *EventController) subscribe(c *gin.Context, lastEventParam string) ([]model.Event, <-chan model.Event, func(), bool) {
+	lastEventID := int64(0)
+	if lastEventParam != "" {
+		id, err := strconv.ParseInt(lastEventParam, 10, 64)
+		if err != nil || id < 0 {
+			respondError(c, ErrInvalidEventID)
+			return nil, nil, nil, false
+		}
+		lastEventID = id
+	}
+
+	backlog, events, unsubscribe, err := ec.eventService.Subscribe(lastEventID)
+	if err != nil {
+		respondError(c, fmt.Errorf("Errore nel recupero degli eventi: %w", err))
+		return nil, nil, nil, false
+	}
+	return backlog, events, unsubscribe, true
+}
+
+// SetupRoutes configura tutte le route relative agli eventi
+func (ec *EventController) SetupRoutes(api *gin.RouterGroup) {
+	events := api.Group("/events")
+	{
+		events.GET("/stream", ec.Stream)
+		events.GET("/ws", ec.WebSocket)
+	}
+}
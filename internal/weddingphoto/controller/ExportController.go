@@ -0,0 +1,284 @@
+package controller
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/metrics"
+	"wedding-photo-backend/internal/weddingphoto/middleware"
+	"wedding-photo-backend/internal/weddingphoto/model"
+	"wedding-photo-backend/internal/weddingphoto/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportController gestisce l'esportazione delle foto originali in archivi ZIP
+type ExportController struct {
+	exportService *service.ExportService
+	adminAuth     *middleware.AdminAuth
+}
+
+// NewExportController crea una nuova istanza del controller
+func NewExportController(exportService *service.ExportService, adminAuth *middleware.AdminAuth) *ExportController {
+	return &ExportController{
+		exportService: exportService,
+		adminAuth:     adminAuth,
+	}
+}
+
+// ExportPhotos scarica tutte le foto originali in un archivio ZIP
+// @Summary Esporta tutte le foto
+// @Description Scarica in streaming un archivio ZIP con tutte le foto originali, riservato agli amministratori.
+// @Description Supporta le richieste Range per riprendere un download interrotto.
+// @Tags exports
+// @Produce application/zip
+// @Param manifest query string false "Manifest da includere nell'archivio" Enums(json, csv)
+// @Param layout query string false "Struttura delle cartelle" Enums(flat, uploader)
+// @Param Range header string false "Intervallo di byte da scaricare"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 416 {object} model.ErrorResponse
+// @Security AdminToken
+// @Router /api/export.zip [get]
+func (ec *ExportController) ExportPhotos(c *gin.Context) {
+	ec.serveArchive(c, service.ExportOptions{
+		Manifest: c.Query("manifest"),
+		Layout:   c.Query("layout"),
+	})
+}
+
+// ExportAlbum scarica le foto originali di un album in un archivio ZIP
+// @Summary Esporta un album
+// @Description Scarica in streaming un archivio ZIP con le foto originali di un album, riservato agli amministratori.
+// @Description Supporta le richieste Range per riprendere un download interrotto.
+// @Tags exports
+// @Produce application/zip
+// @Param id path int true "Identificativo dell'album"
+// @Param manifest query string false "Manifest da includere nell'archivio" Enums(json, csv)
+// @Param layout query string false "Struttura delle cartelle" Enums(flat, uploader)
+// @Param Range header string false "Intervallo di byte da scaricare"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 416 {object} model.ErrorResponse
+// @Security AdminToken
+// @Router /api/albums/{id}/export.zip [get]
+func (ec *ExportController) ExportAlbum(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		respondError(c, ErrInvalidAlbumID)
+		return
+	}
+
+	ec.serveArchive(c, service.ExportOptions{
+		AlbumID:  id,
+		Manifest: c.Query("manifest"),
+		Layout:   c.Query("layout"),
+	})
+}
+
+// CreateExport avvia un'esportazione asincrona
+// @Summary Avvia un'esportazione asincrona
+// @Description Prepara l'archivio ZIP in background, per gallerie troppo grandi da scaricare in una richiesta.
+// @Description Al termine viene pubblicato un evento export.ready o export.failed; l'archivio resta disponibile per 24 ore.
+// @Tags exports
+// @Accept json
+// @Produce json
+// @Param request body model.CreateExportRequest true "Opzioni dell'esportazione"
+// @Success 202 {object} model.ExportJob
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Security AdminToken
+// @Router /api/exports [post]
+func (ec *ExportController) CreateExport(c *gin.Context) {
+	var request model.CreateExportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, invalidRequest(err))
+		return
+	}
+
+	job, err := ec.exportService.CreateExport(c.Request.Context(), service.ExportOptions{
+		AlbumID:  request.AlbumID,
+		Manifest: request.Manifest,
+		Layout:   request.Layout,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetExport restituisce lo stato di un'esportazione asincrona
+// @Summary Stato di un'esportazione
+// @Tags exports
+// @Produce json
+// @Param id path string true "Identificativo dell'esportazione"
+// @Success 200 {object} model.ExportJob
+// @Failure 404 {object} model.ErrorResponse
+// @Security AdminToken
+// @Router /api/exports/{id} [get]
+func (ec *ExportController) GetExport(c *gin.Context) {
+	job, err := ec.exportService.GetExport(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadExport scarica l'archivio di un'esportazione completata
+// @Summary Scarica un'esportazione
+// @Description Scarica l'archivio ZIP di un'esportazione asincrona completata, con supporto alle richieste Range
+// @Tags exports
+// @Produce application/zip
+// @Param id path string true "Identificativo dell'esportazione"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Security AdminToken
+// @Router /api/exports/{id}/download [get]
+func (ec *ExportController) DownloadExport(c *gin.Context) {
+	file, job, err := ec.exportService.OpenExport(c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer file.Close()
+
+	modTime := job.CreatedAt
+	if job.CompletedAt != nil {
+		modTime = *job.CompletedAt
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="esportazione-%s.zip"`, job.ID))
+	c.Header("Content-Type", "application/zip")
+	c.Header("ETag", `"`+job.ID+`"`)
+	http.ServeContent(c.Writer, c.Request, "", modTime, file)
+}
+
+// serveArchive scrive l'archivio ZIP in streaming, rispettando un eventuale header Range
+func (ec *ExportController) serveArchive(c *gin.Context, options service.ExportOptions) {
+	archive, err := ec.exportService.PrepareArchive(options)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, archive.Filename))
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("ETag", archive.ETag)
+	if !archive.ModTime.IsZero() {
+		c.Header("Last-Modified", archive.ModTime.UTC().Format(http.TimeFormat))
+	}
+
+	offset, length := int64(0), archive.Size
+	status := http.StatusOK
+	if rangeHeader := c.GetHeader("Range"); rangeHeader != "" && ec.rangeApplies(c, archive) {
+		start, end, ok := parseByteRange(rangeHeader, archive.Size)
+		if !ok {
+			c.Header("Content-Range", fmt.Sprintf("bytes */%d", archive.Size))
+			respondError(c, apperror.WithDetails(ErrRangeNotSatisfiable, map[string]any{"size": archive.Size}))
+			return
+		}
+		if start >= 0 {
+			offset, length = start, end-start+1
+			status = http.StatusPartialContent
+			c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, archive.Size))
+		}
+	}
+
+	c.Header("Content-Length", strconv.FormatInt(length, 10))
+	c.Status(status)
+	if c.Request.Method == http.MethodHead {
+		return
+	}
+
+	// Gli header sono già stati inviati: un errore può solo interrompere il download
+	if err := archive.WriteRange(c.Writer, offset, length); err != nil {
+		slog.WarnContext(c.Request.Context(), "Download dell'archivio interrotto", "filename", archive.Filename, "error", err)
+		c.Abort()
+	}
+}
+
+// rangeApplies verifica l'header If-Range: se l'archivio è cambiato va inviato per intero
+func (ec *ExportController) rangeApplies(c *gin.Context, archive *service.Archive) bool {
+	ifRange := c.GetHeader("If-Range")
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, `W/`) {
+		return ifRange == archive.ETag
+	}
+	since, err := http.ParseTime(ifRange)
+	return err == nil && !archive.ModTime.Truncate(time.Second).After(since)
+}
+
+// parseByteRange interpreta un header Range con un solo intervallo (bytes=a-b, bytes=a-, bytes=-n).
+// Restituisce start -1 se l'header va ignorato (più intervalli o unità diverse dai byte)
+// e ok false se l'intervallo non è soddisfacibile.
+func parseByteRange(header string, size int64) (start, end int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return -1, -1, true
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	if first == "" {
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || suffix <= 0 || size == 0 {
+			return 0, 0, false
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return size - suffix, size - 1, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+	end = size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end, true
+}
+
+// SetupRoutes configura tutte le route relative alle esportazioni
+func (ec *ExportController) SetupRoutes(api *gin.RouterGroup) {
+	requireAdmin := ec.adminAuth.Require()
+	countBytes := middleware.CountServedBytes(metrics.KindExport)
+
+	api.GET("/export.zip", requireAdmin, countBytes, ec.ExportPhotos)
+	api.HEAD("/export.zip", requireAdmin, ec.ExportPhotos)
+	api.GET("/albums/:id/export.zip", requireAdmin, countBytes, ec.ExportAlbum)
+	api.HEAD("/albums/:id/export.zip", requireAdmin, ec.ExportAlbum)
+
+	exports := api.Group("/exports", requireAdmin)
+	{
+		exports.POST("", ec.CreateExport)
+		exports.GET("/:id", ec.GetExport)
+		exports.GET("/:id/download", countBytes, ec.DownloadExport)
+	}
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// guestTokenPattern definisce il formato ammesso per il token che identifica un ospite
+var guestTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{8,64}$`)
+
+// requireGuestToken legge il token dell'ospite dall'header X-Guest-Token, rispondendo 400 se assente o non valido
+func requireGuestToken(c *gin.Context) (string, bool) {
+	guestToken := c.GetHeader("X-Guest-Token")
+	if !guestTokenPattern.MatchString(guestToken) {
+		respondError(c, ErrGuestTokenRequired)
+		return "", false
+	}
+	return guestToken, true
+}
+
+// optionalGuestToken restituisce il token dell'ospite se presente e valido, altrimenti una stringa vuota
+func optionalGuestToken(c *gin.Context) string {
+	guestToken := c.GetHeader("X-Guest-Token")
+	if !guestTokenPattern.MatchString(guestToken) {
+		return ""
+	}
+	return guestToken
+}
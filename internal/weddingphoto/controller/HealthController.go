@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"net/http"
+
+	"wedding-photo-backend/internal/weddingphoto/middleware"
+	"wedding-photo-backend/internal/weddingphoto/model"
+	"wedding-photo-backend/internal/weddingphoto/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthController espone lo stato dell'istanza ai load balancer, agli orchestratori e agli amministratori
+type HealthController struct {
+	healthService *service.HealthService
+	adminAuth     *middleware.AdminAuth
+}
+
+// NewHealthController crea una nuova istanza del controller
+func NewHealthController(healthService *service.HealthService, adminAuth *middleware.AdminAuth) *HealthController {
+	return &HealthController{
+		healthService: healthService,
+		adminAuth:     adminAuth,
+	}
+}
+
+// Live indica che il processo è attivo
+// @Summary Liveness dell'istanza
+// @Description Risponde sempre 200 finché il processo è in grado di gestire richieste; non verifica le dipendenze
+// @Tags health
+// @Produce json
+// @Success 200 {object} model.LivenessResponse
+// @Router /healthz [get]
+func (hc *HealthController) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, model.LivenessResponse{
+		Status:        "ok",
+		UptimeSeconds: int64(hc.healthService.Uptime().Seconds()),
+	})
+}
+
+// Ready indica se l'istanza può ricevere nuove richieste
+// @Summary Readiness dell'istanza
+// @Description Verifica Redis, scrittura nella directory delle foto, spazio libero su disco e database dei metadati; risponde 503 se una verifica non è superata o se l'istanza è in arresto
+// @Tags health
+// @Produce json
+// @Success 200 {object} model.ReadinessResponse
+// @Failure 503 {object} model.ReadinessResponse
+// @Router /readyz [get]
+func (hc *HealthController) Ready(c *gin.Context) {
+	status, checks := hc.healthService.Readiness(c.Request.Context())
+
+	httpStatus := http.StatusOK
+	if status != service.ReadinessReady {
+		httpStatus = http.StatusServiceUnavailable
+	}
+	c.JSON(httpStatus, model.ReadinessResponse{Status: status, Checks: checks})
+}
+
+// GetStatus restituisce lo stato dell'istanza
+// @Summary Stato dell'istanza
+// @Description Mostra verifiche di prontezza, lunghezza della coda di elaborazione, worker attivi, foto in attesa di thumbnail e preview e spazio su disco
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} model.StatusResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/admin/status [get]
+func (hc *HealthController) GetStatus(c *gin.Context) {
+	status, err := hc.healthService.Status(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// SetupProbeRoutes configura le route per i controlli di liveness e readiness, fuori da /api e senza limiti di richieste
+func (hc *HealthController) SetupProbeRoutes(r gin.IRoutes) {
+	r.GET("/healthz", hc.Live)
+	r.GET("/readyz", hc.Ready)
+}
+
+// SetupRoutes configura le route di stato riservate agli amministratori
+func (hc *HealthController) SetupRoutes(api *gin.RouterGroup) {
+	api.GET("/admin/status", hc.adminAuth.Require(), hc.GetStatus)
+}
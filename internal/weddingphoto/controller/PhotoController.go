@@ -1,10 +1,19 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/metrics"
+	"wedding-photo-backend/internal/weddingphoto/middleware"
 	"wedding-photo-backend/internal/weddingphoto/model"
 	"wedding-photo-backend/internal/weddingphoto/service"
 
@@ -14,13 +23,22 @@ import (
 // PhotoController gestisce le operazioni sulle foto
 type PhotoController struct {
 	photoService *service.PhotoService
+	albumService *service.AlbumService
+	adminAuth    *middleware.AdminAuth
+	rateLimiter  *middleware.RateLimiter
+	// maxUploadSize è la dimensione massima del file caricato, in byte
+	maxUploadSize int64
 }
 
 // NewPhotoController crea una nuova istanza del controller
-func NewPhotoController(photoService *service.PhotoService) *PhotoController {
+func NewPhotoController(photoService *service.PhotoService, albumService *service.AlbumService, adminAuth *middleware.AdminAuth, rateLimiter *middleware.RateLimiter, maxUploadSize int64) *PhotoController {
 
 	return &PhotoController{
-		photoService: photoService,
+		photoService:  photoService,
+		albumService:  albumService,
+		adminAuth:     adminAuth,
+		rateLimiter:   rateLimiter,
+		maxUploadSize: maxUploadSize,
 	}
 }
 
@@ -32,17 +50,51 @@ func NewPhotoController(photoService *service.PhotoService) *PhotoController {
 // @Produce json
 // @Param fiimagele formData file true "File immagine da caricare"
 // @Param imageName formData string false "Nome personalizzato per l'immagine"
+// @Param caption formData string false "Didascalia della foto"
+// @Param uploader_name formData string false "Nome di chi carica la foto"
+// @Param album_id formData int false "Album in cui caricare la foto"
+// @Param X-Guest-Token header string false "Token che identifica l'ospite che carica la foto"
 // @Success 200 {object} model.AddPhotoResponse
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 413 {object} model.ErrorResponse
 // @Router /api/photos [post]
 func (pc *PhotoController) AddPhoto(c *gin.Context) {
+	start := time.Now()
+	response, err := pc.addPhoto(c)
+
+	outcome := metrics.Outcome(err)
+	metrics.UploadRequestDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+	if err != nil {
+		if outcome == metrics.OutcomeRejected {
+			metrics.UploadRejectionsTotal.WithLabelValues(apperror.Code(err)).Inc()
+		}
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// addPhoto legge il file dal form e lo salva, eventualmente nell'album indicato; una volta salvata la
+// foto l'errore nell'aggiunta all'album è riportato nella risposta, perché un nuovo tentativo del client
+// creerebbe un duplicato
+func (pc *PhotoController) addPhoto(c *gin.Context) (*model.AddPhotoResponse, error) {
+	// Interrompe la lettura dei corpi troppo grandi, lasciando margine per gli altri campi del form
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, pc.maxUploadSize+uploadFormOverhead)
+
 	// Recupera il file dal form
 	file, header, err := c.Request.FormFile("image")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || (err == nil && header.Size > pc.maxUploadSize) {
+		if file != nil {
+			file.Close()
+		}
+		return nil, uploadTooLarge(pc.maxUploadSize)
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Message: "Errore nel recupero del file: " + err.Error(),
-		})
-		return
+		return nil, apperror.WithDetails(fmt.Errorf("%w: %v", ErrMissingFile, err), map[string]any{"reason": err.Error()})
 	}
 	defer file.Close()
 
@@ -52,40 +104,208 @@ func (pc *PhotoController) AddPhoto(c *gin.Context) {
 		imageName = header.Filename
 	}
 
-	// Salva la foto tramite il service
-	photo, err := pc.photoService.AddPhoto(file, imageName, header.Header.Get("Content-Type"), header.Size)
-	if err != nil {
-		// Gestione più specifica degli errori di validazione
-		statusCode := http.StatusBadRequest
-		if strings.Contains(err.Error(), "formato non è supportato") ||
-			strings.Contains(err.Error(), "non è un'immagine valida") {
-			statusCode = http.StatusUnsupportedMediaType
+	// Verifica l'album di destinazione prima di salvare il file
+	var albumID int64
+	if albumParam := c.PostForm("album_id"); albumParam != "" {
+		albumID, err = strconv.ParseInt(albumParam, 10, 64)
+		if err != nil || albumID <= 0 {
+			return nil, ErrInvalidAlbumID
 		}
 
-		c.JSON(statusCode, model.ErrorResponse{
-			Message: err.Error(),
-		})
-		return
+		if err := pc.albumService.CheckGuestUploads(albumID); err != nil {
+			// Gli amministratori possono caricare anche negli album chiusi agli ospiti
+			if !errors.Is(err, service.ErrAlbumUploadsDisabled) || !pc.adminAuth.IsAdmin(c) {
+				return nil, err
+			}
+		}
+	}
+
+	// Salva la foto tramite il service
+	details := service.UploadDetails{
+		Caption:      c.PostForm("caption"),
+		UploaderName: c.PostForm("uploader_name"),
+		GuestToken:   optionalGuestToken(c),
+	}
+
+	photo, err := pc.photoService.AddPhoto(c.Request.Context(), file, imageName, header.Header.Get("Content-Type"), header.Size, details)
+	if err != nil {
+		return nil, err
 	}
 
-	c.JSON(http.StatusOK, model.AddPhotoResponse{
-		Photo: *photo,
-	})
+	response := &model.AddPhotoResponse{Photo: *photo}
+	if albumID != 0 {
+		if err := pc.albumService.AddGuestUpload(albumID, photo.ImageName); err != nil {
+			slog.WarnContext(c.Request.Context(), "Foto salvata ma non aggiunta all'album", "image_name", photo.ImageName, "album_id", albumID, "error", err)
+			albumError := middleware.ErrorResponse(c, err)
+			response.AlbumError = &albumError
+		}
+	}
+	return response, nil
 }
 
 // GetPhotos restituisce la lista delle foto con paginazione
 // @Summary Recupera la lista delle foto
-// @Description Ottiene tutte le foto caricate sul server con paginazione
+// @Description Ottiene tutte le foto caricate sul server, per pagina (page/per_page) o con i cursori after/before che restano stabili durante i nuovi caricamenti
 // @Tags photos
 // @Produce json
 // @Param page query int false "Numero pagina (default: 1)"
 // @Param per_page query int false "Elementi per pagina (default: 10, max: 100)"
+// @Param sort query string false "Ordinamento: recent (default) o popular"
+// @Param tag query []string false "Filtra per tag, ripetibile" collectionFormat(multi)
+// @Param person query []string false "Filtra per persona, ripetibile" collectionFormat(multi)
+// @Param tag_mode query string false "Combinazione dei filtri: and (default, tutti) o or (almeno uno)"
+// @Param after query string false "Cursore next_cursor: restituisce le foto successive, al posto di page"
+// @Param before query string false "Cursore prev_cursor: restituisce le foto precedenti, al posto di page"
 // @Success 200 {object} model.GetPhotosResponse
 // @Failure 400 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/photos [get]
 func (pc *PhotoController) GetPhotos(c *gin.Context) {
-	// Parse dei parametri di paginazione
+	page, perPage := parsePagination(c)
+
+	query := service.PhotoListQuery{
+		Sort:     c.DefaultQuery("sort", service.SortRecent),
+		Tags:     c.QueryArray("tag"),
+		People:   c.QueryArray("person"),
+		MatchAll: true,
+		After:    c.Query("after"),
+		Before:   c.Query("before"),
+	}
+
+	switch c.DefaultQuery("tag_mode", "and") {
+	case "and":
+	case "or":
+		query.MatchAll = false
+	default:
+		respondError(c, ErrInvalidTagMode)
+		return
+	}
+
+	getPhotosResponse, err := pc.photoService.GetPhotoList(page, perPage, query)
+	if err != nil {
+		if _, ok := apperror.Lookup(err); !ok {
+			err = fmt.Errorf("Errore nel recupero delle foto: %w", err)
+		}
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, getPhotosResponse)
+}
+
+// GetPendingPhotos restituisce le foto in attesa di approvazione
+// @Summary Recupera le foto da approvare
+// @Description Ottiene con paginazione le foto già elaborate in attesa di approvazione, riservato agli amministratori
+// @Tags photos
+// @Security AdminToken
+// @Produce json
+// @Param page query int false "Numero pagina (default: 1)"
+// @Param per_page query int false "Elementi per pagina (default: 10, max: 100)"
+// @Success 200 {object} model.GetPhotosResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/photos/pending [get]
+func (pc *PhotoController) GetPendingPhotos(c *gin.Context) {
+	page, perPage := parsePagination(c)
+
+	getPhotosResponse, err := pc.photoService.GetPendingPhotos(page, perPage)
+	if err != nil {
+		respondError(c, fmt.Errorf("Errore nel recupero delle foto: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, getPhotosResponse)
+}
+
+// ApprovePhoto approva una foto in attesa di moderazione
+// @Summary Approva una foto
+// @Description Rende visibile nella galleria una foto in attesa di approvazione, riservato agli amministratori
+// @Tags photos
+// @Security AdminToken
+// @Param name path string true "Nome della foto"
+// @Success 204
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/photos/{name}/approve [post]
+func (pc *PhotoController) ApprovePhoto(c *gin.Context) {
+	if err := pc.photoService.ApprovePhoto(c.Param("name")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeletePhoto elimina una foto
+// @Summary Elimina una foto
+// @Description Elimina una foto con thumbnail, preview, tag, commenti e reazioni, riservato agli amministratori
+// @Tags photos
+// @Security AdminToken
+// @Param name path string true "Nome della foto"
+// @Success 204
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/photos/{name} [delete]
+func (pc *PhotoController) DeletePhoto(c *gin.Context) {
+	if err := pc.photoService.DeletePhoto(c.Param("name")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetOriginal scarica l'immagine originale di una foto
+// @Summary Scarica la foto originale
+// @Description Scarica l'immagine originale con il nome del file caricato dall'ospite, con supporto alle richieste Range.
+// @Description Gli amministratori e l'ospite che ha caricato la foto (header X-Guest-Token) possono sempre scaricarla;
+// @Description gli altri ospiti solo se la foto è visibile in galleria e ALLOW_ORIGINAL_DOWNLOAD è attivo.
+// @Tags photos
+// @Produce image/jpeg,image/png,image/gif,image/webp
+// @Param name path string true "Nome della foto"
+// @Param X-Guest-Token header string false "Token dell'ospite"
+// @Param Range header string false "Intervallo di byte da scaricare"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 416 {object} model.ErrorResponse
+// @Router /api/photos/{name}/original [get]
+func (pc *PhotoController) GetOriginal(c *gin.Context) {
+	original, err := pc.photoService.OpenOriginal(c.Param("name"), optionalGuestToken(c), pc.adminAuth.IsAdmin(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer original.File.Close()
+
+	c.Header("Content-Type", original.MimeType)
+	c.Header("Content-Disposition", attachmentDisposition(original.DownloadName))
+	c.Header("ETag", fmt.Sprintf(`"%x-%x"`, original.Info.ModTime().UnixNano(), original.Info.Size()))
+	c.Header("Cache-Control", "private, max-age=3600")
+	http.ServeContent(c.Writer, c.Request, "", original.Info.ModTime(), original.File)
+}
+
+// attachmentDisposition costruisce l'header Content-Disposition per un download; per i nomi non ASCII
+// aggiunge a filename* (RFC 5987) un nome semplificato per i client che non lo supportano
+func attachmentDisposition(filename string) string {
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+	fallback := strings.Map(func(r rune) rune {
+		if r < 32 || r > 126 || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, filename)
+	if fallback == filename {
+		return disposition
+	}
+	return fmt.Sprintf(`attachment; filename="%s"`, fallback) + strings.TrimPrefix(disposition, "attachment")
+}
+
+// parsePagination legge i parametri page e per_page, usando i valori di default se assenti o non validi
+func parsePagination(c *gin.Context) (int, int) {
 	page := 1
 	perPage := 10
 
@@ -101,28 +321,21 @@ func (pc *PhotoController) GetPhotos(c *gin.Context) {
 		}
 	}
 
-	photos, totalPages, err := pc.photoService.GetPhotoList(page, perPage)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Message: "Errore nel recupero delle foto: " + err.Error(),
-		})
-		return
-	}
-
-	getPhotosResponse := model.GetPhotosResponse{
-		Photos:     photos,
-		Page:       page,
-		TotalPages: totalPages,
-	}
-
-	c.JSON(http.StatusOK, getPhotosResponse)
+	return page, perPage
 }
 
 // SetupRoutes configura tutte le route relative alle foto
 func (pc *PhotoController) SetupRoutes(api *gin.RouterGroup) {
+	requireAdmin := pc.adminAuth.Require()
+
 	photos := api.Group("/photos")
 	{
-		photos.POST("", pc.AddPhoto)
+		photos.POST("", pc.rateLimiter.Require(manager.RateLimitUpload), pc.AddPhoto)
 		photos.GET("", pc.GetPhotos)
+		photos.GET("/pending", requireAdmin, pc.GetPendingPhotos)
+		photos.GET("/:name/original", middleware.CountServedBytes(metrics.KindOriginal), pc.GetOriginal)
+		photos.HEAD("/:name/original", pc.GetOriginal)
+		photos.POST("/:name/approve", requireAdmin, pc.ApprovePhoto)
+		photos.DELETE("/:name", requireAdmin, pc.DeletePhoto)
 	}
 }
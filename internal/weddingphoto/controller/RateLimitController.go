@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"net/http"
+
+	"wedding-photo-backend/internal/weddingphoto/middleware"
+	"wedding-photo-backend/internal/weddingphoto/model"
+	"wedding-photo-backend/internal/weddingphoto/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitController gestisce i limiti di richieste dell'evento
+type RateLimitController struct {
+	rateLimitService *service.RateLimitService
+	adminAuth        *middleware.AdminAuth
+}
+
+// NewRateLimitController crea una nuova istanza del controller
+func NewRateLimitController(rateLimitService *service.RateLimitService, adminAuth *middleware.AdminAuth) *RateLimitController {
+	return &RateLimitController{
+		rateLimitService: rateLimitService,
+		adminAuth:        adminAuth,
+	}
+}
+
+// GetRateLimits restituisce i limiti di richieste in vigore
+// @Summary Recupera i limiti di richieste
+// @Description Restituisce per ogni budget (upload, read) le richieste consentite per ospite e per indirizzo IP, riservato agli amministratori
+// @Tags admin
+// @Security AdminToken
+// @Produce json
+// @Success 200 {object} model.RateLimitsResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Router /api/admin/rate-limits [get]
+func (rc *RateLimitController) GetRateLimits(c *gin.Context) {
+	c.JSON(http.StatusOK, model.RateLimitsResponse{
+		Limits: rc.rateLimitService.GetLimits(),
+	})
+}
+
+// UpdateRateLimit modifica il limite di un budget
+// @Summary Modifica un limite di richieste
+// @Description Sostituisce per questo evento il limite configurato di un budget, ad esempio per consentire più upload durante la festa.
+// @Description La modifica vale per tutte le istanze entro pochi secondi, riservato agli amministratori.
+// @Tags admin
+// @Security AdminToken
+// @Accept json
+// @Produce json
+// @Param budget path string true "Budget" Enums(upload, read)
+// @Param request body model.UpdateRateLimitRequest true "Nuovo limite"
+// @Success 200 {object} model.RateLimit
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /api/admin/rate-limits/{budget} [put]
+func (rc *RateLimitController) UpdateRateLimit(c *gin.Context) {
+	var request model.UpdateRateLimitRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, invalidRequest(err))
+		return
+	}
+
+	limit, err := rc.rateLimitService.UpdateLimit(c.Param("budget"), request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, limit)
+}
+
+// ResetRateLimit ripristina il limite configurato di un budget
+// @Summary Ripristina un limite di richieste
+// @Description Annulla la modifica del limite di un budget, tornando al valore della configurazione, riservato agli amministratori
+// @Tags admin
+// @Security AdminToken
+// @Produce json
+// @Param budget path string true "Budget" Enums(upload, read)
+// @Success 200 {object} model.RateLimit
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /api/admin/rate-limits/{budget} [delete]
+func (rc *RateLimitController) ResetRateLimit(c *gin.Context) {
+	limit, err := rc.rateLimitService.ResetLimit(c.Param("budget"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, limit)
+}
+
+// SetupRoutes configura tutte le route relative ai limiti di richieste
+func (rc *RateLimitController) SetupRoutes(api *gin.RouterGroup) {
+	rateLimits := api.Group("/admin/rate-limits", rc.adminAuth.Require())
+	{
+		rateLimits.GET("", rc.GetRateLimits)
+		rateLimits.PUT("/:budget", rc.UpdateRateLimit)
+		rateLimits.DELETE("/:budget", rc.ResetRateLimit)
+	}
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"net/http"
+	"sort"
+
+	"wedding-photo-backend/internal/weddingphoto/model"
+	"wedding-photo-backend/internal/weddingphoto/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReactionController gestisce le reazioni degli ospiti alle foto
+type ReactionController struct {
+	reactionService *service.ReactionService
+}
+
+// NewReactionController crea una nuova istanza del controller
+func NewReactionController(reactionService *service.ReactionService) *ReactionController {
+	return &ReactionController{
+		reactionService: reactionService,
+	}
+}
+
+// GetReactionTypes restituisce i tipi di reazione ammessi
+// @Summary Recupera i tipi di reazione
+// @Description Ottiene l'elenco delle reazioni che gli ospiti possono lasciare sulle foto
+// @Tags reactions
+// @Produce json
+// @Success 200 {object} model.GetReactionTypesResponse
+// @Router /api/reactions [get]
+func (rc *ReactionController) GetReactionTypes(c *gin.Context) {
+	reactionTypes := make([]model.ReactionType, 0, len(service.ReactionTypes))
+	for reactionType, emoji := range service.ReactionTypes {
+		reactionTypes = append(reactionTypes, model.ReactionType{
+			Type:  reactionType,
+			Emoji: emoji,
+		})
+	}
+	sort.Slice(reactionTypes, func(i, j int) bool {
+		return reactionTypes[i].Type < reactionTypes[j].Type
+	})
+
+	c.JSON(http.StatusOK, model.GetReactionTypesResponse{
+		ReactionTypes: reactionTypes,
+	})
+}
+
+// AddReaction aggiunge la reazione dell'ospite a una foto
+// @Summary Aggiunge una reazione
+// @Description Registra la reazione dell'ospite a una foto; ogni ospite può lasciare una sola reazione per tipo
+// @Tags reactions
+// @Accept json
+// @Produce json
+// @Param name path string true "Nome della foto"
+// @Param X-Guest-Token header string true "Token che identifica l'ospite"
+// @Param reaction body model.AddReactionRequest true "Reazione"
+// @Success 200 {object} model.ReactionsResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/photos/{name}/reactions [post]
+func (rc *ReactionController) AddReaction(c *gin.Context) {
+	guestToken, ok := requireGuestToken(c)
+	if !ok {
+		return
+	}
+
+	var request model.AddReactionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, invalidRequest(err))
+		return
+	}
+
+	imageName := c.Param("name")
+	reactions, err := rc.reactionService.AddReaction(imageName, request.Type, guestToken)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ReactionsResponse{
+		ImageName: imageName,
+		Reactions: reactions,
+	})
+}
+
+// RemoveReaction rimuove la reazione dell'ospite da una foto
+// @Summary Rimuove una reazione
+// @Description Rimuove la reazione dell'ospite del tipo indicato
+// @Tags reactions
+// @Produce json
+// @Param name path string true "Nome della foto"
+// @Param type query string true "Tipo di reazione"
+// @Param X-Guest-Token header string true "Token che identifica l'ospite"
+// @Success 200 {object} model.ReactionsResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/photos/{name}/reactions [delete]
+func (rc *ReactionController) RemoveReaction(c *gin.Context) {
+	guestToken, ok := requireGuestToken(c)
+	if !ok {
+		return
+	}
+
+	imageName := c.Param("name")
+	reactions, err := rc.reactionService.RemoveReaction(imageName, c.Query("type"), guestToken)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ReactionsResponse{
+		ImageName: imageName,
+		Reactions: reactions,
+	})
+}
+
+// SetupRoutes configura tutte le route relative alle reazioni
+func (rc *ReactionController) SetupRoutes(api *gin.RouterGroup) {
+	api.GET("/reactions", rc.GetReactionTypes)
+
+	photos := api.Group("/photos")
+	{
+		photos.POST("/:name/reactions", rc.AddReaction)
+		photos.DELETE("/:name/reactions", rc.RemoveReaction)
+	}
+}
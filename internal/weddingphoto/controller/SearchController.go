@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"net/http"
+
+	"wedding-photo-backend/internal/weddingphoto/model"
+	"wedding-photo-backend/internal/weddingphoto/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchController gestisce la ricerca delle foto
+type SearchController struct {
+	searchService *service.SearchService
+}
+
+// NewSearchController crea una nuova istanza del controller
+func NewSearchController(searchService *service.SearchService) *SearchController {
+	return &SearchController{
+		searchService: searchService,
+	}
+}
+
+// Search cerca le foto per testo e filtri
+// @Summary Cerca le foto
+// @Description Cerca nelle didascalie, nei tag e negli autori delle foto, con filtri per autore, data, fotocamera e tipo di contenuto; restituisce anche i conteggi per filtro su tutti i risultati
+// @Tags search
+// @Produce json
+// @Param q query string false "Testo da cercare"
+// @Param uploader query string false "Nome di chi ha caricato la foto"
+// @Param from query string false "Data iniziale (AAAA-MM-GG o RFC3339)"
+// @Param to query string false "Data finale, inclusa (AAAA-MM-GG o RFC3339)"
+// @Param camera query string false "Fotocamera"
+// @Param media_type query string false "Tipo di contenuto: image o video"
+// @Param page query int false "Numero pagina (default: 1)"
+// @Param per_page query int false "Elementi per pagina (default: 10, max: 100)"
+// @Success 200 {object} model.SearchResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/search [get]
+func (sc *SearchController) Search(c *gin.Context) {
+	page, perPage := parsePagination(c)
+
+	query := service.SearchQuery{
+		Text:      c.Query("q"),
+		Uploader:  c.Query("uploader"),
+		From:      c.Query("from"),
+		To:        c.Query("to"),
+		Camera:    c.Query("camera"),
+		MediaType: c.Query("media_type"),
+	}
+
+	photos, totalPages, totalCount, facets, err := sc.searchService.Search(query, page, perPage)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SearchResponse{
+		Photos:     photos,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalCount: totalCount,
+		Facets:     facets,
+	})
+}
+
+// SetupRoutes configura tutte le route relative alla ricerca
+func (sc *SearchController) SetupRoutes(api *gin.RouterGroup) {
+	api.GET("/search", sc.Search)
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"wedding-photo-backend/internal/weddingphoto/middleware"
+	"wedding-photo-backend/internal/weddingphoto/model"
+	"wedding-photo-backend/internal/weddingphoto/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SlideshowController gestisce lo slideshow proiettato durante il ricevimento
+type SlideshowController struct {
+	slideshowService *service.SlideshowService
+	adminAuth        *middleware.AdminAuth
+}
+
+// NewSlideshowController crea una nuova istanza del controller
+func NewSlideshowController(slideshowService *service.SlideshowService, adminAuth *middleware.AdminAuth) *SlideshowController {
+	return &SlideshowController{
+		slideshowService: slideshowService,
+		adminAuth:        adminAuth,
+	}
+}
+
+// GetSlideshow restituisce la foto da proiettare e le prossime previste
+// @Summary Recupera lo stato dello slideshow
+// @Description Restituisce la foto da mostrare, il tempo rimanente e le prossime foto; lo stato è condiviso da tutti gli schermi. Le foto mai mostrate compaiono per prime dalla più recente, poi le altre in ordine casuale senza ripetizioni entro repeat_window slide, con una foto fissata ogni pin_every slide.
+// @Tags slideshow
+// @Produce json
+// @Param count query int false "Numero di prossime foto da restituire (default: 10, max: 100)"
+// @Success 200 {object} model.SlideshowResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/slideshow [get]
+func (sc *SlideshowController) GetSlideshow(c *gin.Context) {
+	count := service.DefaultUpcomingSlides
+	if countParam := c.Query("count"); countParam != "" {
+		if n, err := strconv.Atoi(countParam); err == nil && n >= 0 && n <= 100 {
+			count = n
+		}
+	}
+
+	slideshow, err := sc.slideshowService.GetSlideshow(count)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, slideshow)
+}
+
+// Control invia un comando allo slideshow
+// @Summary Controlla lo slideshow
+// @Description Mette in pausa, riprende, salta la slide corrente, fissa o sblocca una foto, la esclude o la reinserisce nello slideshow; riservato agli amministratori
+// @Tags slideshow
+// @Security AdminToken
+// @Accept json
+// @Produce json
+// @Param control body model.SlideshowControlRequest true "Comando"
+// @Success 200 {object} model.SlideshowResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /api/slideshow/control [post]
+func (sc *SlideshowController) Control(c *gin.Context) {
+	var request model.SlideshowControlRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, invalidRequest(err))
+		return
+	}
+
+	slideshow, err := sc.slideshowService.Control(request.Action, request.ImageName)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, slideshow)
+}
+
+// UpdateSettings modifica le impostazioni dello slideshow
+// @Summary Modifica le impostazioni dello slideshow
+// @Description Modifica la durata delle slide, la frequenza delle foto fissate e la finestra di non ripetizione; riservato agli amministratori
+// @Tags slideshow
+// @Security AdminToken
+// @Accept json
+// @Produce json
+// @Param settings body model.SlideshowSettingsRequest true "Impostazioni da modificare"
+// @Success 200 {object} model.SlideshowResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Router /api/slideshow/settings [put]
+func (sc *SlideshowController) UpdateSettings(c *gin.Context) {
+	var request model.SlideshowSettingsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, invalidRequest(err))
+		return
+	}
+
+	slideshow, err := sc.slideshowService.UpdateSettings(request)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, slideshow)
+}
+
+// SetupRoutes configura tutte le route relative allo slideshow
+func (sc *SlideshowController) SetupRoutes(api *gin.RouterGroup) {
+	requireAdmin := sc.adminAuth.Require()
+
+	slideshow := api.Group("/slideshow")
+	{
+		slideshow.GET("", sc.GetSlideshow)
+		slideshow.POST("/control", requireAdmin, sc.Control)
+		slideshow.PUT("/settings", requireAdmin, sc.UpdateSettings)
+	}
+}
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"wedding-photo-backend/internal/weddingphoto/middleware"
+	"wedding-photo-backend/internal/weddingphoto/model"
+	"wedding-photo-backend/internal/weddingphoto/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagController gestisce tag e persone associate alle foto
+type TagController struct {
+	tagService *service.TagService
+	adminAuth  *middleware.AdminAuth
+}
+
+// NewTagController crea una nuova istanza del controller
+func NewTagController(tagService *service.TagService, adminAuth *middleware.AdminAuth) *TagController {
+	return &TagController{
+		tagService: tagService,
+		adminAuth:  adminAuth,
+	}
+}
+
+// GetPhotoTags restituisce tag e persone di una foto
+// @Summary Recupera i tag di una foto
+// @Tags tags
+// @Produce json
+// @Param name path string true "Nome della foto"
+// @Success 200 {object} model.PhotoTagsResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/photos/{name}/tags [get]
+func (tc *TagController) GetPhotoTags(c *gin.Context) {
+	imageName := c.Param("name")
+	tags, people, err := tc.tagService.GetPhotoTags(imageName)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.PhotoTagsResponse{
+		ImageName: imageName,
+		Tags:      tags,
+		People:    people,
+	})
+}
+
+// AddPhotoTags aggiunge tag e persone a una foto
+// @Summary Aggiunge tag a una foto
+// @Description Aggiunge tag liberi e persone a una foto; consentito a chi ha caricato la foto e agli amministratori
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param name path string true "Nome della foto"
+// @Param X-Guest-Token header string false "Token dell'ospite che ha caricato la foto"
+// @Param tags body model.PhotoTagsRequest true "Tag e persone da aggiungere"
+// @Success 200 {object} model.PhotoTagsResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /api/photos/{name}/tags [post]
+func (tc *TagController) AddPhotoTags(c *gin.Context) {
+	var request model.PhotoTagsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, invalidRequest(err))
+		return
+	}
+
+	imageName := c.Param("name")
+	err := tc.tagService.AddTags(imageName, request.Tags, request.People, optionalGuestToken(c), tc.adminAuth.IsAdmin(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	tc.GetPhotoTags(c)
+}
+
+// RemovePhotoTag rimuove un tag o una persona da una foto
+// @Summary Rimuove un tag da una foto
+// @Description Rimuove un tag libero o una persona da una foto; consentito a chi ha caricato la foto e agli amministratori
+// @Tags tags
+// @Param name path string true "Nome della foto"
+// @Param tag path string true "Tag o persona da rimuovere"
+// @Param kind query string false "Tipo: tag (default) o person"
+// @Param X-Guest-Token header string false "Token dell'ospite che ha caricato la foto"
+// @Success 204
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Router /api/photos/{name}/tags/{tag} [delete]
+func (tc *TagController) RemovePhotoTag(c *gin.Context) {
+	kind := c.DefaultQuery("kind", service.TagKindTag)
+	err := tc.tagService.RemoveTag(c.Param("name"), kind, c.Param("tag"), optionalGuestToken(c), tc.adminAuth.IsAdmin(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SuggestTags restituisce i tag esistenti per l'autocompletamento
+// @Summary Autocompletamento dei tag
+// @Description Restituisce i tag e le persone già usati che iniziano con il testo indicato, dai più usati
+// @Tags tags
+// @Produce json
+// @Param q query string false "Testo iniziale del tag"
+// @Param kind query string false "Tipo: tag o person (default: entrambi)"
+// @Param limit query int false "Numero massimo di suggerimenti (default: 10, max: 50)"
+// @Success 200 {object} model.GetTagSuggestionsResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/tags [get]
+func (tc *TagController) SuggestTags(c *gin.Context) {
+	limit := 10
+	if limitParam := c.Query("limit"); limitParam != "" {
+		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 && l <= 50 {
+			limit = l
+		}
+	}
+
+	suggestions, err := tc.tagService.SuggestTags(c.Query("q"), c.Query("kind"), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	response := model.GetTagSuggestionsResponse{
+		Suggestions: make([]model.TagSuggestion, 0, len(suggestions)),
+	}
+	for _, suggestion := range suggestions {
+		response.Suggestions = append(response.Suggestions, model.TagSuggestion{
+			Name:       suggestion.Name,
+			Kind:       suggestion.Kind,
+			PhotoCount: suggestion.PhotoCount,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetupRoutes configura tutte le route relative ai tag
+func (tc *TagController) SetupRoutes(api *gin.RouterGroup) {
+	api.GET("/tags", tc.SuggestTags)
+
+	photos := api.Group("/photos")
+	{
+		photos.GET("/:name/tags", tc.GetPhotoTags)
+		photos.POST("/:name/tags", tc.AddPhotoTags)
+		photos.DELETE("/:name/tags/:tag", tc.RemovePhotoTag)
+	}
+}
@@ -0,0 +1,233 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale è la lingua usata quando quella richiesta non è disponibile
+const DefaultLocale = "it"
+
+//go:embed locales/*.json
+var embeddedBundles embed.FS
+
+// placeholderPattern riconosce i segnaposto {nome} nei messaggi, sostituiti con i dettagli dell'errore
+var placeholderPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// bundle contiene i messaggi di una lingua: per ogni codice una o più varianti,
+// dalla più specifica, da usare se tutti i segnaposto hanno un valore
+type bundle map[string][]string
+
+// UnmarshalJSON accetta per ogni codice sia un singolo messaggio sia un elenco di varianti
+func (b *bundle) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*b = bundle{}
+	for key, value := range raw {
+		var single string
+		if err := json.Unmarshal(value, &single); err == nil {
+			(*b)[key] = []string{single}
+			continue
+		}
+		var variants []string
+		if err := json.Unmarshal(value, &variants); err != nil {
+			return fmt.Errorf("messaggio %q non valido: deve essere una stringa o un elenco di stringhe", key)
+		}
+		(*b)[key] = variants
+	}
+	return nil
+}
+
+var (
+	bundlesMutex sync.RWMutex
+	bundles      = map[string]bundle{}
+)
+
+func init() {
+	entries, err := embeddedBundles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		data, err := embeddedBundles.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		if err := addBundle(strings.TrimSuffix(entry.Name(), ".json"), data); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// LoadDir carica le traduzioni aggiuntive da una directory con un file <lingua>.json per lingua;
+// i messaggi sostituiscono quelli inclusi nel programma con lo stesso codice
+func LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("errore nella lettura di %s: %v", file, err)
+		}
+		if err := addBundle(strings.TrimSuffix(filepath.Base(file), ".json"), data); err != nil {
+			return fmt.Errorf("errore nella lettura di %s: %v", file, err)
+		}
+	}
+	return nil
+}
+
+// addBundle aggiunge i messaggi di una lingua al catalogo
+func addBundle(locale string, data []byte) error {
+	var messages bundle
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return err
+	}
+
+	locale = normalizeLocale(locale)
+	bundlesMutex.Lock()
+	defer bundlesMutex.Unlock()
+	if bundles[locale] == nil {
+		bundles[locale] = bundle{}
+	}
+	for key, variants := range messages {
+		bundles[locale][key] = variants
+	}
+	return nil
+}
+
+// Locales restituisce le lingue disponibili
+func Locales() []string {
+	bundlesMutex.RLock()
+	defer bundlesMutex.RUnlock()
+
+	locales := make([]string, 0, len(bundles))
+	for locale := range bundles {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Negotiate sceglie la lingua della risposta: prima il parametro lang, poi l'header Accept-Language
+// in ordine di preferenza, infine DefaultLocale
+func Negotiate(lang, acceptLanguage string) string {
+	if locale, ok := supported(lang); ok {
+		return locale
+	}
+
+	type candidate struct {
+		tag     string
+		quality float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = q
+			}
+		}
+		if tag != "" && tag != "*" && quality > 0 {
+			candidates = append(candidates, candidate{tag: tag, quality: quality})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, candidate := range candidates {
+		if locale, ok := supported(candidate.tag); ok {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// supported restituisce la lingua disponibile per un tag, passando alla lingua base (en-GB → en) se necessario
+func supported(tag string) (string, bool) {
+	bundlesMutex.RLock()
+	defer bundlesMutex.RUnlock()
+
+	for _, locale := range fallbackChain(tag) {
+		if _, ok := bundles[locale]; ok {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// Message restituisce il messaggio di un codice nella lingua indicata, con i segnaposto sostituiti
+// dai dettagli. Se la lingua non contiene il codice prova la lingua base e poi DefaultLocale;
+// ok è false se nessuna di queste ha un messaggio utilizzabile.
+func Message(locale, code string, details map[string]interface{}) (string, bool) {
+	bundlesMutex.RLock()
+	defer bundlesMutex.RUnlock()
+
+	chain := append(fallbackChain(locale), DefaultLocale)
+	for _, candidate := range chain {
+		for _, variant := range bundles[candidate][code] {
+			if message, ok := render(bundles[candidate], variant, details); ok {
+				return message, true
+			}
+		}
+	}
+	return "", false
+}
+
+// render sostituisce i segnaposto di un messaggio; i nomi dei campi vengono a loro volta tradotti
+// con le chiavi field.<nome>. Restituisce false se manca il valore di un segnaposto.
+func render(messages bundle, template string, details map[string]interface{}) (string, bool) {
+	complete := true
+	message := placeholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		value, ok := details[name]
+		if !ok {
+			complete = false
+			return placeholder
+		}
+		text := fmt.Sprint(value)
+		if name == "field" {
+			if labels := messages["field."+text]; len(labels) > 0 {
+				text = labels[0]
+			}
+		}
+		return text
+	})
+	return message, complete
+}
+
+// fallbackChain restituisce la lingua normalizzata seguita dalle lingue più generiche (pt-br → pt)
+func fallbackChain(tag string) []string {
+	locale := normalizeLocale(tag)
+	if locale == "" {
+		return nil
+	}
+	chain := []string{locale}
+	for {
+		index := strings.LastIndex(locale, "-")
+		if index <= 0 {
+			return chain
+		}
+		locale = locale[:index]
+		chain = append(chain, locale)
+	}
+}
+
+// normalizeLocale porta un tag di lingua in minuscolo con il trattino come separatore
+func normalizeLocale(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
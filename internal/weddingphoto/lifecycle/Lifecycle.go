@@ -0,0 +1,220 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Options regola l'arresto del server
+type Options struct {
+	// Delay è l'attesa tra il segnale di arresto e la chiusura del listener, durante la quale
+	// il server risponde ancora ma /readyz è già non pronto, così i load balancer smettono di inviare richieste
+	Delay time.Duration
+	// Timeout è il tempo massimo per completare le richieste in corso e fermare i worker
+	Timeout time.Duration
+}
+
+// closer è una risorsa da chiudere all'arresto
+type closer struct {
+	name  string
+	close func() error
+}
+
+// Lifecycle coordina i worker in background e l'arresto ordinato del server: alla ricezione di
+// SIGINT o SIGTERM smette di accettare connessioni, attende le richieste in corso (ad esempio gli
+// upload), ferma i worker lasciando terminare il lavoro già iniziato e chiude le risorse in ordine
+// inverso rispetto alla registrazione
+type Lifecycle struct {
+	options Options
+
+	workersCtx  context.Context
+	stopWorkers context.CancelFunc
+	workers     sync.WaitGroup
+
+	draining  atomic.Bool
+	startedAt time.Time
+
+	mu      sync.Mutex
+	running map[string]int
+	closers []closer
+}
+
+// New crea il gestore del ciclo di vita
+func New(options Options) *Lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Lifecycle{
+		options:     options,
+		workersCtx:  ctx,
+		stopWorkers: cancel,
+		startedAt:   time.Now(),
+		running:     map[string]int{},
+	}
+}
+
+// Go avvia un worker in background; run deve terminare quando il context viene cancellato,
+// dopo aver completato il lavoro in corso
+func (l *Lifecycle) Go(name string, run func(ctx context.Context)) {
+	l.workers.Add(1)
+	l.mu.Lock()
+	l.running[name]++
+	l.mu.Unlock()
+
+	go func() {
+		defer l.workers.Done()
+		defer func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.running[name]--; l.running[name] == 0 {
+				delete(l.running, name)
+			}
+		}()
+		run(l.workersCtx)
+		if l.workersCtx.Err() == nil {
+			slog.Warn("Worker terminato prima dell'arresto", "worker", name)
+		}
+	}()
+}
+
+// OnClose registra una risorsa da chiudere all'arresto, dopo l'arresto dei worker
+func (l *Lifecycle) OnClose(name string, close func() error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closers = append(l.closers, closer{name: name, close: close})
+}
+
+// Workers restituisce i nomi dei worker in esecuzione, in ordine alfabetico
+func (l *Lifecycle) Workers() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	names := make([]string, 0, len(l.running))
+	for name, count := range l.running {
+		for i := 0; i < count; i++ {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Uptime restituisce il tempo trascorso dall'avvio
+func (l *Lifecycle) Uptime() time.Duration {
+	return time.Since(l.startedAt)
+}
+
+// Draining indica se l'arresto è iniziato e il server non deve più ricevere nuove richieste
+func (l *Lifecycle) Draining() bool {
+	return l.draining.Load()
+}
+
+// Serve avvia il server HTTP e ne gestisce l'arresto alla ricezione di SIGINT o SIGTERM;
+// un secondo segnale interrompe subito il processo
+func (l *Lifecycle) Serve(server *http.Server) error {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	var err error
+	delay := l.options.Delay
+	select {
+	case err = <-serverErr:
+		// Il server non è partito: non ci sono richieste da attendere
+		err = fmt.Errorf("errore nell'avvio del server: %w", err)
+		delay = 0
+	case sig := <-signals:
+		slog.Info("Segnale ricevuto, arresto in corso", "signal", sig.String())
+		go func() {
+			sig := <-signals
+			slog.Warn("Segnale ricevuto durante l'arresto, uscita immediata", "signal", sig.String())
+			os.Exit(1)
+		}()
+	}
+
+	return errors.Join(err, l.shutdown(server, delay))
+}
+
+// Wait esegue i worker fino alla ricezione di SIGINT o SIGTERM e poi li arresta chiudendo le risorse,
+// per i processi senza server HTTP; un secondo segnale interrompe subito il processo
+func (l *Lifecycle) Wait() error {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	sig := <-signals
+	slog.Info("Segnale ricevuto, arresto in corso", "signal", sig.String())
+	go func() {
+		sig := <-signals
+		slog.Warn("Segnale ricevuto durante l'arresto, uscita immediata", "signal", sig.String())
+		os.Exit(1)
+	}()
+	return l.shutdown(nil, 0)
+}
+
+// Close arresta i worker e chiude le risorse senza attendere segnali, al termine dei comandi
+// che non restano in esecuzione
+func (l *Lifecycle) Close() error {
+	return l.shutdown(nil, 0)
+}
+
+// shutdown esegue l'arresto ordinato: readiness non pronta, attesa delle richieste in corso se c'è
+// un server, arresto dei worker e chiusura delle risorse
+func (l *Lifecycle) shutdown(server *http.Server, delay time.Duration) error {
+	l.draining.Store(true)
+	if delay > 0 {
+		slog.Info("Attesa prima di chiudere le connessioni", "delay", delay.String())
+		time.Sleep(delay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.options.Timeout)
+	defer cancel()
+
+	var errs []error
+	if server != nil {
+		if err := server.Shutdown(ctx); err != nil {
+			// Le richieste ancora aperte allo scadere del tempo vengono interrotte
+			server.Close()
+			errs = append(errs, fmt.Errorf("richieste ancora in corso dopo %s: %w", l.options.Timeout, err))
+		}
+	}
+
+	l.stopWorkers()
+	stopped := make(chan struct{})
+	go func() {
+		l.workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("worker ancora attivi dopo %s", l.options.Timeout))
+	}
+
+	l.mu.Lock()
+	closers := l.closers
+	l.mu.Unlock()
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].close(); err != nil {
+			errs = append(errs, fmt.Errorf("errore nella chiusura di %s: %w", closers[i].name, err))
+		}
+	}
+
+	if len(errs) == 0 {
+		slog.Info("Arresto completato")
+	}
+	return errors.Join(errs...)
+}
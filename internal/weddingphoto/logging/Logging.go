@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// FormatJSON scrive un oggetto JSON per riga, adatto alla raccolta dei log
+	FormatJSON = "json"
+	// FormatText scrive righe chiave=valore, più leggibili durante lo sviluppo
+	FormatText = "text"
+
+	// RequestIDKey è l'attributo con l'identificativo della richiesta che ha originato il log
+	RequestIDKey = "request_id"
+	// TraceIDKey e SpanIDKey collegano il log alla traccia OpenTelemetry in corso
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+type requestIDKey struct{}
+
+// ParseLevel interpreta un livello di log: debug, info, warn o error
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		return 0, fmt.Errorf("livello di log %q non valido, valori ammessi debug, info, warn, error", value)
+	}
+	return level, nil
+}
+
+// New crea un logger nel formato indicato che scrive su w i messaggi dal livello indicato in su.
+// I messaggi registrati con un context che contiene un identificativo di richiesta lo riportano
+// nell'attributo request_id, insieme a trace_id e span_id se il context appartiene a una traccia
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	parsedLevel, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &slog.HandlerOptions{Level: parsedLevel}
+	var handler slog.Handler
+	switch strings.TrimSpace(format) {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, options)
+	case FormatText:
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("formato di log %q non valido, valori ammessi json, text", format)
+	}
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+// WithRequestID restituisce un context che porta l'identificativo della richiesta nei log
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID restituisce l'identificativo della richiesta contenuto nel context, o una stringa vuota
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID genera un identificativo casuale per una richiesta
+func NewRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
+
+// contextHandler aggiunge ai record l'identificativo della richiesta e la traccia contenuti nel context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String(RequestIDKey, id))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String(TraceIDKey, spanContext.TraceID().String()), slog.String(SpanIDKey, spanContext.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,216 @@
+package manager
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AlbumRecord rappresenta un album così come è salvato nel database
+type AlbumRecord struct {
+	ID           int64
+	Title        string
+	Description  string
+	CoverPhoto   string
+	GuestUploads bool
+	PhotoCount   int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// AlbumManager gestisce la persistenza degli album e delle foto associate
+type AlbumManager struct {
+	db *sql.DB
+}
+
+// NewAlbumManager crea una nuova istanza del manager
+func NewAlbumManager(metadataManager *MetadataManager) *AlbumManager {
+	return &AlbumManager{
+		db: metadataManager.DB(),
+	}
+}
+
+const albumColumns = `a.id, a.title, a.description, a.cover_photo, a.guest_uploads, a.created_at, a.updated_at,
+	(SELECT COUNT(*) FROM album_photos ap WHERE ap.album_id = a.id)`
+
+// scanAlbum legge una riga prodotta da una query su albumColumns
+func scanAlbum(row interface{ Scan(...any) error }) (*AlbumRecord, error) {
+	var album AlbumRecord
+	var createdAt, updatedAt int64
+	err := row.Scan(&album.ID, &album.Title, &album.Description, &album.CoverPhoto,
+		&album.GuestUploads, &createdAt, &updatedAt, &album.PhotoCount)
+	if err != nil {
+		return nil, err
+	}
+	album.CreatedAt = time.Unix(createdAt, 0).UTC()
+	album.UpdatedAt = time.Unix(updatedAt, 0).UTC()
+	return &album, nil
+}
+
+// CreateAlbum crea un nuovo album e ne restituisce l'identificativo
+func (am *AlbumManager) CreateAlbum(title, description, coverPhoto string, guestUploads bool) (int64, error) {
+	now := time.Now().Unix()
+	result, err := am.db.Exec(
+		`INSERT INTO albums (title, description, cover_photo, guest_uploads, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		title, description, coverPhoto, guestUploads, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("errore nella creazione dell'album: %v", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetAlbum restituisce un album, o nil se non esiste
+func (am *AlbumManager) GetAlbum(id int64) (*AlbumRecord, error) {
+	row := am.db.QueryRow(`SELECT `+albumColumns+` FROM albums a WHERE a.id = ?`, id)
+	album, err := scanAlbum(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero dell'album: %v", err)
+	}
+	return album, nil
+}
+
+// GetAlbums restituisce tutti gli album in ordine di creazione
+func (am *AlbumManager) GetAlbums() ([]AlbumRecord, error) {
+	rows, err := am.db.Query(`SELECT ` + albumColumns + ` FROM albums a ORDER BY a.id`)
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero degli album: %v", err)
+	}
+	defer rows.Close()
+
+	var albums []AlbumRecord
+	for rows.Next() {
+		album, err := scanAlbum(rows)
+		if err != nil {
+			return nil, fmt.Errorf("errore nella lettura dell'album: %v", err)
+		}
+		albums = append(albums, *album)
+	}
+	return albums, rows.Err()
+}
+
+// UpdateAlbum salva le modifiche ai campi modificabili di un album
+func (am *AlbumManager) UpdateAlbum(album *AlbumRecord) error {
+	_, err := am.db.Exec(
+		`UPDATE albums SET title = ?, description = ?, cover_photo = ?, guest_uploads = ?, updated_at = ? WHERE id = ?`,
+		album.Title, album.Description, album.CoverPhoto, album.GuestUploads, time.Now().Unix(), album.ID)
+	if err != nil {
+		return fmt.Errorf("errore nell'aggiornamento dell'album: %v", err)
+	}
+	return nil
+}
+
+// DeleteAlbum elimina un album; le foto restano salvate, viene rimossa solo l'associazione
+func (am *AlbumManager) DeleteAlbum(id int64) error {
+	if _, err := am.db.Exec(`DELETE FROM albums WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("errore nell'eliminazione dell'album: %v", err)
+	}
+	return nil
+}
+
+// GetAlbumPhotoNames restituisce i nomi delle foto di un album nell'ordine dell'album
+func (am *AlbumManager) GetAlbumPhotoNames(id int64) ([]string, error) {
+	rows, err := am.db.Query(`SELECT image_name FROM album_photos WHERE album_id = ? ORDER BY position, added_at`, id)
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero delle foto dell'album: %v", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("errore nella lettura delle foto dell'album: %v", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// GetAlbumPhotoPositions restituisce la posizione nell'album di ogni foto; le foto aggiunte dopo hanno
+// posizioni maggiori e le posizioni cambiano solo riordinando l'album
+func (am *AlbumManager) GetAlbumPhotoPositions(id int64) (map[string]int, error) {
+	rows, err := am.db.Query(`SELECT image_name, position FROM album_photos WHERE album_id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero delle foto dell'album: %v", err)
+	}
+	defer rows.Close()
+
+	positions := map[string]int{}
+	for rows.Next() {
+		var name string
+		var position int
+		if err := rows.Scan(&name, &position); err != nil {
+			return nil, fmt.Errorf("errore nella lettura delle foto dell'album: %v", err)
+		}
+		positions[name] = position
+	}
+	return positions, rows.Err()
+}
+
+// AddPhotosToAlbum aggiunge le foto in coda all'album, ignorando quelle già presenti
+func (am *AlbumManager) AddPhotosToAlbum(id int64, imageNames []string) error {
+	tx, err := am.db.Begin()
+	if err != nil {
+		return fmt.Errorf("errore nell'aggiunta delle foto all'album: %v", err)
+	}
+	defer tx.Rollback()
+
+	var position int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(position), -1) + 1 FROM album_photos WHERE album_id = ?`, id).Scan(&position); err != nil {
+		return fmt.Errorf("errore nell'aggiunta delle foto all'album: %v", err)
+	}
+
+	now := time.Now().Unix()
+	for _, imageName := range imageNames {
+		result, err := tx.Exec(
+			`INSERT OR IGNORE INTO album_photos (album_id, image_name, position, added_at) VALUES (?, ?, ?, ?)`,
+			id, imageName, position, now)
+		if err != nil {
+			return fmt.Errorf("errore nell'aggiunta della foto %s all'album: %v", imageName, err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			position++
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE albums SET updated_at = ? WHERE id = ?`, now, id); err != nil {
+		return fmt.Errorf("errore nell'aggiornamento dell'album: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// RemovePhotoFromAlbum rimuove una foto dall'album, restituisce false se non era presente
+func (am *AlbumManager) RemovePhotoFromAlbum(id int64, imageName string) (bool, error) {
+	result, err := am.db.Exec(`DELETE FROM album_photos WHERE album_id = ? AND image_name = ?`, id, imageName)
+	if err != nil {
+		return false, fmt.Errorf("errore nella rimozione della foto dall'album: %v", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// SetAlbumPhotoOrder riassegna le posizioni delle foto secondo l'ordine indicato
+func (am *AlbumManager) SetAlbumPhotoOrder(id int64, imageNames []string) error {
+	tx, err := am.db.Begin()
+	if err != nil {
+		return fmt.Errorf("errore nel riordino delle foto dell'album: %v", err)
+	}
+	defer tx.Rollback()
+
+	for position, imageName := range imageNames {
+		_, err := tx.Exec(`UPDATE album_photos SET position = ? WHERE album_id = ? AND image_name = ?`, position, id, imageName)
+		if err != nil {
+			return fmt.Errorf("errore nel riordino delle foto dell'album: %v", err)
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE albums SET updated_at = ? WHERE id = ?`, time.Now().Unix(), id); err != nil {
+		return fmt.Errorf("errore nell'aggiornamento dell'album: %v", err)
+	}
+
+	return tx.Commit()
+}
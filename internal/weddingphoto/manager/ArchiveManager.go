@@ -0,0 +1,154 @@
+package manager
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ArchiveEntry rappresenta un file da inserire nell'archivio ZIP: un'immagine originale oppure un
+// contenuto generato in memoria (es. il manifest)
+type ArchiveEntry struct {
+	Path      string    // Percorso del file nell'archivio
+	ImageName string    // Immagine originale da copiare, vuoto per i contenuti in memoria
+	Content   []byte    // Contenuto del file se ImageName è vuoto
+	Size      int64     // Dimensione del file
+	Modified  time.Time // Data di modifica registrata nell'archivio
+}
+
+// errRangeDone interrompe la scrittura dell'archivio una volta inviato l'intervallo richiesto
+var errRangeDone = errors.New("intervallo completato")
+
+// ArchiveManager crea archivi ZIP delle foto originali scrivendoli direttamente sul writer, senza file temporanei.
+// I file sono salvati senza compressione (le immagini sono già compresse): la struttura dell'archivio dipende
+// solo da nomi, dimensioni e date dei file, così la dimensione totale è nota in anticipo e gli archivi
+// possono essere ripresi da un offset.
+type ArchiveManager struct {
+	photoManager *PhotoManager
+}
+
+// NewArchiveManager crea una nuova istanza del manager
+func NewArchiveManager(photoManager *PhotoManager) *ArchiveManager {
+	return &ArchiveManager{
+		photoManager: photoManager,
+	}
+}
+
+// ArchiveSize calcola la dimensione esatta dell'archivio senza leggere le immagini
+func (am *ArchiveManager) ArchiveSize(entries []ArchiveEntry) (int64, error) {
+	counter := &countingWriter{}
+	if err := am.write(counter, entries, true); err != nil {
+		return 0, err
+	}
+	return counter.n, nil
+}
+
+// WriteArchive scrive l'archivio completo sul writer
+func (am *ArchiveManager) WriteArchive(w io.Writer, entries []ArchiveEntry) error {
+	return am.write(w, entries, false)
+}
+
+// WriteArchiveRange scrive solo i byte dell'archivio da offset per length byte. I file precedenti
+// vengono comunque letti, perché il loro CRC compare nella directory finale dell'archivio.
+func (am *ArchiveManager) WriteArchiveRange(w io.Writer, entries []ArchiveEntry, offset, length int64) error {
+	err := am.write(&rangeWriter{w: w, skip: offset, remaining: length}, entries, false)
+	if errors.Is(err, errRangeDone) {
+		return nil
+	}
+	return err
+}
+
+// write scrive l'archivio; con dryRun le immagini sono sostituite da zeri della stessa dimensione
+func (am *ArchiveManager) write(w io.Writer, entries []ArchiveEntry, dryRun bool) error {
+	archive := zip.NewWriter(w)
+
+	for _, entry := range entries {
+		header := &zip.FileHeader{
+			Name:     entry.Path,
+			Method:   zip.Store,
+			Modified: entry.Modified.UTC().Truncate(time.Second),
+		}
+		fileWriter, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.ImageName == "":
+			_, err = fileWriter.Write(entry.Content)
+		case dryRun:
+			_, err = io.CopyN(fileWriter, zeroReader{}, entry.Size)
+		default:
+			err = am.copyPhoto(fileWriter, entry)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// copyPhoto copia un'immagine originale nell'archivio verificando che non sia cambiata dalla preparazione
+func (am *ArchiveManager) copyPhoto(w io.Writer, entry ArchiveEntry) error {
+	file, info, err := am.photoManager.OpenPhoto(entry.ImageName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if info.Size() != entry.Size {
+		return fmt.Errorf("il file %s è stato modificato durante l'esportazione", entry.ImageName)
+	}
+	_, err = io.CopyN(w, file, entry.Size)
+	return err
+}
+
+// countingWriter conta i byte scritti
+type countingWriter struct {
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.n += int64(len(p))
+	return len(p), nil
+}
+
+// zeroReader produce zeri all'infinito
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// rangeWriter inoltra solo i byte dell'intervallo richiesto e interrompe la scrittura al termine
+type rangeWriter struct {
+	w         io.Writer
+	skip      int64
+	remaining int64
+}
+
+func (rw *rangeWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if rw.skip >= int64(len(p)) {
+		rw.skip -= int64(len(p))
+		return n, nil
+	}
+	p = p[rw.skip:]
+	rw.skip = 0
+
+	if int64(len(p)) > rw.remaining {
+		p = p[:rw.remaining]
+	}
+	if _, err := rw.w.Write(p); err != nil {
+		return 0, err
+	}
+	rw.remaining -= int64(len(p))
+	if rw.remaining == 0 {
+		return n, errRangeDone
+	}
+	return n, nil
+}
@@ -0,0 +1,100 @@
+package manager
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CommentRecord rappresenta un commento salvato nel database
+type CommentRecord struct {
+	ID         int64
+	ImageName  string
+	ParentID   *int64
+	GuestToken string
+	AuthorName string
+	Body       string
+	CreatedAt  time.Time
+}
+
+// CommentManager gestisce la persistenza dei commenti alle foto
+type CommentManager struct {
+	db *sql.DB
+}
+
+// NewCommentManager crea una nuova istanza del manager
+func NewCommentManager(metadataManager *MetadataManager) *CommentManager {
+	return &CommentManager{
+		db: metadataManager.DB(),
+	}
+}
+
+const commentColumns = `id, image_name, parent_id, guest_token, author_name, body, created_at`
+
+// scanComment legge una riga prodotta da una query su commentColumns
+func scanComment(row interface{ Scan(...any) error }) (*CommentRecord, error) {
+	var comment CommentRecord
+	var parentID sql.NullInt64
+	var createdAt int64
+	err := row.Scan(&comment.ID, &comment.ImageName, &parentID, &comment.GuestToken,
+		&comment.AuthorName, &comment.Body, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		comment.ParentID = &parentID.Int64
+	}
+	comment.CreatedAt = time.Unix(createdAt, 0).UTC()
+	return &comment, nil
+}
+
+// AddComment salva un nuovo commento e ne restituisce l'identificativo
+func (cm *CommentManager) AddComment(comment *CommentRecord) (int64, error) {
+	result, err := cm.db.Exec(
+		`INSERT INTO comments (image_name, parent_id, guest_token, author_name, body, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		comment.ImageName, comment.ParentID, comment.GuestToken, comment.AuthorName, comment.Body, comment.CreatedAt.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("errore nel salvataggio del commento: %v", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetComment restituisce un commento, o nil se non esiste
+func (cm *CommentManager) GetComment(id int64) (*CommentRecord, error) {
+	row := cm.db.QueryRow(`SELECT `+commentColumns+` FROM comments WHERE id = ?`, id)
+	comment, err := scanComment(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero del commento: %v", err)
+	}
+	return comment, nil
+}
+
+// GetComments restituisce tutti i commenti di una foto in ordine cronologico
+func (cm *CommentManager) GetComments(imageName string) ([]CommentRecord, error) {
+	rows, err := cm.db.Query(`SELECT `+commentColumns+` FROM comments WHERE image_name = ? ORDER BY created_at, id`, imageName)
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero dei commenti: %v", err)
+	}
+	defer rows.Close()
+
+	var comments []CommentRecord
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("errore nella lettura dei commenti: %v", err)
+		}
+		comments = append(comments, *comment)
+	}
+	return comments, rows.Err()
+}
+
+// DeleteComment elimina un commento insieme alle sue risposte
+func (cm *CommentManager) DeleteComment(id int64) error {
+	if _, err := cm.db.Exec(`DELETE FROM comments WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("errore nell'eliminazione del commento: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// DatabaseReactionStore conserva le reazioni direttamente nel database dei metadati, per le
+// installazioni con una sola istanza e senza Redis
+type DatabaseReactionStore struct {
+	db *sql.DB
+}
+
+// NewDatabaseReactionStore crea una nuova istanza dello store
+func NewDatabaseReactionStore(metadataManager *MetadataManager) *DatabaseReactionStore {
+	return &DatabaseReactionStore{
+		db: metadataManager.DB(),
+	}
+}
+
+// AddReaction registra la reazione di un ospite, restituisce false se era già presente
+func (rs *DatabaseReactionStore) AddReaction(imageName, reactionType, guestToken string) (bool, error) {
+	result, err := rs.db.Exec(`INSERT OR IGNORE INTO photo_reactions (image_name, type, guest_token) VALUES (?, ?, ?)`,
+		imageName, reactionType, guestToken)
+	if err != nil {
+		return false, fmt.Errorf("errore nel salvataggio della reazione: %v", err)
+	}
+	added, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("errore nel salvataggio della reazione: %v", err)
+	}
+	return added > 0, nil
+}
+
+// RemoveReaction rimuove la reazione di un ospite, restituisce false se non era presente
+func (rs *DatabaseReactionStore) RemoveReaction(imageName, reactionType, guestToken string) (bool, error) {
+	result, err := rs.db.Exec(`DELETE FROM photo_reactions WHERE image_name = ? AND type = ? AND guest_token = ?`,
+		imageName, reactionType, guestToken)
+	if err != nil {
+		return false, fmt.Errorf("errore nella rimozione della reazione: %v", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("errore nella rimozione della reazione: %v", err)
+	}
+	return removed > 0, nil
+}
+
+// DeletePhoto rimuove tutte le reazioni di una foto
+func (rs *DatabaseReactionStore) DeletePhoto(imageName string, reactionTypes []string) error {
+	if _, err := rs.db.Exec(`DELETE FROM photo_reactions WHERE image_name = ?`, imageName); err != nil {
+		return fmt.Errorf("errore nella rimozione delle reazioni di %s: %v", imageName, err)
+	}
+	return nil
+}
+
+// GetCounts restituisce, per ogni foto, il numero di reazioni per tipo
+func (rs *DatabaseReactionStore) GetCounts(imageNames []string, reactionTypes []string) (map[string]map[string]int, error) {
+	return storedReactionCounts(rs.db, imageNames)
+}
+
+// PersistDirty non fa nulla: le reazioni sono già nel database
+func (rs *DatabaseReactionStore) PersistDirty(reactionTypes []string) error {
+	return nil
+}
+
+// RestoreIfEmpty non fa nulla: le reazioni sono già nel database
+func (rs *DatabaseReactionStore) RestoreIfEmpty() error {
+	return nil
+}
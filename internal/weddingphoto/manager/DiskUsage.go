@@ -0,0 +1,8 @@
+package manager
+
+// DiskUsage contiene lo spazio del filesystem che ospita una directory, in byte
+type DiskUsage struct {
+	Total uint64
+	Free  uint64
+	Used  uint64
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package manager
+
+import "errors"
+
+// GetDiskUsage non è disponibile su questo sistema operativo
+func GetDiskUsage(path string) (DiskUsage, error) {
+	return DiskUsage{}, errors.ErrUnsupported
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package manager
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// GetDiskUsage restituisce lo spazio del filesystem che contiene path; Free è lo spazio
+// disponibile per processi non privilegiati
+func GetDiskUsage(path string) (DiskUsage, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return DiskUsage{}, fmt.Errorf("errore nella lettura dello spazio su disco: %v", err)
+	}
+
+	blockSize := uint64(stat.Bsize)
+	total := stat.Blocks * blockSize
+	return DiskUsage{
+		Total: total,
+		Free:  stat.Bavail * blockSize,
+		Used:  total - stat.Bfree*blockSize,
+	}, nil
+}
@@ -0,0 +1,15 @@
+package manager
+
+import "context"
+
+// EventBus pubblica gli eventi della galleria e li distribuisce alle istanze in ascolto, conservando
+// gli ultimi per consentire ai client di riprendere dopo una disconnessione
+type EventBus interface {
+	// Publish assegna un identificativo crescente all'evento, lo salva nello storico e lo invia
+	Publish(eventType string, data any) (*EventRecord, error)
+	// GetEventsAfter restituisce gli eventi conservati con identificativo maggiore di quello indicato, in ordine
+	GetEventsAfter(lastID int64) ([]EventRecord, error)
+	// Listen restituisce gli eventi pubblicati da questo momento; il canale viene chiuso alla
+	// cancellazione del context o se la connessione si interrompe
+	Listen(ctx context.Context) <-chan EventRecord
+}
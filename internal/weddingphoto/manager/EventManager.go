@@ -0,0 +1,142 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	EVENTS_CHANNEL     = "events"
+	EVENTS_HISTORY_KEY = "events_history"
+	EVENTS_LAST_ID_KEY = "events_last_id"
+
+	// EVENTS_HISTORY_SIZE è il numero di eventi conservati per la ripresa dei client disconnessi
+	EVENTS_HISTORY_SIZE = 1000
+)
+
+// EventRecord rappresenta un evento pubblicato, con un identificativo crescente condiviso tra le istanze
+type EventRecord struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// EventManager pubblica gli eventi sul canale Redis condiviso da tutte le istanze dell'API
+// e ne conserva gli ultimi per consentire ai client di riprendere dopo una disconnessione
+type EventManager struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+// NewEventManager crea una nuova istanza del manager
+func NewEventManager(client *redis.Client) *EventManager {
+	return &EventManager{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+// Publish assegna un identificativo all'evento, lo salva nello storico e lo invia alle istanze in ascolto
+func (em *EventManager) Publish(eventType string, data any) (*EventRecord, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("errore nella codifica dell'evento: %v", err)
+	}
+
+	id, err := em.client.Incr(em.ctx, EVENTS_LAST_ID_KEY).Result()
+	if err != nil {
+		return nil, fmt.Errorf("errore nella pubblicazione dell'evento: %v", err)
+	}
+
+	record := &EventRecord{
+		ID:        id,
+		Type:      eventType,
+		Data:      payload,
+		CreatedAt: time.Now().UTC(),
+	}
+	message, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("errore nella codifica dell'evento: %v", err)
+	}
+
+	pipe := em.client.TxPipeline()
+	pipe.ZAdd(em.ctx, EVENTS_HISTORY_KEY, redis.Z{Score: float64(id), Member: message})
+	pipe.ZRemRangeByRank(em.ctx, EVENTS_HISTORY_KEY, 0, -EVENTS_HISTORY_SIZE-1)
+	pipe.Publish(em.ctx, EVENTS_CHANNEL, message)
+	if _, err := pipe.Exec(em.ctx); err != nil {
+		return nil, fmt.Errorf("errore nella pubblicazione dell'evento: %v", err)
+	}
+
+	return record, nil
+}
+
+// GetEventsAfter restituisce gli eventi conservati con identificativo maggiore di quello indicato, in ordine
+func (em *EventManager) GetEventsAfter(lastID int64) ([]EventRecord, error) {
+	messages, err := em.client.ZRangeByScore(em.ctx, EVENTS_HISTORY_KEY, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(lastID, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero degli eventi: %v", err)
+	}
+
+	records := make([]EventRecord, 0, len(messages))
+	for _, message := range messages {
+		record, err := DecodeEvent(message)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *record)
+	}
+	return records, nil
+}
+
+// Listen si iscrive al canale degli eventi e restituisce gli eventi pubblicati da tutte le istanze;
+// il canale viene chiuso alla cancellazione del context o alla chiusura della connessione
+func (em *EventManager) Listen(ctx context.Context) <-chan EventRecord {
+	records := make(chan EventRecord)
+	go func() {
+		defer close(records)
+		pubsub := em.client.Subscribe(ctx, EVENTS_CHANNEL)
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				record, err := DecodeEvent(message.Payload)
+				if err != nil {
+					slog.Error("Errore nella lettura dell'evento", "error", err)
+					continue
+				}
+				select {
+				case records <- *record:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return records
+}
+
+// DecodeEvent legge un evento ricevuto dal canale o dallo storico
+func DecodeEvent(message string) (*EventRecord, error) {
+	var record EventRecord
+	if err := json.Unmarshal([]byte(message), &record); err != nil {
+		return nil, fmt.Errorf("evento non valido: %v", err)
+	}
+	return &record, nil
+}
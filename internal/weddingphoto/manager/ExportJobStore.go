@@ -0,0 +1,19 @@
+package manager
+
+import (
+	"context"
+	"time"
+)
+
+// ExportJobStore conserva lo stato delle esportazioni asincrone e la coda di quelle da preparare
+type ExportJobStore interface {
+	// SaveJob salva lo stato di un'esportazione, conservato per il periodo di conservazione
+	SaveJob(job *ExportJob) error
+	// GetJob restituisce un'esportazione, o nil se non esiste o è scaduta
+	GetJob(id string) (*ExportJob, error)
+	// PushJob aggiunge un'esportazione alla coda
+	PushJob(id string) error
+	// PopJob recupera dalla coda l'identificativo della prossima esportazione, attendendo al massimo
+	// timeout; restituisce una stringa vuota se la coda resta vuota
+	PopJob(ctx context.Context, timeout time.Duration) (string, error)
+}
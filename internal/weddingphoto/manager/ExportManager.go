@@ -0,0 +1,134 @@
+package manager
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExportJob rappresenta un'esportazione asincrona delle foto originali
+type ExportJob struct {
+	ID          string    `json:"id"`
+	Status      string    `json:"status"`
+	AlbumID     int64     `json:"album_id"`
+	Manifest    string    `json:"manifest"`
+	Layout      string    `json:"layout"`
+	Size        int64     `json:"size"`
+	Error       string    `json:"error"`
+	RequestID   string    `json:"request_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	CompletedAt time.Time `json:"completed_at"`
+
+	// TraceContext continua nel worker la traccia della richiesta che ha creato l'esportazione
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+}
+
+// ExportManager gestisce la coda delle esportazioni asincrone, conservata da uno ExportJobStore, e gli
+// archivi generati su disco
+type ExportManager struct {
+	jobs       ExportJobStore
+	exportsDir string
+	retention  time.Duration
+}
+
+// NewExportManager crea una nuova istanza del manager; gli archivi vengono conservati per retention,
+// come lo stato delle esportazioni in jobs
+func NewExportManager(jobs ExportJobStore, dataDir string, retention time.Duration) *ExportManager {
+	exportsDir := filepath.Join(dataDir, "exports")
+	if err := os.MkdirAll(exportsDir, 0755); err != nil {
+		slog.Error("Errore nella creazione della directory", "path", exportsDir, "error", err)
+	}
+
+	return &ExportManager{
+		jobs:       jobs,
+		exportsDir: exportsDir,
+		retention:  retention,
+	}
+}
+
+// CreateJob assegna un identificativo all'esportazione, la salva e la aggiunge alla coda
+func (em *ExportManager) CreateJob(job *ExportJob) error {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("errore nella generazione dell'identificativo: %v", err)
+	}
+	job.ID = hex.EncodeToString(id)
+
+	if err := em.jobs.SaveJob(job); err != nil {
+		return err
+	}
+	return em.jobs.PushJob(job.ID)
+}
+
+// SaveJob salva lo stato di un'esportazione
+func (em *ExportManager) SaveJob(job *ExportJob) error {
+	return em.jobs.SaveJob(job)
+}
+
+// GetJob restituisce un'esportazione, o nil se non esiste o è scaduta
+func (em *ExportManager) GetJob(id string) (*ExportJob, error) {
+	return em.jobs.GetJob(id)
+}
+
+// PopJob recupera la prossima esportazione dalla coda, attendendo al massimo timeout, o nil se la coda resta vuota
+func (em *ExportManager) PopJob(ctx context.Context, timeout time.Duration) (*ExportJob, error) {
+	id, err := em.jobs.PopJob(ctx, timeout)
+	if err != nil || id == "" {
+		return nil, err
+	}
+	return em.jobs.GetJob(id)
+}
+
+// FilePath restituisce il percorso dell'archivio di un'esportazione
+func (em *ExportManager) FilePath(id string) string {
+	return filepath.Join(em.exportsDir, id+".zip")
+}
+
+// CreateFile crea il file in cui scrivere l'archivio; diventa visibile solo con CompleteFile
+func (em *ExportManager) CreateFile(id string) (*os.File, error) {
+	file, err := os.Create(em.FilePath(id) + ".part")
+	if err != nil {
+		return nil, fmt.Errorf("errore nella creazione dell'archivio: %v", err)
+	}
+	return file, nil
+}
+
+// CompleteFile rende disponibile l'archivio scritto, o lo elimina se la scrittura non è riuscita
+func (em *ExportManager) CompleteFile(id string, ok bool) error {
+	if !ok {
+		os.Remove(em.FilePath(id) + ".part")
+		return nil
+	}
+	if err := os.Rename(em.FilePath(id)+".part", em.FilePath(id)); err != nil {
+		return fmt.Errorf("errore nel salvataggio dell'archivio: %v", err)
+	}
+	return nil
+}
+
+// RemoveExpiredFiles elimina gli archivi più vecchi del periodo di conservazione
+func (em *ExportManager) RemoveExpiredFiles() error {
+	files, err := os.ReadDir(em.exportsDir)
+	if err != nil {
+		return fmt.Errorf("errore nella lettura della directory: %v", err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !strings.Contains(file.Name(), ".zip") {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil || time.Since(info.ModTime()) < em.retention {
+			continue
+		}
+		if err := os.Remove(filepath.Join(em.exportsDir, file.Name())); err != nil {
+			return fmt.Errorf("errore nell'eliminazione dell'archivio %s: %v", file.Name(), err)
+		}
+	}
+	return nil
+}
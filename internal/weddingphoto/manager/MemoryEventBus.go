@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// memoryEventListenerBuffer è il numero di eventi in attesa per ogni ascoltatore oltre il quale gli
+// eventi successivi non gli vengono consegnati
+const memoryEventListenerBuffer = 256
+
+// MemoryEventBus distribuisce gli eventi all'interno del processo, per le installazioni con una sola
+// istanza e senza Redis. Gli identificativi partono dall'istante di avvio in millisecondi, così restano
+// crescenti anche dopo un riavvio e i client che riprendono con Last-Event-ID non scartano i nuovi
+// eventi; lo storico non sopravvive al riavvio
+type MemoryEventBus struct {
+	mu        sync.Mutex
+	lastID    int64
+	history   []EventRecord
+	listeners map[chan EventRecord]struct{}
+}
+
+// NewMemoryEventBus crea un nuovo bus di eventi nel processo
+func NewMemoryEventBus() *MemoryEventBus {
+	return &MemoryEventBus{
+		lastID:    time.Now().UnixMilli(),
+		listeners: make(map[chan EventRecord]struct{}),
+	}
+}
+
+// Publish assegna un identificativo all'evento, lo salva nello storico e lo consegna agli ascoltatori
+func (mb *MemoryEventBus) Publish(eventType string, data any) (*EventRecord, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("errore nella codifica dell'evento: %v", err)
+	}
+
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	mb.lastID++
+	record := EventRecord{
+		ID:        mb.lastID,
+		Type:      eventType,
+		Data:      payload,
+		CreatedAt: time.Now().UTC(),
+	}
+	mb.history = append(mb.history, record)
+	if excess := len(mb.history) - EVENTS_HISTORY_SIZE; excess > 0 {
+		mb.history = append(mb.history[:0:0], mb.history[excess:]...)
+	}
+
+	for listener := range mb.listeners {
+		select {
+		case listener <- record:
+		default:
+			slog.Warn("Evento non consegnato, ascoltatore in ritardo", "event_id", record.ID, "event_type", eventType)
+		}
+	}
+	return &record, nil
+}
+
+// GetEventsAfter restituisce gli eventi conservati con identificativo maggiore di quello indicato, in ordine
+func (mb *MemoryEventBus) GetEventsAfter(lastID int64) ([]EventRecord, error) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	records := []EventRecord{}
+	for _, record := range mb.history {
+		if record.ID > lastID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// Listen restituisce gli eventi pubblicati da questo momento, fino alla cancellazione del context
+func (mb *MemoryEventBus) Listen(ctx context.Context) <-chan EventRecord {
+	listener := make(chan EventRecord, memoryEventListenerBuffer)
+	mb.mu.Lock()
+	mb.listeners[listener] = struct{}{}
+	mb.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		mb.mu.Lock()
+		delete(mb.listeners, listener)
+		close(listener)
+		mb.mu.Unlock()
+	}()
+	return listener
+}
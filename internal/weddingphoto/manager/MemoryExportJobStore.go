@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryExportJobStore conserva le esportazioni nel processo, per le installazioni con una sola istanza
+// e senza Redis; le esportazioni non ancora completate al riavvio vanno richieste di nuovo
+type MemoryExportJobStore struct {
+	mu        sync.Mutex
+	jobs      map[string]memoryExportJob
+	pending   []string
+	ready     chan struct{}
+	retention time.Duration
+}
+
+// memoryExportJob è lo stato di un'esportazione con la sua scadenza
+type memoryExportJob struct {
+	job       ExportJob
+	expiresAt time.Time
+}
+
+// NewMemoryExportJobStore crea una nuova istanza dello store; lo stato delle esportazioni scade dopo retention
+func NewMemoryExportJobStore(retention time.Duration) *MemoryExportJobStore {
+	return &MemoryExportJobStore{
+		jobs:      map[string]memoryExportJob{},
+		ready:     make(chan struct{}, 1),
+		retention: retention,
+	}
+}
+
+// SaveJob salva lo stato di un'esportazione ed elimina quelle scadute
+func (ms *MemoryExportJobStore) SaveJob(job *ExportJob) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	now := time.Now()
+	for id, stored := range ms.jobs {
+		if now.After(stored.expiresAt) {
+			delete(ms.jobs, id)
+		}
+	}
+	ms.jobs[job.ID] = memoryExportJob{job: *job, expiresAt: now.Add(ms.retention)}
+	return nil
+}
+
+// GetJob restituisce un'esportazione, o nil se non esiste o è scaduta
+func (ms *MemoryExportJobStore) GetJob(id string) (*ExportJob, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	stored, ok := ms.jobs[id]
+	if !ok || time.Now().After(stored.expiresAt) {
+		return nil, nil
+	}
+	job := stored.job
+	return &job, nil
+}
+
+// PushJob aggiunge un'esportazione alla coda
+func (ms *MemoryExportJobStore) PushJob(id string) error {
+	ms.mu.Lock()
+	ms.pending = append(ms.pending, id)
+	ms.mu.Unlock()
+	select {
+	case ms.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// PopJob recupera la prossima esportazione dalla coda, attendendo al massimo timeout
+func (ms *MemoryExportJobStore) PopJob(ctx context.Context, timeout time.Duration) (string, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		ms.mu.Lock()
+		if len(ms.pending) > 0 {
+			id := ms.pending[0]
+			ms.pending = ms.pending[1:]
+			ms.mu.Unlock()
+			return id, nil
+		}
+		ms.mu.Unlock()
+
+		select {
+		case <-ms.ready:
+		case <-timer.C:
+			return "", nil
+		case <-ctx.Done():
+			return "", nil
+		}
+	}
+}
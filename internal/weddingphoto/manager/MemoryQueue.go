@@ -0,0 +1,384 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// memoryQueueExt è l'estensione dei file dei job in attesa
+	memoryQueueExt = ".job"
+	// memoryQueueScanInterval è ogni quanto i worker rileggono la directory della coda, per trovare
+	// i job aggiunti o eliminati da altri processi, ad esempio dai comandi di manutenzione
+	memoryQueueScanInterval = 5 * time.Second
+)
+
+// MemoryQueue è la coda di elaborazione conservata nel processo, per le installazioni con una sola
+// istanza. Ogni job è salvato in un file della directory della coda, eliminato quando il job viene
+// prelevato: i job in attesa sopravvivono a un riavvio, quelli in elaborazione no (come con Redis).
+// Il nome del file contiene l'istante di inserimento, che ne dà l'ordine, e la corsia del job.
+// Solo il server preleva i job, ma altri processi possono aggiungerli o eliminarli: la directory
+// viene riletta periodicamente
+type MemoryQueue struct {
+	dir       string
+	mutex     sync.Mutex
+	pending   map[string][]string // file dei job per corsia e di QueueFailed, dal più vecchio
+	lastStamp int64               // istante dell'ultimo job aggiunto, per mantenere l'ordine di arrivo
+	lastScan  time.Time
+	ready     chan struct{} // segnala ai worker in attesa che c'è un job
+	scheduler *laneScheduler
+	closed    bool
+}
+
+// NewMemoryQueue crea la coda nella directory indicata, ricaricando i job rimasti dall'ultimo arresto
+func NewMemoryQueue(dir string) (*MemoryQueue, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("errore nella creazione della directory %s: %v", dir, err)
+	}
+	// I file temporanei vecchi appartengono a job mai completati; quelli recenti possono essere
+	// in scrittura da parte di un altro processo
+	if stale, err := filepath.Glob(filepath.Join(dir, incompleteUploadPattern)); err == nil {
+		for _, path := range stale {
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > time.Minute {
+				os.Remove(path)
+			}
+		}
+	}
+
+	mq := &MemoryQueue{
+		dir:       dir,
+		ready:     make(chan struct{}, 1),
+		scheduler: newLaneScheduler(),
+	}
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+	if err := mq.scan(); err != nil {
+		return nil, err
+	}
+	return mq, nil
+}
+
+// scan ricostruisce l'elenco dei job dai file della directory; va chiamata con il mutex acquisito
+func (mq *MemoryQueue) scan() error {
+	paths, err := filepath.Glob(filepath.Join(mq.dir, "*"+memoryQueueExt))
+	if err != nil {
+		return fmt.Errorf("errore nella lettura della coda: %v", err)
+	}
+	sort.Strings(paths)
+
+	mq.pending = map[string][]string{}
+	for _, path := range paths {
+		lane := jobFileLane(filepath.Base(path))
+		mq.pending[lane] = append(mq.pending[lane], path)
+	}
+	mq.lastScan = time.Now()
+	if mq.length() > 0 {
+		mq.signal()
+	}
+	return nil
+}
+
+// jobFileLane restituisce la corsia del file di un job, istante-casuale.corsia.job; i file senza
+// corsia, salvati prima dell'introduzione delle corsie, appartengono alla corsia interactive
+func jobFileLane(name string) string {
+	parts := strings.SplitN(strings.TrimSuffix(name, memoryQueueExt), ".", 2)
+	if len(parts) < 2 || checkListLane(parts[1]) != nil {
+		return LaneInteractive
+	}
+	return parts[1]
+}
+
+// AddImageToQueue salva il job su disco e lo aggiunge alla sua corsia
+func (mq *MemoryQueue) AddImageToQueue(ctx context.Context, job *QueueJob) error {
+	lane, err := jobLane(job)
+	if err != nil {
+		return fmt.Errorf("errore nell'aggiunta dell'immagine alla coda: %v", err)
+	}
+	if err := mq.add(lane, job); err != nil {
+		return fmt.Errorf("errore nell'aggiunta dell'immagine alla coda: %v", err)
+	}
+	return nil
+}
+
+// AddFailedJob conserva su disco un job non riuscito
+func (mq *MemoryQueue) AddFailedJob(ctx context.Context, job *QueueJob) error {
+	if err := mq.add(QueueFailed, job); err != nil {
+		return fmt.Errorf("errore nel salvataggio del job non riuscito: %v", err)
+	}
+	return nil
+}
+
+// add salva il job nel file della corsia indicata
+func (mq *MemoryQueue) add(lane string, job *QueueJob) error {
+	payload, err := EncodeQueueJob(job)
+	if err != nil {
+		return err
+	}
+
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+	if mq.closed {
+		return errors.New("coda chiusa")
+	}
+
+	// L'istante a larghezza fissa mantiene l'ordine di arrivo nell'elenco dei file, la parte casuale
+	// evita collisioni con i job aggiunti nello stesso istante da altri processi
+	stamp := time.Now().UnixNano()
+	if stamp <= mq.lastStamp {
+		stamp = mq.lastStamp + 1
+	}
+	path := filepath.Join(mq.dir, fmt.Sprintf("%020d-%08x.%s%s", stamp, rand.Uint32(), lane, memoryQueueExt))
+	if err := writeFileAtomic(mq.dir, path, []byte(payload)); err != nil {
+		return err
+	}
+	mq.lastStamp = stamp
+	mq.pending[lane] = append(mq.pending[lane], path)
+	if lane != QueueFailed {
+		mq.signal()
+	}
+	return nil
+}
+
+// GetNextImageFromQueue preleva il job più vecchio della corsia scelta dallo scheduler, attendendo al
+// massimo timeout se la coda è vuota
+func (mq *MemoryQueue) GetNextImageFromQueue(ctx context.Context, timeout time.Duration) (*QueueJob, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(memoryQueueScanInterval)
+	defer ticker.Stop()
+
+	for {
+		data, ok, err := mq.pop()
+		if err != nil {
+			return nil, fmt.Errorf("errore nel recupero dell'immagine dalla coda: %v", err)
+		}
+		if ok {
+			return DecodeQueueJob(string(data))
+		}
+
+		select {
+		case <-mq.ready:
+		case <-ticker.C:
+			mq.mutex.Lock()
+			err := mq.scan()
+			mq.mutex.Unlock()
+			if err != nil {
+				return nil, err
+			}
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, nil
+		}
+	}
+}
+
+// pop legge ed elimina il file del job più vecchio della prima corsia non vuota, nell'ordine deciso
+// dallo scheduler, se c'è; i file eliminati nel frattempo da altri processi vengono saltati
+func (mq *MemoryQueue) pop() ([]byte, bool, error) {
+	order := mq.scheduler.order()
+
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+	if time.Since(mq.lastScan) > memoryQueueScanInterval {
+		if err := mq.scan(); err != nil {
+			return nil, false, err
+		}
+	}
+	for _, lane := range order {
+		for len(mq.pending[lane]) > 0 {
+			path := mq.pending[lane][0]
+			mq.pending[lane] = mq.pending[lane][1:]
+			data, err := os.ReadFile(path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, false, err
+			}
+			os.Remove(path)
+			mq.scheduler.served(order, lane)
+			// Altri worker possono prelevare i job rimasti
+			if mq.length() > 0 {
+				mq.signal()
+			}
+			return data, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// length restituisce il numero di job in attesa in tutte le corsie; va chiamata con il mutex acquisito
+func (mq *MemoryQueue) length() int {
+	total := 0
+	for _, lane := range QueueLanes {
+		total += len(mq.pending[lane])
+	}
+	return total
+}
+
+// signal sveglia un worker in attesa senza bloccare se nessuno sta aspettando
+func (mq *MemoryQueue) signal() {
+	select {
+	case mq.ready <- struct{}{}:
+	default:
+	}
+}
+
+// GetQueueLength restituisce il numero di job in attesa in tutte le corsie
+func (mq *MemoryQueue) GetQueueLength() (int64, error) {
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+	return int64(mq.length()), nil
+}
+
+// GetLaneLengths restituisce il numero di job in attesa in ogni corsia
+func (mq *MemoryQueue) GetLaneLengths() (map[string]int64, error) {
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+	lengths := make(map[string]int64, len(QueueLanes))
+	for _, lane := range QueueLanes {
+		lengths[lane] = int64(len(mq.pending[lane]))
+	}
+	return lengths, nil
+}
+
+// ListJobs restituisce, senza prelevarli, al massimo limit job della corsia o di QueueFailed dal più
+// vecchio; i file non validi compaiono come job con il solo errore
+func (mq *MemoryQueue) ListJobs(lane string, limit int) ([]*QueueJob, int64, error) {
+	if err := checkListLane(lane); err != nil {
+		return nil, 0, err
+	}
+
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+	if err := mq.scan(); err != nil {
+		return nil, 0, err
+	}
+	paths := mq.pending[lane]
+	jobs := []*QueueJob{}
+	for _, path := range paths {
+		if len(jobs) >= limit {
+			break
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		job, err := DecodeQueueJob(string(data))
+		if err != nil {
+			job = &QueueJob{Error: err.Error()}
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, int64(len(paths)), nil
+}
+
+// RequeueFailed rimette i job non riusciti nelle loro corsie, dal più vecchio; i file non validi
+// restano tra i job non riusciti
+func (mq *MemoryQueue) RequeueFailed(ctx context.Context) (requeued, skipped int, err error) {
+	mq.mutex.Lock()
+	err = mq.scan()
+	paths := mq.pending[QueueFailed]
+	mq.mutex.Unlock()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return requeued, skipped, fmt.Errorf("errore nella lettura dei job non riusciti: %v", err)
+		}
+		job, err := DecodeQueueJob(string(data))
+		if err != nil {
+			slog.Warn("Job non valido lasciato tra i job non riusciti", "path", path, "error", err)
+			skipped++
+			continue
+		}
+		if err := mq.AddImageToQueue(ctx, retryJob(job)); err != nil {
+			return requeued, skipped, err
+		}
+		requeued++
+		os.Remove(path)
+	}
+
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+	return requeued, skipped, mq.scan()
+}
+
+// Purge elimina i file dei job della corsia o di QueueFailed
+func (mq *MemoryQueue) Purge(lane string) (int64, error) {
+	if err := checkListLane(lane); err != nil {
+		return 0, err
+	}
+
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+	if err := mq.scan(); err != nil {
+		return 0, err
+	}
+	var removed int64
+	for _, path := range mq.pending[lane] {
+		if err := os.Remove(path); err == nil {
+			removed++
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("errore nello svuotamento della coda: %v", err)
+		}
+	}
+	delete(mq.pending, lane)
+	return removed, nil
+}
+
+// Ping verifica che nella directory della coda si possano salvare i job
+func (mq *MemoryQueue) Ping(ctx context.Context) error {
+	file, err := os.CreateTemp(mq.dir, incompleteUploadPattern)
+	if err != nil {
+		return fmt.Errorf("directory della coda non scrivibile: %v", err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// Close rifiuta i nuovi job; quelli in attesa restano su disco per il prossimo avvio
+func (mq *MemoryQueue) Close() error {
+	mq.mutex.Lock()
+	defer mq.mutex.Unlock()
+	mq.closed = true
+	return nil
+}
+
+// writeFileAtomic scrive un file passando da un file temporaneo nella stessa directory
+func writeFileAtomic(dir, path string, data []byte) error {
+	file, err := os.CreateTemp(dir, incompleteUploadPattern)
+	if err != nil {
+		return err
+	}
+	tempPath := file.Name()
+	defer os.Remove(tempPath)
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
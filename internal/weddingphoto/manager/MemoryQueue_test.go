@@ -0,0 +1,174 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewMemoryQueueRecovery(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string        // file lasciati nella directory dall'ultimo arresto
+		ages       map[string]time.Duration // età dei file, zero per quelli appena scritti
+		wantLanes  map[string]int64
+		wantFailed int64
+		wantOrder  []string // photo_id nell'ordine di prelievo
+		wantKept   []string // file temporanei che devono restare
+	}{
+		{
+			name: "job nelle corsie in ordine di arrivo",
+			files: map[string]string{
+				"00000000000000000002-00000000.bulk.job":        `{"version":2,"photo_id":"b2.jpg","lane":"bulk"}`,
+				"00000000000000000001-00000000.bulk.job":        `{"version":2,"photo_id":"b1.jpg","lane":"bulk"}`,
+				"00000000000000000003-00000000.interactive.job": `{"version":2,"photo_id":"i1.jpg"}`,
+				"00000000000000000004-00000000.background.job":  `{"version":2,"photo_id":"g1.jpg","lane":"background"}`,
+			},
+			wantLanes: map[string]int64{LaneInteractive: 1, LaneBulk: 2, LaneBackground: 1},
+			wantOrder: []string{"i1.jpg", "b1.jpg", "b2.jpg", "g1.jpg"},
+		},
+		{
+			name: "file senza corsia e nel formato originale",
+			files: map[string]string{
+				"00000000000000000001-00000000.job":      "legacy.jpg",
+				"00000000000000000002-00000000.job":      `{"version":2,"photo_id":"v2.jpg"}`,
+				"00000000000000000003-00000000.bulk.job": `{"version":2,"photo_id":"b1.jpg","lane":"bulk"}`,
+			},
+			wantLanes: map[string]int64{LaneInteractive: 2, LaneBulk: 1, LaneBackground: 0},
+			wantOrder: []string{"legacy.jpg", "v2.jpg", "b1.jpg"},
+		},
+		{
+			name: "job non riusciti non vengono prelevati",
+			files: map[string]string{
+				"00000000000000000001-00000000.failed.job":      `{"version":2,"photo_id":"f1.jpg","attempt":3,"error":"boom"}`,
+				"00000000000000000002-00000000.interactive.job": `{"version":2,"photo_id":"i1.jpg"}`,
+			},
+			wantLanes:  map[string]int64{LaneInteractive: 1, LaneBulk: 0, LaneBackground: 0},
+			wantFailed: 1,
+			wantOrder:  []string{"i1.jpg"},
+		},
+		{
+			name: "file temporanei vecchi eliminati, recenti conservati",
+			files: map[string]string{
+				".upload-old":                       "parziale",
+				".upload-new":                       "in scrittura",
+				"00000000000000000001-00000000.job": "a.jpg",
+				"notes.txt":                         "ignorato",
+			},
+			ages:      map[string]time.Duration{".upload-old": 2 * time.Minute},
+			wantLanes: map[string]int64{LaneInteractive: 1, LaneBulk: 0, LaneBackground: 0},
+			wantOrder: []string{"a.jpg"},
+			wantKept:  []string{".upload-new", "notes.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if age := tt.ages[name]; age > 0 {
+					modTime := time.Now().Add(-age)
+					if err := os.Chtimes(path, modTime, modTime); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			mq, err := NewMemoryQueue(dir)
+			if err != nil {
+				t.Fatalf("NewMemoryQueue: %v", err)
+			}
+			defer mq.Close()
+
+			lanes, err := mq.GetLaneLengths()
+			if err != nil {
+				t.Fatalf("GetLaneLengths: %v", err)
+			}
+			if !reflect.DeepEqual(lanes, tt.wantLanes) {
+				t.Errorf("GetLaneLengths() = %v, atteso %v", lanes, tt.wantLanes)
+			}
+			if _, failed, err := mq.ListJobs(QueueFailed, 0); err != nil || failed != tt.wantFailed {
+				t.Errorf("ListJobs(QueueFailed) = %d, %v, atteso %d", failed, err, tt.wantFailed)
+			}
+
+			var order []string
+			for {
+				job, err := mq.GetNextImageFromQueue(context.Background(), 10*time.Millisecond)
+				if err != nil {
+					t.Fatalf("GetNextImageFromQueue: %v", err)
+				}
+				if job == nil {
+					break
+				}
+				order = append(order, job.PhotoID)
+			}
+			if !reflect.DeepEqual(order, tt.wantOrder) {
+				t.Errorf("ordine di prelievo %v, atteso %v", order, tt.wantOrder)
+			}
+
+			for _, name := range []string{".upload-old", ".upload-new", "notes.txt"} {
+				_, err := os.Stat(filepath.Join(dir, name))
+				kept := err == nil
+				wantKept := false
+				for _, want := range tt.wantKept {
+					wantKept = wantKept || want == name
+				}
+				if _, written := tt.files[name]; written && kept != wantKept {
+					t.Errorf("file %s conservato = %v, atteso %v", name, kept, wantKept)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryQueueSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	mq, err := NewMemoryQueue(dir)
+	if err != nil {
+		t.Fatalf("NewMemoryQueue: %v", err)
+	}
+	jobs := []*QueueJob{
+		NewRenditionJob(ctx, "a.jpg", LaneInteractive, RenditionOperations),
+		NewRenditionJob(ctx, "b.jpg", LaneBackground, []string{OperationPreview}),
+		NewRenditionJob(ctx, "c.jpg", LaneInteractive, RenditionOperations),
+	}
+	for _, job := range jobs {
+		if err := mq.AddImageToQueue(ctx, job); err != nil {
+			t.Fatalf("AddImageToQueue: %v", err)
+		}
+	}
+	// Il primo job è in elaborazione al momento dell'arresto e va perso, come con Redis
+	if job, err := mq.GetNextImageFromQueue(ctx, time.Second); err != nil || job == nil || job.PhotoID != "a.jpg" {
+		t.Fatalf("GetNextImageFromQueue() = %v, %v, atteso a.jpg", job, err)
+	}
+	if err := mq.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	restarted, err := NewMemoryQueue(dir)
+	if err != nil {
+		t.Fatalf("NewMemoryQueue dopo il riavvio: %v", err)
+	}
+	defer restarted.Close()
+	if length, err := restarted.GetQueueLength(); err != nil || length != 2 {
+		t.Fatalf("GetQueueLength() = %d, %v, attesi 2 job", length, err)
+	}
+	for _, want := range []*QueueJob{jobs[2], jobs[1]} {
+		got, err := restarted.GetNextImageFromQueue(ctx, time.Second)
+		if err != nil {
+			t.Fatalf("GetNextImageFromQueue: %v", err)
+		}
+		if got == nil || got.PhotoID != want.PhotoID || got.Lane != want.Lane || !reflect.DeepEqual(got.Operations, want.Operations) {
+			t.Errorf("GetNextImageFromQueue() = %+v, atteso %+v", got, want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// slideshowCuration sono le scelte degli amministratori salvate su disco dalla MemorySlideshowStore
+type slideshowCuration struct {
+	Pinned []string `json:"pinned"`
+	Hidden []string `json:"hidden"`
+}
+
+// MemorySlideshowStore conserva lo stato dello slideshow nel processo, per le installazioni con una
+// sola istanza e senza Redis. Le foto fissate e nascoste sono salvate in un file, così le scelte degli
+// amministratori sopravvivono a un riavvio; slide corrente, coda e foto già viste ripartono da zero
+type MemorySlideshowStore struct {
+	mu          sync.Mutex
+	path        string
+	state       SlideshowState
+	lockedUntil time.Time
+	recent      []string
+	seen        map[string]bool
+	queue       []string
+	pinned      []string
+	hidden      map[string]bool
+}
+
+// NewMemorySlideshowStore crea lo store e legge le foto fissate e nascoste salvate in dataDir
+func NewMemorySlideshowStore(dataDir string) (*MemorySlideshowStore, error) {
+	ss := &MemorySlideshowStore{
+		path:   filepath.Join(dataDir, "slideshow.json"),
+		seen:   map[string]bool{},
+		hidden: map[string]bool{},
+	}
+
+	data, err := os.ReadFile(ss.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ss, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("errore nella lettura dello stato dello slideshow: %v", err)
+	}
+	var curation slideshowCuration
+	if err := json.Unmarshal(data, &curation); err != nil {
+		return nil, fmt.Errorf("stato dello slideshow %s non valido: %v", ss.path, err)
+	}
+	ss.pinned = curation.Pinned
+	for _, imageName := range curation.Hidden {
+		ss.hidden[imageName] = true
+	}
+	return ss, nil
+}
+
+// GetState restituisce lo stato dello slideshow
+func (ss *MemorySlideshowStore) GetState() (*SlideshowState, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	state := ss.state
+	return &state, nil
+}
+
+// SaveState salva lo stato dello slideshow
+func (ss *MemorySlideshowStore) SaveState(state *SlideshowState) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.state = *state
+	return nil
+}
+
+// Lock acquisisce il lock per modificare lo stato, restituisce false se è già acquisito da un'altra richiesta
+func (ss *MemorySlideshowStore) Lock(ttl time.Duration) (bool, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	now := time.Now()
+	if now.Before(ss.lockedUntil) {
+		return false, nil
+	}
+	ss.lockedUntil = now.Add(ttl)
+	return true, nil
+}
+
+// Unlock rilascia il lock dello stato
+func (ss *MemorySlideshowStore) Unlock() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.lockedUntil = time.Time{}
+}
+
+// AddShown registra una foto come mostrata: la aggiunge alle slide recenti (limitate a window) e alle foto già viste
+func (ss *MemorySlideshowStore) AddShown(imageName string, window int) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.recent = append([]string{imageName}, ss.recent[:min(len(ss.recent), max(window, 1)-1)]...)
+	ss.seen[imageName] = true
+	return nil
+}
+
+// GetRecent restituisce le ultime foto mostrate, dalla più recente
+func (ss *MemorySlideshowStore) GetRecent(limit int) ([]string, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return slices.Clone(ss.recent[:min(len(ss.recent), max(limit, 1))]), nil
+}
+
+// GetSeen restituisce l'insieme delle foto mostrate almeno una volta
+func (ss *MemorySlideshowStore) GetSeen() (map[string]bool, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return maps.Clone(ss.seen), nil
+}
+
+// GetQueue restituisce le foto in coda nello slideshow, nell'ordine in cui verranno mostrate
+func (ss *MemorySlideshowStore) GetQueue() ([]string, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return slices.Clone(ss.queue), nil
+}
+
+// PopQueue estrae la prossima foto dalla coda, restituisce una stringa vuota se la coda è vuota
+func (ss *MemorySlideshowStore) PopQueue() (string, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if len(ss.queue) == 0 {
+		return "", nil
+	}
+	imageName := ss.queue[0]
+	ss.queue = ss.queue[1:]
+	return imageName, nil
+}
+
+// FillQueue sostituisce la coda con le foto indicate in ordine casuale
+func (ss *MemorySlideshowStore) FillQueue(imageNames []string) error {
+	shuffled := slices.Clone(imageNames)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.queue = shuffled
+	return nil
+}
+
+// GetPinned restituisce le foto fissate, nell'ordine in cui sono state fissate
+func (ss *MemorySlideshowStore) GetPinned() ([]string, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return slices.Clone(ss.pinned), nil
+}
+
+// SetPinned fissa o sblocca una foto
+func (ss *MemorySlideshowStore) SetPinned(imageName string, pinned bool) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	index := slices.Index(ss.pinned, imageName)
+	switch {
+	case pinned && index < 0:
+		ss.pinned = append(ss.pinned, imageName)
+	case !pinned && index >= 0:
+		ss.pinned = slices.Delete(ss.pinned, index, index+1)
+	default:
+		return nil
+	}
+	if err := ss.saveCuration(); err != nil {
+		return fmt.Errorf("errore nell'aggiornamento delle foto fissate: %v", err)
+	}
+	return nil
+}
+
+// GetHidden restituisce l'insieme delle foto escluse dallo slideshow
+func (ss *MemorySlideshowStore) GetHidden() (map[string]bool, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return maps.Clone(ss.hidden), nil
+}
+
+// SetHidden esclude una foto dallo slideshow o la reinserisce
+func (ss *MemorySlideshowStore) SetHidden(imageName string, hidden bool) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.hidden[imageName] == hidden {
+		return nil
+	}
+	if hidden {
+		ss.hidden[imageName] = true
+	} else {
+		delete(ss.hidden, imageName)
+	}
+	if err := ss.saveCuration(); err != nil {
+		return fmt.Errorf("errore nell'aggiornamento delle foto nascoste: %v", err)
+	}
+	return nil
+}
+
+// saveCuration scrive le foto fissate e nascoste su un file temporaneo rinominato al termine; richiede mu
+func (ss *MemorySlideshowStore) saveCuration() error {
+	curation := slideshowCuration{
+		Pinned: append([]string{}, ss.pinned...),
+		Hidden: append([]string{}, slices.Sorted(maps.Keys(ss.hidden))...),
+	}
+	data, err := json.Marshal(curation)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(ss.path), ".slideshow-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), ss.path)
+}
@@ -0,0 +1,178 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// migrations contiene gli step di creazione dello schema, applicati in ordine.
+// Il numero di step già applicati è salvato in PRAGMA user_version: aggiungere
+// sempre nuovi step in fondo, senza modificare quelli esistenti.
+var migrations = []string{
+	// 1: album e appartenenza delle foto agli album
+	`CREATE TABLE albums (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		cover_photo TEXT NOT NULL DEFAULT '',
+		guest_uploads INTEGER NOT NULL DEFAULT 1,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+	CREATE TABLE album_photos (
+		album_id INTEGER NOT NULL REFERENCES albums(id) ON DELETE CASCADE,
+		image_name TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		added_at INTEGER NOT NULL,
+		PRIMARY KEY (album_id, image_name)
+	);
+	CREATE INDEX idx_album_photos_image ON album_photos(image_name);`,
+	// 2: copia persistente delle reazioni mantenute in Redis
+	`CREATE TABLE photo_reactions (
+		image_name TEXT NOT NULL,
+		type TEXT NOT NULL,
+		guest_token TEXT NOT NULL,
+		PRIMARY KEY (image_name, type, guest_token)
+	);`,
+	// 3: dati inseriti dagli ospiti al caricamento e commenti
+	`CREATE TABLE photos (
+		image_name TEXT PRIMARY KEY,
+		original_name TEXT NOT NULL DEFAULT '',
+		caption TEXT NOT NULL DEFAULT '',
+		uploader_token TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL
+	);
+	CREATE TABLE comments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		image_name TEXT NOT NULL,
+		parent_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+		guest_token TEXT NOT NULL,
+		author_name TEXT NOT NULL DEFAULT '',
+		body TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX idx_comments_image ON comments(image_name, created_at);`,
+	// 4: tag liberi e persone riconosciute nelle foto
+	`CREATE TABLE photo_tags (
+		image_name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		name TEXT NOT NULL,
+		normalized TEXT NOT NULL,
+		added_by TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL,
+		PRIMARY KEY (image_name, kind, normalized)
+	);
+	CREATE INDEX idx_photo_tags_label ON photo_tags(kind, normalized);`,
+	// 5: autore, dati EXIF e indice di ricerca full-text
+	`ALTER TABLE photos ADD COLUMN uploader_name TEXT NOT NULL DEFAULT '';
+	ALTER TABLE photos ADD COLUMN camera TEXT NOT NULL DEFAULT '';
+	ALTER TABLE photos ADD COLUMN taken_at INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE photos ADD COLUMN media_type TEXT NOT NULL DEFAULT 'image';
+	CREATE VIRTUAL TABLE photo_search USING fts5(
+		image_name UNINDEXED, caption, tags, uploader,
+		tokenize = 'unicode61 remove_diacritics 2'
+	);
+	INSERT INTO photo_search (image_name, caption, tags, uploader)
+		SELECT p.image_name, p.caption,
+			COALESCE((SELECT group_concat(t.name, ' ') FROM photo_tags t WHERE t.image_name = p.image_name), ''),
+			p.uploader_name
+		FROM photos p;`,
+	// 6: moderazione e stato di elaborazione delle foto (le foto esistenti sono già approvate ed elaborate)
+	`ALTER TABLE photos ADD COLUMN approved INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE photos ADD COLUMN processed_at INTEGER NOT NULL DEFAULT 0;
+	UPDATE photos SET processed_at = created_at;
+	CREATE INDEX idx_photos_processed ON photos(processed_at);`,
+	// 7: identificativo della richiesta di upload, per collegare i log dell'elaborazione all'upload
+	`ALTER TABLE photos ADD COLUMN request_id TEXT NOT NULL DEFAULT '';`,
+}
+
+// MetadataManager gestisce il database SQLite con i metadati delle foto
+type MetadataManager struct {
+	db *sql.DB
+}
+
+// NewMetadataManager apre (o crea) il database dei metadati e applica le migrazioni
+func NewMetadataManager(dataDir string) (*MetadataManager, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("errore nella creazione della directory %s: %v", dataDir, err)
+	}
+
+	dsn := "file:" + filepath.Join(dataDir, "metadata.db") +
+		"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("errore nell'apertura del database dei metadati: %v", err)
+	}
+
+	// SQLite gestisce un solo writer alla volta
+	db.SetMaxOpenConns(1)
+
+	mm := &MetadataManager{db: db}
+	if err := mm.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return mm, nil
+}
+
+// DB restituisce la connessione al database, condivisa dagli altri manager
+func (mm *MetadataManager) DB() *sql.DB {
+	return mm.db
+}
+
+// migrate applica gli step di migrazione non ancora eseguiti
+func (mm *MetadataManager) migrate() error {
+	var version int
+	if err := mm.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("errore nella lettura della versione dello schema: %v", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := mm.db.Begin()
+		if err != nil {
+			return fmt.Errorf("errore nell'avvio della migrazione %d: %v", i+1, err)
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("errore nella migrazione %d: %v", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("errore nell'aggiornamento della versione dello schema: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("errore nel commit della migrazione %d: %v", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// inClause restituisce i segnaposto per una condizione IN e i relativi argomenti
+func inClause(values []string) (string, []any) {
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return "(" + strings.TrimSuffix(strings.Repeat("?,", len(values)), ",") + ")", args
+}
+
+// Ping verifica che il database dei metadati sia aperto e risponda
+func (mm *MetadataManager) Ping(ctx context.Context) error {
+	if err := mm.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("database dei metadati non raggiungibile: %v", err)
+	}
+	return nil
+}
+
+// Close chiude il database dei metadati
+func (mm *MetadataManager) Close() error {
+	return mm.db.Close()
+}
@@ -3,7 +3,9 @@ package manager
 import (
 	"bytes"
 	"fmt"
+	"image"
 	"io"
+	"log/slog"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -11,6 +13,9 @@ import (
 	"time"
 
 	"github.com/disintegration/imaging"
+	"github.com/rwcarlsen/goexif/exif"
+	// Decoder WebP per image.Decode, usato da imaging.Open
+	_ "golang.org/x/image/webp"
 )
 
 // PhotoManager gestisce le operazioni sulfilesystem per le foto
@@ -27,13 +32,13 @@ func NewPhotoManager(photosDir string) *PhotoManager {
 
 	// Crea le directory se non esistono
 	if err := os.MkdirAll(photosDir, 0755); err != nil {
-		fmt.Printf("Errore nella creazione della directory %s: %v\n", photosDir, err)
+		slog.Error("Errore nella creazione della directory", "path", photosDir, "error", err)
 	}
 	if err := os.MkdirAll(thumbnailsDir, 0755); err != nil {
-		fmt.Printf("Errore nella creazione della directory thumbnails %s: %v\n", thumbnailsDir, err)
+		slog.Error("Errore nella creazione della directory", "path", thumbnailsDir, "error", err)
 	}
 	if err := os.MkdirAll(previewsDir, 0755); err != nil {
-		fmt.Printf("Errore nella creazione della directory previews %s: %v\n", previewsDir, err)
+		slog.Error("Errore nella creazione della directory", "path", previewsDir, "error", err)
 	}
 
 	return &PhotoManager{
@@ -43,6 +48,173 @@ func NewPhotoManager(photosDir string) *PhotoManager {
 	}
 }
 
+// incompleteUploadPattern è il nome dei file temporanei degli upload e delle versioni ridotte in corso
+const incompleteUploadPattern = ".upload-*"
+
+// incompleteUploadMaxAge è il tempo dall'ultima scrittura dopo cui un file temporaneo è considerato
+// abbandonato; quelli più recenti possono appartenere a upload in corso su altre istanze o worker
+const incompleteUploadMaxAge = 10 * time.Minute
+
+// RemoveIncompleteUploads elimina i file temporanei lasciati da upload ed elaborazioni interrotti,
+// ad esempio da un arresto forzato, non modificati da almeno incompleteUploadMaxAge, e restituisce
+// quanti ne ha eliminati
+func (pm *PhotoManager) RemoveIncompleteUploads() (int, error) {
+	var paths []string
+	for _, dir := range []string{pm.photosDir, pm.thumbnailsDir, pm.previewsDir} {
+		matches, err := filepath.Glob(filepath.Join(dir, incompleteUploadPattern))
+		if err != nil {
+			return 0, fmt.Errorf("errore nella ricerca degli upload incompleti: %v", err)
+		}
+		paths = append(paths, matches...)
+	}
+
+	removed := 0
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || time.Since(info.ModTime()) < incompleteUploadMaxAge {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("errore nell'eliminazione di %s: %v", path, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// CheckWritable verifica che nella directory delle foto si possano creare file
+func (pm *PhotoManager) CheckWritable() error {
+	file, err := os.CreateTemp(pm.photosDir, ".healthcheck-*")
+	if err != nil {
+		return fmt.Errorf("directory delle foto non scrivibile: %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("ok")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("directory delle foto non scrivibile: %v", err)
+	}
+	return nil
+}
+
+// RenditionName restituisce il nome del file di thumbnail e preview di una foto: le versioni ridotte
+// delle foto WebP sono salvate in JPEG, perché il formato non si può codificare, e hanno l'estensione
+// .jpg aggiunta al nome dell'originale per essere servite con il tipo corretto
+func RenditionName(filename string) string {
+	if strings.EqualFold(filepath.Ext(filename), ".webp") {
+		return filename + ".jpg"
+	}
+	return filename
+}
+
+// renditionPath restituisce il percorso della versione ridotta di una foto nella directory indicata.
+// Le versioni ridotte delle foto WebP generate prima di RenditionName, anche da image-parse.sh, hanno
+// il nome dell'originale: se esiste solo quella viene restituito il suo percorso
+func (pm *PhotoManager) renditionPath(dir, filename string) string {
+	name := RenditionName(filename)
+	path := filepath.Join(dir, name)
+	if name != filename {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			legacyPath := filepath.Join(dir, filename)
+			if _, err := os.Stat(legacyPath); err == nil {
+				return legacyPath
+			}
+		}
+	}
+	return path
+}
+
+// MigrateLegacyRenditions converte in JPEG, con il nome di RenditionName, le versioni ridotte delle foto
+// WebP salvate con il nome dell'originale, che non sarebbero raggiungibili dagli URL delle foto, e
+// restituisce quante ne ha convertite. Quelle non leggibili restano al loro posto e vengono segnalate
+// dalla verifica delle foto
+func (pm *PhotoManager) MigrateLegacyRenditions() (int, error) {
+	migrated := 0
+	for _, dir := range []string{pm.thumbnailsDir, pm.previewsDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return migrated, fmt.Errorf("errore nella lettura della directory %s: %v", dir, err)
+		}
+		for _, entry := range entries {
+			filename := entry.Name()
+			if entry.IsDir() || RenditionName(filename) == filename {
+				continue
+			}
+			legacyPath := filepath.Join(dir, filename)
+			if _, err := os.Stat(filepath.Join(dir, RenditionName(filename))); err != nil {
+				img, err := openImage(legacyPath)
+				if err != nil {
+					slog.Warn("Versione ridotta non leggibile, non convertita", "path", legacyPath, "error", err)
+					continue
+				}
+				if err := pm.saveRendition(img, dir, filename); err != nil {
+					return migrated, fmt.Errorf("errore nella conversione di %s: %v", legacyPath, err)
+				}
+			}
+			if err := os.Remove(legacyPath); err != nil && !os.IsNotExist(err) {
+				return migrated, fmt.Errorf("errore nell'eliminazione di %s: %v", legacyPath, err)
+			}
+			migrated++
+		}
+	}
+	return migrated, nil
+}
+
+// GetRenditionModTimes restituisce la data di creazione di thumbnail e preview, zero se non esistono
+func (pm *PhotoManager) GetRenditionModTimes(filename string) (thumbnail, preview time.Time) {
+	if info, err := os.Stat(pm.renditionPath(pm.thumbnailsDir, filename)); err == nil {
+		thumbnail = info.ModTime()
+	}
+	if info, err := os.Stat(pm.renditionPath(pm.previewsDir, filename)); err == nil {
+		preview = info.ModTime()
+	}
+	return thumbnail, preview
+}
+
+// DiskUsage restituisce lo spazio del filesystem che ospita le foto
+func (pm *PhotoManager) DiskUsage() (DiskUsage, error) {
+	return GetDiskUsage(pm.photosDir)
+}
+
+// MediaSizes restituisce lo spazio occupato da originali, thumbnail e preview, in byte
+func (pm *PhotoManager) MediaSizes() (originals, thumbnails, previews int64, err error) {
+	if originals, err = directorySize(pm.photosDir); err != nil {
+		return 0, 0, 0, err
+	}
+	if thumbnails, err = directorySize(pm.thumbnailsDir); err != nil {
+		return 0, 0, 0, err
+	}
+	if previews, err = directorySize(pm.previewsDir); err != nil {
+		return 0, 0, 0, err
+	}
+	return originals, thumbnails, previews, nil
+}
+
+// directorySize somma la dimensione dei file contenuti direttamente in dir
+func directorySize(dir string) (int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("errore nella lettura della directory: %v", err)
+	}
+
+	var size int64
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// Il file può essere stato eliminato nel frattempo
+			continue
+		}
+		size += info.Size()
+	}
+	return size, nil
+}
+
 func (pm *PhotoManager) GetPhotoList() ([]string, error) {
 	var images []string
 
@@ -79,82 +251,126 @@ func (pm *PhotoManager) SavePhotoFromBytes(reader io.Reader, originalFilename st
 		randomNum, ext)
 	filePath := filepath.Join(pm.photosDir, filename)
 
-	// Crea il file di destinazione
-	dst, err := os.Create(filePath)
+	// Scrive su un file temporaneo nascosto e lo rinomina solo a scrittura completata, così un upload
+	// interrotto (ad esempio da un riavvio) non lascia una foto troncata nella galleria
+	dst, err := os.CreateTemp(pm.photosDir, incompleteUploadPattern)
 	if err != nil {
 		return "", fmt.Errorf("errore nella creazione del file: %v", err)
 	}
-	defer dst.Close()
+	tempPath := dst.Name()
+	defer os.Remove(tempPath)
 
 	// Copia il contenuto dal reader al file
 	_, err = io.Copy(dst, reader)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
 		return "", fmt.Errorf("errore nella scrittura del file: %v", err)
 	}
-
-	// Crea il thumbnail
-	if err := pm.createThumbnail(filePath, filename, contentType); err != nil {
-		fmt.Printf("Errore nella creazione del thumbnail per %s: %v\n", filename, err)
-		// Non restituiamo errore per il thumbnail, continuiamo
+	if err := os.Chmod(tempPath, 0644); err != nil {
+		return "", fmt.Errorf("errore nella scrittura del file: %v", err)
 	}
-
-	// Crea la preview
-	if err := pm.createPreview(filePath, filename, contentType); err != nil {
-		fmt.Printf("Errore nella creazione della preview per %s: %v\n", filename, err)
-		// Non restituiamo errore per la preview, continuiamo
+	if err := os.Rename(tempPath, filePath); err != nil {
+		return "", fmt.Errorf("errore nel salvataggio del file: %v", err)
 	}
 
+	// Thumbnail e preview vengono generate dai worker della coda di elaborazione
 	return filename, nil
 }
 
-// createThumbnail crea un thumbnail di 400x400px usando la libreria imaging
-func (pm *PhotoManager) createThumbnail(originalPath, filename, contentType string) error {
-	return nil
-	thumbnailPath := filepath.Join(pm.thumbnailsDir, filename)
-
-	// Apre l'immagine originale
-	src, err := imaging.Open(originalPath, imaging.AutoOrientation(true))
+// OpenOriginal decodifica l'immagine originale di una foto, da cui generare le versioni ridotte
+func (pm *PhotoManager) OpenOriginal(filename string) (image.Image, error) {
+	if filename == "" || filepath.Base(filename) != filename {
+		return nil, fmt.Errorf("nome file non valido: %s", filename)
+	}
+	src, err := openImage(filepath.Join(pm.photosDir, filename))
 	if err != nil {
-		return fmt.Errorf("errore nell'apertura dell'immagine: %v", err)
+		return nil, fmt.Errorf("errore nell'apertura dell'immagine: %v", err)
+	}
+	return src, nil
+}
+
+// GenerateRendition genera la versione ridotta indicata (OperationThumbnail, OperationPreview) di una foto
+// dall'originale restituito da OpenOriginal
+func (pm *PhotoManager) GenerateRendition(src image.Image, filename, operation string) error {
+	switch operation {
+	case OperationThumbnail:
+		return pm.createThumbnail(src, filename)
+	case OperationPreview:
+		return pm.createPreview(src, filename)
+	default:
+		return fmt.Errorf("operazione %q non supportata", operation)
 	}
+}
+
+// openImage decodifica un'immagine in uno dei formati accettati negli upload, WebP compreso, ruotandola
+// secondo l'orientamento EXIF; è usata sia per generare le versioni ridotte sia per verificarle
+func openImage(path string) (image.Image, error) {
+	return imaging.Open(path, imaging.AutoOrientation(true))
+}
 
+// createThumbnail crea un thumbnail di 400x400px usando la libreria imaging
+func (pm *PhotoManager) createThumbnail(src image.Image, filename string) error {
 	// Crea il thumbnail 400x400 con crop al centro
 	thumbnail := imaging.Fill(src, 400, 400, imaging.Center, imaging.Lanczos)
 
 	// Salva il thumbnail con qualità JPEG 85
-	err = imaging.Save(thumbnail, thumbnailPath, imaging.JPEGQuality(85))
-	if err != nil {
+	if err := pm.saveRendition(thumbnail, pm.thumbnailsDir, filename); err != nil {
 		return fmt.Errorf("errore nel salvataggio del thumbnail: %v", err)
 	}
-
 	return nil
 }
 
 // createPreview crea una preview con dimensioni massime 1024x1024 mantenendo le proporzioni
-func (pm *PhotoManager) createPreview(originalPath, filename, contentType string) error {
+func (pm *PhotoManager) createPreview(src image.Image, filename string) error {
+	// Ridimensiona mantenendo le proporzioni con dimensioni massime 1024x1024
+	preview := imaging.Fit(src, 1024, 1024, imaging.Lanczos)
+
+	// Salva la preview con qualità JPEG 85
+	if err := pm.saveRendition(preview, pm.previewsDir, filename); err != nil {
+		return fmt.Errorf("errore nel salvataggio della preview: %v", err)
+	}
 	return nil
-	previewPath := filepath.Join(pm.previewsDir, filename)
+}
 
-	// Apre l'immagine originale
-	src, err := imaging.Open(originalPath, imaging.AutoOrientation(true))
+// saveRendition scrive una versione ridotta nel formato indicato dall'estensione di RenditionName, passando da un file
+// temporaneo perché la foto risulti elaborata e venga servita solo a scrittura completata
+func (pm *PhotoManager) saveRendition(img image.Image, dir, filename string) error {
+	name := RenditionName(filename)
+	format, err := imaging.FormatFromFilename(name)
 	if err != nil {
-		return fmt.Errorf("errore nell'apertura dell'immagine: %v", err)
+		return err
 	}
 
-	// Ridimensiona mantenendo le proporzioni con dimensioni massime 1024x1024
-	preview := imaging.Fit(src, 1024, 1024, imaging.Lanczos)
-
-	// Salva la preview con qualità JPEG 85
-	err = imaging.Save(preview, previewPath, imaging.JPEGQuality(85))
+	dst, err := os.CreateTemp(dir, incompleteUploadPattern)
 	if err != nil {
-		return fmt.Errorf("errore nel salvataggio della preview: %v", err)
+		return err
 	}
+	tempPath := dst.Name()
+	defer os.Remove(tempPath)
 
-	return nil
+	err = imaging.Encode(dst, img, format, imaging.JPEGQuality(85))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tempPath, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, filepath.Join(dir, name))
 }
 
-// DeletePhoto elimina una immagine dal filesystem
+// DeletePhoto elimina una immagine dal filesystem insieme a thumbnail e preview
 func (pm *PhotoManager) DeletePhoto(filename string) error {
+	if filename == "" || filepath.Base(filename) != filename {
+		return fmt.Errorf("nome file non valido: %s", filename)
+	}
 	filePath := filepath.Join(pm.photosDir, filename)
 
 	// Verifica che il file esista
@@ -167,6 +383,16 @@ func (pm *PhotoManager) DeletePhoto(filename string) error {
 		return fmt.Errorf("errore nell'eliminazione del file: %v", err)
 	}
 
+	// Le versioni ridotte possono non essere ancora state generate; per le foto WebP vengono eliminate
+	// anche quelle con il nome dell'originale non ancora convertite
+	for _, dir := range []string{pm.thumbnailsDir, pm.previewsDir} {
+		for _, name := range []string{RenditionName(filename), filename} {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("errore nell'eliminazione del file: %v", err)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -183,8 +409,8 @@ func (pm *PhotoManager) isImageFile(filename string) bool {
 	return false
 }
 
-// getMimeTypeFromExtension restituisce il tipo MIME basandosi sull'estensione
-func (pm *PhotoManager) getMimeTypeFromExtension(filename string) string {
+// GetMimeTypeFromExtension restituisce il tipo MIME basandosi sull'estensione
+func (pm *PhotoManager) GetMimeTypeFromExtension(filename string) string {
 	ext := strings.ToLower(filepath.Ext(filename))
 	switch ext {
 	case ".jpg", ".jpeg":
@@ -216,16 +442,137 @@ func (pm *PhotoManager) getExtensionFromMimeType(mimeType string) string {
 	}
 }
 
+// PhotoExists verifica se l'immagine originale esiste, rifiutando nomi che contengono percorsi
+func (pm *PhotoManager) PhotoExists(filename string) bool {
+	if filename == "" || filepath.Base(filename) != filename || !pm.isImageFile(filename) {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(pm.photosDir, filename))
+	return err == nil && !info.IsDir()
+}
+
+// OpenPhoto apre l'immagine originale in lettura, rifiutando nomi che contengono percorsi
+func (pm *PhotoManager) OpenPhoto(filename string) (*os.File, os.FileInfo, error) {
+	if !pm.PhotoExists(filename) {
+		return nil, nil, fmt.Errorf("file non trovato: %s", filename)
+	}
+	file, err := os.Open(filepath.Join(pm.photosDir, filename))
+	if err != nil {
+		return nil, nil, fmt.Errorf("errore nell'apertura dell'immagine: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("errore nella lettura del file %s: %v", filename, err)
+	}
+	return file, info, nil
+}
+
+// GetPhotoInfo restituisce dimensione e data di modifica dell'immagine originale
+func (pm *PhotoManager) GetPhotoInfo(filename string) (os.FileInfo, error) {
+	if !pm.PhotoExists(filename) {
+		return nil, fmt.Errorf("file non trovato: %s", filename)
+	}
+	info, err := os.Stat(filepath.Join(pm.photosDir, filename))
+	if err != nil {
+		return nil, fmt.Errorf("errore nella lettura del file %s: %v", filename, err)
+	}
+	return info, nil
+}
+
+// GetPhotoModTime restituisce la data di ultima modifica dell'immagine originale
+func (pm *PhotoManager) GetPhotoModTime(filename string) (time.Time, error) {
+	info, err := os.Stat(filepath.Join(pm.photosDir, filename))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("errore nella lettura del file %s: %v", filename, err)
+	}
+	return info.ModTime(), nil
+}
+
+// ReadExif legge dai dati EXIF dell'immagine la fotocamera e la data di scatto;
+// restituisce valori vuoti se l'immagine non contiene dati EXIF
+func (pm *PhotoManager) ReadExif(filename string) (string, time.Time, error) {
+	file, err := os.Open(filepath.Join(pm.photosDir, filename))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("errore nell'apertura dell'immagine: %v", err)
+	}
+	defer file.Close()
+
+	x, err := exif.Decode(file)
+	if err != nil {
+		// Formati senza EXIF (PNG, GIF, WebP) o EXIF assente
+		return "", time.Time{}, nil
+	}
+
+	var parts []string
+	for _, field := range []exif.FieldName{exif.Make, exif.Model} {
+		if tag, err := x.Get(field); err == nil {
+			if value, err := tag.StringVal(); err == nil && strings.TrimSpace(value) != "" {
+				parts = append(parts, strings.TrimSpace(value))
+			}
+		}
+	}
+	camera := strings.Join(parts, " ")
+
+	// Molti modelli ripetono la marca nel nome del modello (es. "Canon Canon EOS R6")
+	if len(parts) == 2 && strings.HasPrefix(strings.ToLower(parts[1]), strings.ToLower(parts[0])) {
+		camera = parts[1]
+	}
+
+	takenAt, err := x.DateTime()
+	if err != nil {
+		takenAt = time.Time{}
+	}
+
+	return camera, takenAt, nil
+}
+
+// MissingRenditions restituisce le versioni ridotte (OperationThumbnail, OperationPreview) non ancora
+// generate per una foto
+func (pm *PhotoManager) MissingRenditions(filename string) []string {
+	var missing []string
+	if !pm.ThumbnailExists(filename) {
+		missing = append(missing, OperationThumbnail)
+	}
+	if !pm.PreviewExists(filename) {
+		missing = append(missing, OperationPreview)
+	}
+	return missing
+}
+
+// VerifyPhoto decodifica l'originale e le versioni ridotte di una foto e restituisce i problemi trovati,
+// nessuno se la foto è integra
+func (pm *PhotoManager) VerifyPhoto(filename string) []string {
+	var problems []string
+	if _, err := openImage(filepath.Join(pm.photosDir, filename)); err != nil {
+		problems = append(problems, fmt.Sprintf("originale non leggibile: %v", err))
+	}
+	for _, rendition := range []struct{ operation, dir string }{
+		{OperationThumbnail, pm.thumbnailsDir},
+		{OperationPreview, pm.previewsDir},
+	} {
+		path := pm.renditionPath(rendition.dir, filename)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			problems = append(problems, rendition.operation+" mancante")
+			continue
+		}
+		if _, err := openImage(path); err != nil {
+			problems = append(problems, fmt.Sprintf("%s non leggibile: %v", rendition.operation, err))
+		}
+	}
+	return problems
+}
+
 // ThumbnailExists verifica se il thumbnail di un'immagine esiste
 func (pm *PhotoManager) ThumbnailExists(filename string) bool {
-	thumbnailPath := filepath.Join(pm.thumbnailsDir, filename)
+	thumbnailPath := pm.renditionPath(pm.thumbnailsDir, filename)
 	_, err := os.Stat(thumbnailPath)
 	return !os.IsNotExist(err)
 }
 
 // PreviewExists verifica se la preview di un'immagine esiste
 func (pm *PhotoManager) PreviewExists(filename string) bool {
-	previewPath := filepath.Join(pm.previewsDir, filename)
+	previewPath := pm.renditionPath(pm.previewsDir, filename)
 	_, err := os.Stat(previewPath)
 	return !os.IsNotExist(err)
 }
@@ -0,0 +1,73 @@
+package manager
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// TestLegacyWebpRenditions verifica le versioni ridotte delle foto WebP salvate con il nome dell'originale
+func TestLegacyWebpRenditions(t *testing.T) {
+	dir := t.TempDir()
+	pm := NewPhotoManager(dir)
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+
+	// Il decoder riconosce il formato dal contenuto: un JPEG basta a simulare una versione ridotta leggibile
+	for _, path := range []string{
+		filepath.Join(dir, "a.webp"),
+		filepath.Join(dir, "thumbnails", "a.webp"),
+		filepath.Join(dir, "previews", "a.webp"),
+	} {
+		if err := imaging.Save(img, path+".tmp.jpg"); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(path+".tmp.jpg", path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "thumbnails", "b.webp"), []byte("non è un'immagine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if !pm.ThumbnailExists("a.webp") || !pm.PreviewExists("a.webp") {
+		t.Fatal("versioni ridotte con il nome dell'originale non trovate")
+	}
+	if thumbnail, preview := pm.GetRenditionModTimes("a.webp"); thumbnail.IsZero() || preview.IsZero() {
+		t.Errorf("GetRenditionModTimes() = %v, %v, attese date non nulle", thumbnail, preview)
+	}
+	if problems := pm.VerifyPhoto("a.webp"); len(problems) > 0 {
+		t.Errorf("VerifyPhoto() = %v, nessun problema atteso", problems)
+	}
+
+	migrated, err := pm.MigrateLegacyRenditions()
+	if err != nil || migrated != 2 {
+		t.Fatalf("MigrateLegacyRenditions() = %d, %v, attese 2", migrated, err)
+	}
+	for _, name := range []string{"thumbnails/a.webp.jpg", "previews/a.webp.jpg", "thumbnails/b.webp"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"thumbnails/a.webp", "previews/a.webp"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s non eliminato dopo la conversione", name)
+		}
+	}
+
+	// La versione non convertita, perché non leggibile, viene eliminata con la foto
+	if err := os.WriteFile(filepath.Join(dir, "b.webp"), []byte("originale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !pm.ThumbnailExists("b.webp") {
+		t.Error("thumbnail non convertito non trovato")
+	}
+	if err := pm.DeletePhoto("b.webp"); err != nil {
+		t.Fatalf("DeletePhoto: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "thumbnails", "b.webp")); !os.IsNotExist(err) {
+		t.Error("thumbnail con il nome dell'originale non eliminato")
+	}
+}
@@ -0,0 +1,238 @@
+package manager
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PhotoRecord rappresenta i metadati di una foto salvati nel database
+type PhotoRecord struct {
+	ImageName     string
+	OriginalName  string
+	Caption       string
+	UploaderToken string
+	UploaderName  string
+	Camera        string
+	TakenAt       time.Time
+	MediaType     string
+	CreatedAt     time.Time
+	Approved      bool
+	ProcessedAt   time.Time
+	RequestID     string
+	CommentCount  int
+}
+
+const photoColumns = `image_name, original_name, caption, uploader_token, uploader_name, camera, taken_at, media_type, created_at, approved, processed_at, request_id`
+
+// scanPhoto legge una riga prodotta da una query su photoColumns
+func scanPhoto(row interface{ Scan(...any) error }) (*PhotoRecord, error) {
+	var record PhotoRecord
+	var takenAt, createdAt, processedAt int64
+	err := row.Scan(&record.ImageName, &record.OriginalName, &record.Caption, &record.UploaderToken,
+		&record.UploaderName, &record.Camera, &takenAt, &record.MediaType, &createdAt, &record.Approved, &processedAt, &record.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	if takenAt > 0 {
+		record.TakenAt = time.Unix(takenAt, 0).UTC()
+	}
+	if processedAt > 0 {
+		record.ProcessedAt = time.Unix(processedAt, 0).UTC()
+	}
+	record.CreatedAt = time.Unix(createdAt, 0).UTC()
+	return &record, nil
+}
+
+// unixOrZero converte una data in secondi Unix, usando 0 per la data vuota
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// PhotoMetadataManager gestisce la persistenza dei metadati delle foto
+type PhotoMetadataManager struct {
+	db *sql.DB
+}
+
+// NewPhotoMetadataManager crea una nuova istanza del manager
+func NewPhotoMetadataManager(metadataManager *MetadataManager) *PhotoMetadataManager {
+	return &PhotoMetadataManager{
+		db: metadataManager.DB(),
+	}
+}
+
+// SavePhoto salva i metadati di una foto appena caricata
+func (pmm *PhotoMetadataManager) SavePhoto(record *PhotoRecord) error {
+	_, err := pmm.db.Exec(
+		`INSERT INTO photos (`+photoColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.ImageName, record.OriginalName, record.Caption, record.UploaderToken, record.UploaderName,
+		record.Camera, unixOrZero(record.TakenAt), record.MediaType, record.CreatedAt.Unix(),
+		record.Approved, unixOrZero(record.ProcessedAt), record.RequestID)
+	if err != nil {
+		return fmt.Errorf("errore nel salvataggio dei metadati della foto: %v", err)
+	}
+	return nil
+}
+
+// GetPhotos restituisce i metadati delle foto indicate, indicizzati per nome.
+// Le foto caricate prima dell'introduzione dei metadati compaiono solo se hanno commenti.
+func (pmm *PhotoMetadataManager) GetPhotos(imageNames []string) (map[string]PhotoRecord, error) {
+	records := make(map[string]PhotoRecord, len(imageNames))
+	if len(imageNames) == 0 {
+		return records, nil
+	}
+
+	in, args := inClause(imageNames)
+	rows, err := pmm.db.Query(`SELECT `+photoColumns+` FROM photos WHERE image_name IN `+in, args...)
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero dei metadati delle foto: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scanPhoto(rows)
+		if err != nil {
+			return nil, fmt.Errorf("errore nella lettura dei metadati delle foto: %v", err)
+		}
+		records[record.ImageName] = *record
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("errore nella lettura dei metadati delle foto: %v", err)
+	}
+
+	countRows, err := pmm.db.Query(`SELECT image_name, COUNT(*) FROM comments WHERE image_name IN `+in+` GROUP BY image_name`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("errore nel conteggio dei commenti: %v", err)
+	}
+	defer countRows.Close()
+
+	for countRows.Next() {
+		var imageName string
+		var count int
+		if err := countRows.Scan(&imageName, &count); err != nil {
+			return nil, fmt.Errorf("errore nel conteggio dei commenti: %v", err)
+		}
+		record := records[imageName] // le foto senza metadati restano con CreatedAt vuoto
+		record.ImageName = imageName
+		record.CommentCount = count
+		records[imageName] = record
+	}
+	return records, countRows.Err()
+}
+
+// GetPhoto restituisce i metadati di una foto, o nil se non sono presenti
+func (pmm *PhotoMetadataManager) GetPhoto(imageName string) (*PhotoRecord, error) {
+	records, err := pmm.GetPhotos([]string{imageName})
+	if err != nil {
+		return nil, err
+	}
+	record, ok := records[imageName]
+	if !ok || record.CreatedAt.IsZero() {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// GetImageNames restituisce i nomi di tutte le foto registrate nel database
+func (pmm *PhotoMetadataManager) GetImageNames() ([]string, error) {
+	return pmm.queryNames(`SELECT image_name FROM photos`)
+}
+
+// GetUnprocessedNames restituisce i nomi delle foto di cui non è ancora stata rilevata l'elaborazione
+func (pmm *PhotoMetadataManager) GetUnprocessedNames() ([]string, error) {
+	return pmm.queryNames(`SELECT image_name FROM photos WHERE processed_at = 0 ORDER BY image_name`)
+}
+
+// CountUnprocessed restituisce il numero di foto di cui thumbnail e preview non sono ancora pronte
+func (pmm *PhotoMetadataManager) CountUnprocessed() (int, error) {
+	var count int
+	if err := pmm.db.QueryRow(`SELECT COUNT(*) FROM photos WHERE processed_at = 0`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("errore nel conteggio delle foto da elaborare: %v", err)
+	}
+	return count, nil
+}
+
+// GetPendingApprovalNames restituisce i nomi delle foto in attesa di approvazione, dalla più recente
+func (pmm *PhotoMetadataManager) GetPendingApprovalNames() ([]string, error) {
+	return pmm.queryNames(`SELECT image_name FROM photos WHERE approved = 0 ORDER BY image_name DESC`)
+}
+
+// queryNames esegue una query che restituisce una colonna di nomi di foto
+func (pmm *PhotoMetadataManager) queryNames(query string, args ...any) ([]string, error) {
+	rows, err := pmm.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero della lista delle foto: %v", err)
+	}
+	defer rows.Close()
+
+	imageNames := []string{}
+	for rows.Next() {
+		var imageName string
+		if err := rows.Scan(&imageName); err != nil {
+			return nil, fmt.Errorf("errore nella lettura della lista delle foto: %v", err)
+		}
+		imageNames = append(imageNames, imageName)
+	}
+	return imageNames, rows.Err()
+}
+
+// MarkProcessed registra che le versioni ridotte della foto sono state generate
+func (pmm *PhotoMetadataManager) MarkProcessed(imageName string, processedAt time.Time) error {
+	_, err := pmm.db.Exec(`UPDATE photos SET processed_at = ? WHERE image_name = ?`, processedAt.Unix(), imageName)
+	if err != nil {
+		return fmt.Errorf("errore nell'aggiornamento dello stato della foto %s: %v", imageName, err)
+	}
+	return nil
+}
+
+// UpdateExif aggiorna fotocamera e data di scatto di una foto, ad esempio dopo averli riletti dal file
+func (pmm *PhotoMetadataManager) UpdateExif(imageName, camera string, takenAt time.Time) error {
+	_, err := pmm.db.Exec(`UPDATE photos SET camera = ?, taken_at = ? WHERE image_name = ?`, camera, unixOrZero(takenAt), imageName)
+	if err != nil {
+		return fmt.Errorf("errore nell'aggiornamento dei dati EXIF della foto %s: %v", imageName, err)
+	}
+	return nil
+}
+
+// SetApproved approva una foto, restituisce false se la foto non esiste o era già approvata
+func (pmm *PhotoMetadataManager) SetApproved(imageName string) (bool, error) {
+	result, err := pmm.db.Exec(`UPDATE photos SET approved = 1 WHERE image_name = ? AND approved = 0`, imageName)
+	if err != nil {
+		return false, fmt.Errorf("errore nell'approvazione della foto %s: %v", imageName, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("errore nell'approvazione della foto %s: %v", imageName, err)
+	}
+	return affected > 0, nil
+}
+
+// DeletePhoto elimina i metadati di una foto insieme a tag, commenti, reazioni salvate,
+// appartenenza agli album e voce dell'indice di ricerca
+func (pmm *PhotoMetadataManager) DeletePhoto(imageName string) error {
+	tx, err := pmm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("errore nell'eliminazione dei metadati della foto %s: %v", imageName, err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`DELETE FROM photos WHERE image_name = ?`,
+		`DELETE FROM photo_tags WHERE image_name = ?`,
+		`DELETE FROM comments WHERE image_name = ?`,
+		`DELETE FROM photo_reactions WHERE image_name = ?`,
+		`DELETE FROM album_photos WHERE image_name = ?`,
+		`UPDATE albums SET cover_photo = '' WHERE cover_photo = ?`,
+		`DELETE FROM photo_search WHERE image_name = ?`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, imageName); err != nil {
+			return fmt.Errorf("errore nell'eliminazione dei metadati della foto %s: %v", imageName, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,127 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// LaneStarvationLimit è il numero di job delle corsie più alte dopo il quale una corsia in attesa
+	// viene servita per prima, così le importazioni avanzano anche durante la festa
+	LaneStarvationLimit = 10
+
+	// QueueFailed raccoglie i job non riusciti dopo l'ultimo tentativo; non è una corsia e i worker
+	// non lo svuotano, ma i job possono essere rimessi in coda con RequeueFailed
+	QueueFailed = "failed"
+)
+
+// Queue è la coda di elaborazione delle immagini, divisa in corsie: i worker prelevano i job dalla
+// corsia più alta non vuota, salvo servire una corsia più bassa rimasta in attesa troppo a lungo
+type Queue interface {
+	// AddImageToQueue aggiunge un job alla coda
+	AddImageToQueue(ctx context.Context, job *QueueJob) error
+	// GetNextImageFromQueue recupera il prossimo job, attendendo al massimo timeout; restituisce nil
+	// se la coda resta vuota o il context viene cancellato
+	GetNextImageFromQueue(ctx context.Context, timeout time.Duration) (*QueueJob, error)
+	// GetQueueLength restituisce il numero di job in attesa in tutte le corsie
+	GetQueueLength() (int64, error)
+	// GetLaneLengths restituisce il numero di job in attesa in ogni corsia
+	GetLaneLengths() (map[string]int64, error)
+	// ListJobs restituisce, senza prelevarli, al massimo limit job della corsia indicata o di
+	// QueueFailed dal più vecchio, insieme al numero totale di job presenti
+	ListJobs(lane string, limit int) ([]*QueueJob, int64, error)
+	// AddFailedJob conserva in QueueFailed un job non riuscito dopo l'ultimo tentativo
+	AddFailedJob(ctx context.Context, job *QueueJob) error
+	// RequeueFailed rimette i job di QueueFailed nelle loro corsie, dal primo tentativo, e restituisce
+	// quanti ne ha rimessi; gli elementi non decodificabili restano in QueueFailed, dove si possono
+	// esaminare ed eliminare con Purge, e vengono contati in skipped
+	RequeueFailed(ctx context.Context) (requeued, skipped int, err error)
+	// Purge elimina i job della corsia indicata o di QueueFailed e restituisce quanti ne ha eliminati
+	Purge(lane string) (int64, error)
+	// Ping verifica che la coda sia raggiungibile entro il tempo del context
+	Ping(ctx context.Context) error
+	// Close rilascia le risorse della coda
+	Close() error
+}
+
+// jobLane restituisce la corsia in cui accodare il job, interactive se non è indicata
+func jobLane(job *QueueJob) (string, error) {
+	if job.Lane == "" {
+		return LaneInteractive, nil
+	}
+	if !ValidLane(job.Lane) {
+		return "", fmt.Errorf("corsia %q non valida", job.Lane)
+	}
+	return job.Lane, nil
+}
+
+// checkListLane verifica che lane sia una corsia o QueueFailed
+func checkListLane(lane string) error {
+	if lane != QueueFailed && !ValidLane(lane) {
+		return fmt.Errorf("corsia %q non valida, valori ammessi %s, %s, %s, %s", lane, LaneInteractive, LaneBulk, LaneBackground, QueueFailed)
+	}
+	return nil
+}
+
+// retryJob prepara un job non riuscito per essere rimesso in coda dal primo tentativo
+func retryJob(job *QueueJob) *QueueJob {
+	retry := *job
+	retry.Attempt = 1
+	retry.Error = ""
+	retry.EnqueuedAt = time.Now().UTC()
+	return &retry
+}
+
+// laneScheduler decide in che ordine i worker cercano i job nelle corsie. Per ogni corsia conta i
+// job prelevati dalle corsie più alte mentre lei aspettava: raggiunto LaneStarvationLimit la corsia
+// passa in testa per un prelievo. Con più worker il conteggio è approssimato, ma nessuna corsia
+// resta ferma finché arrivano job più urgenti
+type laneScheduler struct {
+	mutex  sync.Mutex
+	passed map[string]int
+}
+
+func newLaneScheduler() *laneScheduler {
+	return &laneScheduler{passed: map[string]int{}}
+}
+
+// order restituisce le corsie nell'ordine in cui cercare il prossimo job
+func (ls *laneScheduler) order() []string {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	starved := -1
+	for i, lane := range QueueLanes {
+		if ls.passed[lane] >= LaneStarvationLimit && (starved < 0 || ls.passed[lane] > ls.passed[QueueLanes[starved]]) {
+			starved = i
+		}
+	}
+	order := append([]string(nil), QueueLanes...)
+	if starved > 0 {
+		copy(order[1:starved+1], QueueLanes[:starved])
+		order[0] = QueueLanes[starved]
+	}
+	return order
+}
+
+// served registra il prelievo di un job dalla corsia lane cercando nell'ordine order: le corsie
+// precedenti erano vuote, quelle successive e meno urgenti hanno aspettato un job in più
+func (ls *laneScheduler) served(order []string, lane string) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	found := false
+	for _, candidate := range order {
+		switch {
+		case candidate == lane:
+			ls.passed[candidate] = 0
+			found = true
+		case !found:
+			ls.passed[candidate] = 0
+		case laneRank(candidate) > laneRank(lane):
+			ls.passed[candidate]++
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/logging"
+	"wedding-photo-backend/internal/weddingphoto/tracing"
+)
+
+const (
+	// QueueJobVersion è la versione del formato dei job scritti in coda. La versione 1 è il formato
+	// originale, con il solo nome dell'immagine come stringa
+	QueueJobVersion = 2
+
+	// JobTypeRenditions chiede la generazione delle versioni ridotte di una foto
+	JobTypeRenditions = "renditions"
+
+	// OperationThumbnail e OperationPreview sono le versioni ridotte che il worker può generare
+	OperationThumbnail = "thumbnail"
+	OperationPreview   = "preview"
+
+	// LaneInteractive è la corsia delle foto caricate dagli ospiti, elaborate per prime
+	LaneInteractive = "interactive"
+	// LaneBulk è la corsia delle importazioni in blocco
+	LaneBulk = "bulk"
+	// LaneBackground è la corsia delle rielaborazioni in background
+	LaneBackground = "background"
+)
+
+// RenditionOperations sono le operazioni di un job di elaborazione completo
+var RenditionOperations = []string{OperationThumbnail, OperationPreview}
+
+// QueueLanes sono le corsie della coda di elaborazione, in ordine di priorità
+var QueueLanes = []string{LaneInteractive, LaneBulk, LaneBackground}
+
+// ValidLane verifica se lane è una corsia della coda
+func ValidLane(lane string) bool {
+	return laneRank(lane) >= 0
+}
+
+// laneRank restituisce la posizione della corsia in ordine di priorità, -1 se non esiste
+func laneRank(lane string) int {
+	for i, candidate := range QueueLanes {
+		if candidate == lane {
+			return i
+		}
+	}
+	return -1
+}
+
+// QueueJob è un job della coda di elaborazione. Oltre alla foto e alle operazioni richieste porta
+// l'identificativo della richiesta che l'ha creato, perché il worker possa riportarlo nei propri log,
+// e il contesto di traccia (header W3C traceparent e tracestate), perché gli span dell'elaborazione
+// continuino la traccia della richiesta. Error è l'ultimo errore dei job non riusciti
+type QueueJob struct {
+	Version      int               `json:"version"`
+	Type         string            `json:"type"`
+	PhotoID      string            `json:"photo_id"`
+	Lane         string            `json:"lane"`
+	Operations   []string          `json:"operations"`
+	Attempt      int               `json:"attempt"`
+	EnqueuedAt   time.Time         `json:"enqueued_at"`
+	RequestID    string            `json:"request_id,omitempty"`
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// NewRenditionJob crea il primo tentativo di un job di elaborazione per le operazioni indicate nella
+// corsia indicata, con l'identificativo della richiesta e il contesto di traccia contenuti in ctx
+func NewRenditionJob(ctx context.Context, photoID, lane string, operations []string) *QueueJob {
+	return &QueueJob{
+		Version:      QueueJobVersion,
+		Type:         JobTypeRenditions,
+		PhotoID:      photoID,
+		Lane:         lane,
+		Operations:   append([]string(nil), operations...),
+		Attempt:      1,
+		EnqueuedAt:   time.Now().UTC(),
+		RequestID:    logging.RequestID(ctx),
+		TraceContext: tracing.Inject(ctx),
+	}
+}
+
+// EncodeQueueJob scrive un job nel formato della coda
+func EncodeQueueJob(job *QueueJob) (string, error) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return "", fmt.Errorf("errore nella codifica del job: %v", err)
+	}
+	return string(data), nil
+}
+
+// DecodeQueueJob interpreta un elemento della coda. Gli elementi nel formato originale, con il solo
+// nome dell'immagine, diventano job di versione 1 che chiedono thumbnail e preview; i job senza
+// corsia, accodati prima dell'introduzione delle corsie, appartengono alla corsia interactive. Il campo
+// event dei job scritti in precedenza, che ripeteva il tipo, viene ignorato
+func DecodeQueueJob(value string) (*QueueJob, error) {
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		return &QueueJob{
+			Version:    1,
+			Type:       JobTypeRenditions,
+			PhotoID:    value,
+			Lane:       LaneInteractive,
+			Operations: append([]string(nil), RenditionOperations...),
+			Attempt:    1,
+		}, nil
+	}
+
+	var job QueueJob
+	if err := json.Unmarshal([]byte(value), &job); err != nil {
+		return nil, fmt.Errorf("job non valido: %v", err)
+	}
+	if job.Version < 2 || job.Version > QueueJobVersion {
+		return nil, fmt.Errorf("versione %d del job non supportata, attesa al massimo %d", job.Version, QueueJobVersion)
+	}
+	if job.PhotoID == "" {
+		return nil, fmt.Errorf("job senza photo_id")
+	}
+	if job.Lane == "" {
+		job.Lane = LaneInteractive
+	}
+	if !ValidLane(job.Lane) {
+		return nil, fmt.Errorf("corsia %q del job non valida", job.Lane)
+	}
+	return &job, nil
+}
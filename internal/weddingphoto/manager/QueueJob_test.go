@@ -0,0 +1,104 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeQueueJob(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    *QueueJob
+		wantErr bool
+	}{
+		{
+			name:  "formato originale con il solo nome",
+			value: "2024-06-15-18-30-00-12345678.jpg",
+			want: &QueueJob{
+				Version:    1,
+				Type:       JobTypeRenditions,
+				PhotoID:    "2024-06-15-18-30-00-12345678.jpg",
+				Lane:       LaneInteractive,
+				Operations: []string{OperationThumbnail, OperationPreview},
+				Attempt:    1,
+			},
+		},
+		{
+			name:  "versione 2 senza corsia e con il campo event",
+			value: `{"version":2,"type":"renditions","photo_id":"a.jpg","event":"photo.regenerate","operations":["preview"],"attempt":2,"enqueued_at":"2024-06-15T18:30:00Z"}`,
+			want: &QueueJob{
+				Version:    2,
+				Type:       JobTypeRenditions,
+				PhotoID:    "a.jpg",
+				Lane:       LaneInteractive,
+				Operations: []string{OperationPreview},
+				Attempt:    2,
+				EnqueuedAt: time.Date(2024, 6, 15, 18, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "versione 2 con corsia ed errore",
+			value: `{"version":2,"type":"renditions","photo_id":"b.png","lane":"bulk","operations":["thumbnail"],"attempt":3,"error":"boom"}`,
+			want: &QueueJob{
+				Version:    2,
+				Type:       JobTypeRenditions,
+				PhotoID:    "b.png",
+				Lane:       LaneBulk,
+				Operations: []string{OperationThumbnail},
+				Attempt:    3,
+				Error:      "boom",
+			},
+		},
+		{name: "JSON non valido", value: `{"version":2,`, wantErr: true},
+		{name: "versione mancante", value: `{"photo_id":"a.jpg"}`, wantErr: true},
+		{name: "versione futura", value: `{"version":3,"photo_id":"a.jpg"}`, wantErr: true},
+		{name: "senza photo_id", value: `{"version":2}`, wantErr: true},
+		{name: "corsia sconosciuta", value: `{"version":2,"photo_id":"a.jpg","lane":"urgent"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeQueueJob(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("DecodeQueueJob(%q) = %+v, atteso un errore", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeQueueJob(%q): %v", tt.value, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeQueueJob(%q) = %+v, atteso %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeQueueJobRoundTrip(t *testing.T) {
+	job := &QueueJob{
+		Version:      QueueJobVersion,
+		Type:         JobTypeRenditions,
+		PhotoID:      "c.webp",
+		Lane:         LaneBackground,
+		Operations:   []string{OperationThumbnail, OperationPreview},
+		Attempt:      1,
+		EnqueuedAt:   time.Date(2024, 6, 15, 18, 30, 0, 0, time.UTC),
+		RequestID:    "0123456789abcdef",
+		TraceContext: map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+	}
+
+	value, err := EncodeQueueJob(job)
+	if err != nil {
+		t.Fatalf("EncodeQueueJob: %v", err)
+	}
+	got, err := DecodeQueueJob(value)
+	if err != nil {
+		t.Fatalf("DecodeQueueJob(%q): %v", value, err)
+	}
+	if !reflect.DeepEqual(got, job) {
+		t.Errorf("DecodeQueueJob(EncodeQueueJob(job)) = %+v, atteso %+v", got, job)
+	}
+}
@@ -3,81 +3,223 @@ package manager
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 const (
+	// IMAGE_PROCESSING_QUEUE è la lista della corsia interactive, la stessa usata prima delle corsie
+	// dai worker esterni; le altre corsie usano la lista con il nome della corsia come suffisso
 	IMAGE_PROCESSING_QUEUE = "image_processing_queue"
 )
 
-// QueueManager gestisce la comunicazione con Redis per la coda di elaborazione immagini
+// QueueManager è la coda di elaborazione immagini conservata in Redis, con una lista per corsia
 type QueueManager struct {
-	client *redis.Client
-	ctx    context.Context
+	client    *redis.Client
+	ctx       context.Context
+	scheduler *laneScheduler
 }
 
-// NewQueueManager crea una nuova istanza del manager
-func NewQueueManager(redisAddr, redisPassword string, redisDB int) *QueueManager {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     redisAddr,
-		Password: redisPassword,
-		DB:       redisDB,
-	})
-
+// NewQueueManager crea una nuova istanza del manager sul client Redis condiviso
+func NewQueueManager(client *redis.Client) *QueueManager {
 	return &QueueManager{
-		client: rdb,
-		ctx:    context.Background(),
+		client:    client,
+		ctx:       context.Background(),
+		scheduler: newLaneScheduler(),
+	}
+}
+
+// laneKey restituisce la lista Redis della corsia o di QueueFailed
+func laneKey(lane string) string {
+	if lane == LaneInteractive {
+		return IMAGE_PROCESSING_QUEUE
 	}
+	return IMAGE_PROCESSING_QUEUE + ":" + lane
 }
 
-// AddImageToQueue aggiunge un'immagine alla coda di elaborazione
-func (qm *QueueManager) AddImageToQueue(imageName string) error {
-	return nil // Modifica temporanea per togliere la coda
-	err := qm.client.LPush(qm.ctx, IMAGE_PROCESSING_QUEUE, imageName).Err()
+// AddImageToQueue aggiunge un job alla coda di elaborazione, nella lista della sua corsia
+func (qm *QueueManager) AddImageToQueue(ctx context.Context, job *QueueJob) error {
+	lane, err := jobLane(job)
+	if err != nil {
+		return fmt.Errorf("errore nell'aggiunta dell'immagine alla coda: %v", err)
+	}
+	payload, err := EncodeQueueJob(job)
+	if err != nil {
+		return err
+	}
+	err = qm.client.LPush(ctx, laneKey(lane), payload).Err()
 	if err != nil {
 		return fmt.Errorf("errore nell'aggiunta dell'immagine alla coda: %v", err)
 	}
 	return nil
 }
 
-// GetNextImageFromQueue recupera la prossima immagine dalla coda (operazione bloccante)
-func (qm *QueueManager) GetNextImageFromQueue(timeout time.Duration) (string, error) {
-	result, err := qm.client.BRPop(qm.ctx, timeout, IMAGE_PROCESSING_QUEUE).Result()
+// GetNextImageFromQueue recupera il prossimo job dalla coda (operazione bloccante), o nil se la
+// coda resta vuota. BRPOP restituisce l'elemento della prima lista non vuota, quindi le liste sono
+// passate nell'ordine deciso dallo scheduler delle corsie
+func (qm *QueueManager) GetNextImageFromQueue(ctx context.Context, timeout time.Duration) (*QueueJob, error) {
+	order := qm.scheduler.order()
+	keys := make([]string, len(order))
+	for i, lane := range order {
+		keys[i] = laneKey(lane)
+	}
+
+	result, err := qm.client.BRPop(ctx, timeout, keys...).Result()
 	if err != nil {
-		if err == redis.Nil {
-			return "", nil // Nessun elemento nella coda
+		if err == redis.Nil || ctx.Err() != nil {
+			return nil, nil // Nessun elemento nella coda
 		}
-		return "", fmt.Errorf("errore nel recupero dell'immagine dalla coda: %v", err)
+		return nil, fmt.Errorf("errore nel recupero dell'immagine dalla coda: %v", err)
 	}
 
 	if len(result) < 2 {
-		return "", fmt.Errorf("risposta Redis malformata")
+		return nil, fmt.Errorf("risposta Redis malformata")
 	}
 
-	return result[1], nil // result[0] è il nome della coda, result[1] è il valore
+	// result[0] è il nome della lista, result[1] è il valore
+	for _, lane := range order {
+		if laneKey(lane) == result[0] {
+			qm.scheduler.served(order, lane)
+		}
+	}
+	return DecodeQueueJob(result[1])
 }
 
-// GetQueueLength restituisce il numero di elementi nella coda
+// GetQueueLength restituisce il numero di elementi in tutte le corsie
 func (qm *QueueManager) GetQueueLength() (int64, error) {
-	length, err := qm.client.LLen(qm.ctx, IMAGE_PROCESSING_QUEUE).Result()
+	lengths, err := qm.GetLaneLengths()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, length := range lengths {
+		total += length
+	}
+	return total, nil
+}
+
+// GetLaneLengths restituisce il numero di elementi in ogni corsia
+func (qm *QueueManager) GetLaneLengths() (map[string]int64, error) {
+	pipe := qm.client.Pipeline()
+	commands := make(map[string]*redis.IntCmd, len(QueueLanes))
+	for _, lane := range QueueLanes {
+		commands[lane] = pipe.LLen(qm.ctx, laneKey(lane))
+	}
+	if _, err := pipe.Exec(qm.ctx); err != nil {
+		return nil, fmt.Errorf("errore nel recupero della lunghezza della coda: %v", err)
+	}
+
+	lengths := make(map[string]int64, len(commands))
+	for lane, command := range commands {
+		lengths[lane] = command.Val()
+	}
+	return lengths, nil
+}
+
+// ListJobs restituisce, senza prelevarli, al massimo limit job della corsia o di QueueFailed dal più
+// vecchio; gli elementi non validi compaiono come job con il solo errore
+func (qm *QueueManager) ListJobs(lane string, limit int) ([]*QueueJob, int64, error) {
+	if err := checkListLane(lane); err != nil {
+		return nil, 0, err
+	}
+	key := laneKey(lane)
+	total, err := qm.client.LLen(qm.ctx, key).Result()
 	if err != nil {
-		return 0, fmt.Errorf("errore nel recupero della lunghezza della coda: %v", err)
+		return nil, 0, fmt.Errorf("errore nella lettura della coda: %v", err)
 	}
-	return length, nil
+	if limit <= 0 {
+		return []*QueueJob{}, total, nil
+	}
+
+	// LPUSH aggiunge in testa e BRPOP preleva dalla coda: il job più vecchio è l'ultimo della lista
+	values, err := qm.client.LRange(qm.ctx, key, -int64(limit), -1).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("errore nella lettura della coda: %v", err)
+	}
+	jobs := make([]*QueueJob, 0, len(values))
+	for i := len(values) - 1; i >= 0; i-- {
+		job, err := DecodeQueueJob(values[i])
+		if err != nil {
+			job = &QueueJob{Error: err.Error()}
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, total, nil
 }
 
-// TestConnection testa la connessione a Redis
-func (qm *QueueManager) TestConnection() error {
-	_, err := qm.client.Ping(qm.ctx).Result()
+// AddFailedJob conserva un job non riuscito nella lista dei job falliti
+func (qm *QueueManager) AddFailedJob(ctx context.Context, job *QueueJob) error {
+	payload, err := EncodeQueueJob(job)
 	if err != nil {
+		return err
+	}
+	if err := qm.client.LPush(ctx, laneKey(QueueFailed), payload).Err(); err != nil {
+		return fmt.Errorf("errore nel salvataggio del job non riuscito: %v", err)
+	}
+	return nil
+}
+
+// RequeueFailed rimette i job falliti nelle loro corsie, dal più vecchio; gli elementi non validi
+// restano tra i job non riusciti e vengono contati in skipped
+func (qm *QueueManager) RequeueFailed(ctx context.Context) (requeued, skipped int, err error) {
+	// Esamina solo i job presenti all'inizio: quelli non validi tornano in testa alla lista e quelli
+	// aggiunti nel frattempo dai worker restano tra i non riusciti
+	length, err := qm.client.LLen(ctx, laneKey(QueueFailed)).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("errore nella lettura dei job non riusciti: %v", err)
+	}
+	for ; length > 0; length-- {
+		value, err := qm.client.RPop(ctx, laneKey(QueueFailed)).Result()
+		if err == redis.Nil {
+			break
+		}
+		if err != nil {
+			return requeued, skipped, fmt.Errorf("errore nella lettura dei job non riusciti: %v", err)
+		}
+		job, err := DecodeQueueJob(value)
+		if err != nil {
+			slog.Warn("Job non valido lasciato tra i job non riusciti", "value", value, "error", err)
+			if err := qm.client.LPush(ctx, laneKey(QueueFailed), value).Err(); err != nil {
+				return requeued, skipped, fmt.Errorf("errore nel salvataggio del job non valido: %v", err)
+			}
+			skipped++
+			continue
+		}
+		if err := qm.AddImageToQueue(ctx, retryJob(job)); err != nil {
+			// Il job torna tra quelli falliti per non perderlo
+			qm.client.RPush(ctx, laneKey(QueueFailed), value)
+			return requeued, skipped, err
+		}
+		requeued++
+	}
+	return requeued, skipped, nil
+}
+
+// Purge elimina la lista della corsia o di QueueFailed
+func (qm *QueueManager) Purge(lane string) (int64, error) {
+	if err := checkListLane(lane); err != nil {
+		return 0, err
+	}
+	pipe := qm.client.TxPipeline()
+	length := pipe.LLen(qm.ctx, laneKey(lane))
+	pipe.Del(qm.ctx, laneKey(lane))
+	if _, err := pipe.Exec(qm.ctx); err != nil {
+		return 0, fmt.Errorf("errore nello svuotamento della coda: %v", err)
+	}
+	return length.Val(), nil
+}
+
+// Ping verifica la connessione a Redis entro il tempo del context
+func (qm *QueueManager) Ping(ctx context.Context) error {
+	if err := qm.client.Ping(ctx).Err(); err != nil {
 		return fmt.Errorf("errore nella connessione a Redis: %v", err)
 	}
 	return nil
 }
 
-// Close chiude la connessione Redis
+// Close non chiude il client, che appartiene al RedisManager
 func (qm *QueueManager) Close() error {
-	return qm.client.Close()
+	return nil
 }
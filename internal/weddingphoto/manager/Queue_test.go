@@ -0,0 +1,123 @@
+package manager
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLaneSchedulerOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		passed map[string]int
+		want   []string
+	}{
+		{name: "nessuna attesa", passed: map[string]int{}, want: []string{LaneInteractive, LaneBulk, LaneBackground}},
+		{name: "sotto il limite", passed: map[string]int{LaneBulk: LaneStarvationLimit - 1, LaneBackground: LaneStarvationLimit - 1}, want: []string{LaneInteractive, LaneBulk, LaneBackground}},
+		{name: "bulk al limite", passed: map[string]int{LaneBulk: LaneStarvationLimit}, want: []string{LaneBulk, LaneInteractive, LaneBackground}},
+		{name: "background al limite", passed: map[string]int{LaneBackground: LaneStarvationLimit}, want: []string{LaneBackground, LaneInteractive, LaneBulk}},
+		{name: "a parità vince la corsia più alta", passed: map[string]int{LaneBulk: LaneStarvationLimit, LaneBackground: LaneStarvationLimit}, want: []string{LaneBulk, LaneInteractive, LaneBackground}},
+		{name: "vince l'attesa più lunga", passed: map[string]int{LaneBulk: LaneStarvationLimit, LaneBackground: LaneStarvationLimit + 2}, want: []string{LaneBackground, LaneInteractive, LaneBulk}},
+		{name: "interactive non scavalca nessuno", passed: map[string]int{LaneInteractive: LaneStarvationLimit * 2}, want: []string{LaneInteractive, LaneBulk, LaneBackground}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ls := newLaneScheduler()
+			for lane, passed := range tt.passed {
+				ls.passed[lane] = passed
+			}
+			if got := ls.order(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order() = %v, atteso %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLaneSchedulerServed simula dei worker che prelevano sempre dalla prima corsia non vuota
+// nell'ordine dello scheduler, con le corsie indicate sempre piene
+func TestLaneSchedulerServed(t *testing.T) {
+	repeat := func(lane string, count int) []string {
+		lanes := make([]string, count)
+		for i := range lanes {
+			lanes[i] = lane
+		}
+		return lanes
+	}
+	concat := func(groups ...[]string) []string {
+		var lanes []string
+		for _, group := range groups {
+			lanes = append(lanes, group...)
+		}
+		return lanes
+	}
+
+	tests := []struct {
+		name  string
+		busy  []string
+		picks int
+		want  []string
+	}{
+		{
+			name:  "solo interactive",
+			busy:  []string{LaneInteractive},
+			picks: 15,
+			want:  repeat(LaneInteractive, 15),
+		},
+		{
+			name:  "solo corsie basse",
+			busy:  []string{LaneBackground},
+			picks: 3,
+			want:  repeat(LaneBackground, 3),
+		},
+		{
+			name:  "bulk servita ogni LaneStarvationLimit job interactive",
+			busy:  []string{LaneInteractive, LaneBulk},
+			picks: 2 * (LaneStarvationLimit + 1),
+			want: concat(
+				repeat(LaneInteractive, LaneStarvationLimit), []string{LaneBulk},
+				repeat(LaneInteractive, LaneStarvationLimit), []string{LaneBulk},
+			),
+		},
+		{
+			name:  "tutte le corsie piene",
+			busy:  []string{LaneInteractive, LaneBulk, LaneBackground},
+			picks: 2 * (LaneStarvationLimit + 2),
+			want: concat(
+				repeat(LaneInteractive, LaneStarvationLimit), []string{LaneBulk, LaneBackground},
+				repeat(LaneInteractive, LaneStarvationLimit), []string{LaneBulk, LaneBackground},
+			),
+		},
+		{
+			name:  "background servita ogni LaneStarvationLimit job bulk",
+			busy:  []string{LaneBulk, LaneBackground},
+			picks: LaneStarvationLimit + 2,
+			want:  concat(repeat(LaneBulk, LaneStarvationLimit), []string{LaneBackground, LaneBulk}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			busy := map[string]bool{}
+			for _, lane := range tt.busy {
+				busy[lane] = true
+			}
+
+			ls := newLaneScheduler()
+			var got []string
+			for i := 0; i < tt.picks; i++ {
+				order := ls.order()
+				for _, lane := range order {
+					if busy[lane] {
+						ls.served(order, lane)
+						got = append(got, lane)
+						break
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("prelievi:\n  %s\natteso:\n  %s", strings.Join(got, " "), strings.Join(tt.want, " "))
+			}
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	RATE_LIMIT_KEY_PREFIX    = "ratelimit:"
+	RATE_LIMIT_OVERRIDES_KEY = "ratelimit:overrides"
+
+	// RateLimitUpload è il budget dei caricamenti di foto
+	RateLimitUpload = "upload"
+	// RateLimitRead è il budget delle richieste in lettura
+	RateLimitRead = "read"
+
+	// rateLimitOverridesTTL indica per quanto tempo le modifiche ai limiti restano in cache
+	rateLimitOverridesTTL = 10 * time.Second
+	// rateLimitRedisTimeout è il tempo massimo di attesa di Redis prima di usare i contatori in memoria
+	rateLimitRedisTimeout = 500 * time.Millisecond
+	// rateLimitRedisRetry indica dopo quanto tempo Redis viene interrogato di nuovo dopo un errore
+	rateLimitRedisRetry = 5 * time.Second
+)
+
+// tokenBucketScript aggiorna in modo atomico uno o più token bucket: la richiesta viene accettata
+// solo se tutti i bucket hanno almeno un gettone, altrimenti nessun bucket viene consumato.
+// KEYS: i bucket; ARGV: istante corrente in ms, poi capacità e durata della ricarica completa in ms per ogni bucket.
+// Restituisce l'attesa in ms prima del prossimo gettone (0 se accettata) e i gettoni rimasti.
+var tokenBucketScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local wait = 0
+local tokens = {}
+for i = 1, #KEYS do
+	local capacity = tonumber(ARGV[i * 2])
+	local period = tonumber(ARGV[i * 2 + 1])
+	local state = redis.call('HMGET', KEYS[i], 'tokens', 'ts')
+	local available = tonumber(state[1]) or capacity
+	local updated = tonumber(state[2]) or now
+	local rate = capacity / period
+	available = math.min(capacity, available + math.max(0, now - updated) * rate)
+	if available < 1 then
+		wait = math.max(wait, math.ceil((1 - available) / rate))
+	end
+	tokens[i] = available
+end
+local remaining = -1
+for i = 1, #KEYS do
+	local period = tonumber(ARGV[i * 2 + 1])
+	local available = tokens[i]
+	if wait == 0 then
+		available = available - 1
+	end
+	redis.call('HSET', KEYS[i], 'tokens', tostring(available), 'ts', tostring(now))
+	redis.call('PEXPIRE', KEYS[i], period)
+	if remaining < 0 or available < remaining then
+		remaining = available
+	end
+end
+return {wait, math.floor(remaining)}
+`)
+
+// errRedisSkipped indica che Redis non è stato interrogato perché non disponibile di recente
+var errRedisSkipped = errors.New("Redis temporaneamente escluso")
+
+// RateLimit indica quante richieste sono consentite in un periodo; il bucket si ricarica gradualmente
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// String restituisce il limite nel formato accettato da ParseRateLimit
+func (rl RateLimit) String() string {
+	return fmt.Sprintf("%d/%s", rl.Requests, rl.Period)
+}
+
+// ParseRateLimit interpreta un limite nel formato "richieste/durata", ad esempio "30/10m"
+func ParseRateLimit(value string) (RateLimit, error) {
+	requestsPart, periodPart, found := strings.Cut(strings.TrimSpace(value), "/")
+	if !found {
+		return RateLimit{}, fmt.Errorf("limite %q non valido, formato atteso richieste/durata (es. 30/10m)", value)
+	}
+	requests, err := strconv.Atoi(strings.TrimSpace(requestsPart))
+	if err != nil || requests < 1 {
+		return RateLimit{}, fmt.Errorf("limite %q non valido: il numero di richieste deve essere positivo", value)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(periodPart))
+	if err != nil || period < time.Second {
+		return RateLimit{}, fmt.Errorf("limite %q non valido: la durata deve essere di almeno 1s", value)
+	}
+	return RateLimit{Requests: requests, Period: period}, nil
+}
+
+// RateLimitResult è l'esito della verifica di un limite
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      RateLimit
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// memoryBucket è un token bucket in memoria, usato quando Redis non è raggiungibile
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	period  time.Duration
+}
+
+// RateLimitManager limita le richieste con token bucket salvati in Redis, condivisi da tutte le istanze.
+// Ogni richiesta consuma un gettone dal bucket dell'indirizzo IP e, se presente, da quello dell'ospite;
+// il bucket dell'IP ha capacità moltiplicata per ipMultiplier perché più ospiti condividono la rete della sala.
+type RateLimitManager struct {
+	client       *redis.Client
+	ctx          context.Context
+	defaults     map[string]RateLimit
+	ipMultiplier int
+
+	overridesMutex   sync.Mutex
+	overrides        map[string]RateLimit
+	overridesFetched time.Time
+
+	memoryMutex   sync.Mutex
+	memoryBuckets map[string]*memoryBucket
+	lastCleanup   time.Time
+	usingMemory   bool
+	redisRetryAt  time.Time
+}
+
+// NewRateLimitManager crea una nuova istanza del manager con i limiti di default per ogni budget; senza
+// client Redis, nelle installazioni con una sola istanza, contatori e limiti modificati restano in memoria
+func NewRateLimitManager(client *redis.Client, defaults map[string]RateLimit, ipMultiplier int) *RateLimitManager {
+	if ipMultiplier < 1 {
+		ipMultiplier = 1
+	}
+	return &RateLimitManager{
+		client:        client,
+		ctx:           context.Background(),
+		defaults:      defaults,
+		ipMultiplier:  ipMultiplier,
+		memoryBuckets: map[string]*memoryBucket{},
+	}
+}
+
+// Budgets restituisce i nomi dei budget configurati
+func (rm *RateLimitManager) Budgets() []string {
+	budgets := make([]string, 0, len(rm.defaults))
+	for budget := range rm.defaults {
+		budgets = append(budgets, budget)
+	}
+	sort.Strings(budgets)
+	return budgets
+}
+
+// IPMultiplier restituisce il moltiplicatore della capacità dei bucket per indirizzo IP
+func (rm *RateLimitManager) IPMultiplier() int {
+	return rm.ipMultiplier
+}
+
+// GetLimit restituisce il limite in vigore per un budget e se è stato modificato rispetto al default
+func (rm *RateLimitManager) GetLimit(budget string) (RateLimit, bool, bool) {
+	limit, ok := rm.defaults[budget]
+	if !ok {
+		return RateLimit{}, false, false
+	}
+	if override, ok := rm.getOverrides()[budget]; ok {
+		return override, true, true
+	}
+	return limit, false, true
+}
+
+// SetOverride modifica il limite di un budget per tutte le istanze, ad esempio durante la festa
+func (rm *RateLimitManager) SetOverride(budget string, limit RateLimit) error {
+	if rm.client == nil {
+		rm.setLocalOverride(budget, &limit)
+		return nil
+	}
+	if err := rm.client.HSet(rm.ctx, RATE_LIMIT_OVERRIDES_KEY, budget, limit.String()).Err(); err != nil {
+		return fmt.Errorf("errore nel salvataggio del limite: %v", err)
+	}
+	rm.invalidateOverrides()
+	return nil
+}
+
+// ClearOverride ripristina il limite di default di un budget
+func (rm *RateLimitManager) ClearOverride(budget string) error {
+	if rm.client == nil {
+		rm.setLocalOverride(budget, nil)
+		return nil
+	}
+	if err := rm.client.HDel(rm.ctx, RATE_LIMIT_OVERRIDES_KEY, budget).Err(); err != nil {
+		return fmt.Errorf("errore nel ripristino del limite: %v", err)
+	}
+	rm.invalidateOverrides()
+	return nil
+}
+
+// Allow consuma un gettone del budget per l'indirizzo IP e l'ospite indicati. Se Redis non risponde
+// usa contatori in memoria, validi solo per questa istanza, per non bloccare le richieste.
+func (rm *RateLimitManager) Allow(ctx context.Context, budget, ip, guestToken string) RateLimitResult {
+	limit, _, ok := rm.GetLimit(budget)
+	if !ok {
+		return RateLimitResult{Allowed: true}
+	}
+
+	keys := []string{RATE_LIMIT_KEY_PREFIX + budget + ":ip:" + ip}
+	capacities := []int{limit.Requests * rm.ipMultiplier}
+	if guestToken != "" {
+		keys = append(keys, RATE_LIMIT_KEY_PREFIX+budget+":guest:"+guestToken)
+		capacities = append(capacities, limit.Requests)
+	}
+
+	now := time.Now()
+	var wait time.Duration
+	var remaining int
+	err := errRedisSkipped
+	rm.memoryMutex.Lock()
+	skipRedis := rm.client == nil || now.Before(rm.redisRetryAt)
+	rm.memoryMutex.Unlock()
+	if !skipRedis {
+		wait, remaining, err = rm.allowRedis(ctx, keys, capacities, limit.Period, now)
+	}
+
+	rm.memoryMutex.Lock()
+	if err != nil {
+		if !rm.usingMemory && rm.client != nil {
+			slog.Warn("Redis non disponibile per il rate limiting, uso i contatori in memoria", "error", err)
+			rm.usingMemory = true
+		}
+		if !skipRedis {
+			rm.redisRetryAt = now.Add(rateLimitRedisRetry)
+		}
+		wait, remaining = rm.allowMemory(keys, capacities, limit.Period, now)
+	} else if rm.usingMemory {
+		slog.Info("Redis di nuovo disponibile per il rate limiting")
+		rm.usingMemory = false
+	}
+	rm.memoryMutex.Unlock()
+
+	return RateLimitResult{
+		Allowed:    wait == 0,
+		Limit:      limit,
+		Remaining:  remaining,
+		RetryAfter: wait,
+	}
+}
+
+// allowRedis esegue lo script dei token bucket in Redis
+func (rm *RateLimitManager) allowRedis(ctx context.Context, keys []string, capacities []int, period time.Duration, now time.Time) (time.Duration, int, error) {
+	args := []any{now.UnixMilli()}
+	for _, capacity := range capacities {
+		args = append(args, capacity, period.Milliseconds())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rateLimitRedisTimeout)
+	defer cancel()
+	result, err := tokenBucketScript.Run(ctx, rm.client, keys, args...).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(result) != 2 {
+		return 0, 0, fmt.Errorf("risposta inattesa dello script di rate limiting: %v", result)
+	}
+	return time.Duration(result[0]) * time.Millisecond, int(result[1]), nil
+}
+
+// allowMemory applica lo stesso algoritmo dello script ai bucket in memoria; richiede memoryMutex
+func (rm *RateLimitManager) allowMemory(keys []string, capacities []int, period time.Duration, now time.Time) (time.Duration, int) {
+	// Elimina periodicamente i bucket ormai pieni, che equivalgono a bucket nuovi
+	if now.Sub(rm.lastCleanup) > time.Minute {
+		for key, bucket := range rm.memoryBuckets {
+			if now.Sub(bucket.updated) > bucket.period {
+				delete(rm.memoryBuckets, key)
+			}
+		}
+		rm.lastCleanup = now
+	}
+
+	var wait time.Duration
+	buckets := make([]*memoryBucket, len(keys))
+	for i, key := range keys {
+		capacity := float64(capacities[i])
+		rate := capacity / float64(period)
+		bucket, ok := rm.memoryBuckets[key]
+		if !ok {
+			bucket = &memoryBucket{tokens: capacity, updated: now}
+			rm.memoryBuckets[key] = bucket
+		}
+		bucket.tokens = math.Min(capacity, bucket.tokens+float64(now.Sub(bucket.updated))*rate)
+		bucket.updated = now
+		bucket.period = period
+		if bucket.tokens < 1 {
+			wait = max(wait, time.Duration(math.Ceil((1-bucket.tokens)/rate)))
+		}
+		buckets[i] = bucket
+	}
+
+	remaining := -1
+	for _, bucket := range buckets {
+		if wait == 0 {
+			bucket.tokens--
+		}
+		if remaining < 0 || int(bucket.tokens) < remaining {
+			remaining = int(bucket.tokens)
+		}
+	}
+	return wait, remaining
+}
+
+// getOverrides restituisce i limiti modificati, letti da Redis al più ogni rateLimitOverridesTTL
+func (rm *RateLimitManager) getOverrides() map[string]RateLimit {
+	rm.overridesMutex.Lock()
+	defer rm.overridesMutex.Unlock()
+
+	if rm.overrides != nil && (rm.client == nil || time.Since(rm.overridesFetched) < rateLimitOverridesTTL) {
+		return rm.overrides
+	}
+	if rm.client == nil {
+		rm.overrides = map[string]RateLimit{}
+		return rm.overrides
+	}
+
+	ctx, cancel := context.WithTimeout(rm.ctx, rateLimitRedisTimeout)
+	defer cancel()
+	values, err := rm.client.HGetAll(ctx, RATE_LIMIT_OVERRIDES_KEY).Result()
+	if err != nil {
+		// Redis non disponibile: si continuano a usare gli ultimi limiti letti
+		if rm.overrides == nil {
+			rm.overrides = map[string]RateLimit{}
+		}
+		rm.overridesFetched = time.Now()
+		return rm.overrides
+	}
+
+	overrides := make(map[string]RateLimit, len(values))
+	for budget, value := range values {
+		limit, err := ParseRateLimit(value)
+		if err != nil {
+			slog.Warn("Limite non valido ignorato", "budget", budget, "error", err)
+			continue
+		}
+		overrides[budget] = limit
+	}
+	rm.overrides = overrides
+	rm.overridesFetched = time.Now()
+	return overrides
+}
+
+// invalidateOverrides forza la rilettura dei limiti modificati alla prossima richiesta
+func (rm *RateLimitManager) invalidateOverrides() {
+	rm.overridesMutex.Lock()
+	rm.overrides = nil
+	rm.overridesMutex.Unlock()
+}
+
+// setLocalOverride modifica o, con limit nil, ripristina il limite di un budget senza Redis
+func (rm *RateLimitManager) setLocalOverride(budget string, limit *RateLimit) {
+	rm.overridesMutex.Lock()
+	defer rm.overridesMutex.Unlock()
+
+	overrides := make(map[string]RateLimit, len(rm.overrides)+1)
+	for name, value := range rm.overrides {
+		overrides[name] = value
+	}
+	if limit != nil {
+		overrides[budget] = *limit
+	} else {
+		delete(overrides, budget)
+	}
+	rm.overrides = overrides
+}
@@ -0,0 +1,226 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	REACTIONS_KEY_PREFIX = "reactions:"
+	REACTIONS_DIRTY_KEY  = "reactions_dirty"
+	REACTIONS_LOADED_KEY = "reactions_loaded"
+)
+
+// ReactionManager gestisce le reazioni alle foto: i set di ospiti per ogni foto e tipo
+// sono mantenuti in Redis e copiati periodicamente nel database dei metadati
+type ReactionManager struct {
+	client *redis.Client
+	db     *sql.DB
+	ctx    context.Context
+}
+
+// NewReactionManager crea una nuova istanza del manager
+func NewReactionManager(client *redis.Client, metadataManager *MetadataManager) *ReactionManager {
+	return &ReactionManager{
+		client: client,
+		db:     metadataManager.DB(),
+		ctx:    context.Background(),
+	}
+}
+
+// reactionKey restituisce la chiave Redis del set di ospiti che hanno reagito a una foto con un tipo
+func reactionKey(imageName, reactionType string) string {
+	return REACTIONS_KEY_PREFIX + imageName + ":" + reactionType
+}
+
+// AddReaction registra la reazione di un ospite, restituisce false se era già presente
+func (rm *ReactionManager) AddReaction(imageName, reactionType, guestToken string) (bool, error) {
+	pipe := rm.client.TxPipeline()
+	added := pipe.SAdd(rm.ctx, reactionKey(imageName, reactionType), guestToken)
+	pipe.SAdd(rm.ctx, REACTIONS_DIRTY_KEY, imageName)
+	if _, err := pipe.Exec(rm.ctx); err != nil {
+		return false, fmt.Errorf("errore nel salvataggio della reazione: %v", err)
+	}
+	return added.Val() > 0, nil
+}
+
+// RemoveReaction rimuove la reazione di un ospite, restituisce false se non era presente
+func (rm *ReactionManager) RemoveReaction(imageName, reactionType, guestToken string) (bool, error) {
+	pipe := rm.client.TxPipeline()
+	removed := pipe.SRem(rm.ctx, reactionKey(imageName, reactionType), guestToken)
+	pipe.SAdd(rm.ctx, REACTIONS_DIRTY_KEY, imageName)
+	if _, err := pipe.Exec(rm.ctx); err != nil {
+		return false, fmt.Errorf("errore nella rimozione della reazione: %v", err)
+	}
+	return removed.Val() > 0, nil
+}
+
+// DeletePhoto rimuove da Redis tutte le reazioni di una foto
+func (rm *ReactionManager) DeletePhoto(imageName string, reactionTypes []string) error {
+	keys := make([]string, len(reactionTypes))
+	for i, reactionType := range reactionTypes {
+		keys[i] = reactionKey(imageName, reactionType)
+	}
+
+	pipe := rm.client.TxPipeline()
+	pipe.Del(rm.ctx, keys...)
+	pipe.SRem(rm.ctx, REACTIONS_DIRTY_KEY, imageName)
+	if _, err := pipe.Exec(rm.ctx); err != nil {
+		return fmt.Errorf("errore nella rimozione delle reazioni di %s: %v", imageName, err)
+	}
+	return nil
+}
+
+// GetCounts restituisce, per ogni foto, il numero di reazioni per tipo (i tipi senza reazioni sono omessi).
+// Se Redis non è raggiungibile usa l'ultima copia salvata nel database.
+func (rm *ReactionManager) GetCounts(imageNames []string, reactionTypes []string) (map[string]map[string]int, error) {
+	counts := make(map[string]map[string]int, len(imageNames))
+	if len(imageNames) == 0 {
+		return counts, nil
+	}
+
+	pipe := rm.client.Pipeline()
+	results := make(map[string]map[string]*redis.IntCmd, len(imageNames))
+	for _, imageName := range imageNames {
+		results[imageName] = make(map[string]*redis.IntCmd, len(reactionTypes))
+		for _, reactionType := range reactionTypes {
+			results[imageName][reactionType] = pipe.SCard(rm.ctx, reactionKey(imageName, reactionType))
+		}
+	}
+	if _, err := pipe.Exec(rm.ctx); err != nil {
+		return storedReactionCounts(rm.db, imageNames)
+	}
+
+	for imageName, byType := range results {
+		for reactionType, cmd := range byType {
+			if n := cmd.Val(); n > 0 {
+				if counts[imageName] == nil {
+					counts[imageName] = make(map[string]int)
+				}
+				counts[imageName][reactionType] = int(n)
+			}
+		}
+	}
+	return counts, nil
+}
+
+// storedReactionCounts legge i conteggi dalle reazioni salvate nel database
+func storedReactionCounts(db *sql.DB, imageNames []string) (map[string]map[string]int, error) {
+	wanted := make(map[string]bool, len(imageNames))
+	for _, imageName := range imageNames {
+		wanted[imageName] = true
+	}
+
+	rows, err := db.Query(`SELECT image_name, type, COUNT(*) FROM photo_reactions GROUP BY image_name, type`)
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero delle reazioni: %v", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]map[string]int, len(imageNames))
+	for rows.Next() {
+		var imageName, reactionType string
+		var n int
+		if err := rows.Scan(&imageName, &reactionType, &n); err != nil {
+			return nil, fmt.Errorf("errore nella lettura delle reazioni: %v", err)
+		}
+		if !wanted[imageName] {
+			continue
+		}
+		if counts[imageName] == nil {
+			counts[imageName] = make(map[string]int)
+		}
+		counts[imageName][reactionType] = n
+	}
+	return counts, rows.Err()
+}
+
+// PersistDirty copia nel database le reazioni delle foto modificate dall'ultimo salvataggio
+func (rm *ReactionManager) PersistDirty(reactionTypes []string) error {
+	for {
+		imageName, err := rm.client.SPop(rm.ctx, REACTIONS_DIRTY_KEY).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("errore nel recupero delle reazioni da salvare: %v", err)
+		}
+
+		if err := rm.persistPhoto(imageName, reactionTypes); err != nil {
+			// Rimette la foto tra quelle da salvare per il prossimo tentativo
+			rm.client.SAdd(rm.ctx, REACTIONS_DIRTY_KEY, imageName)
+			return err
+		}
+	}
+}
+
+// persistPhoto sostituisce nel database le reazioni di una foto con quelle presenti in Redis
+func (rm *ReactionManager) persistPhoto(imageName string, reactionTypes []string) error {
+	guests := make(map[string][]string, len(reactionTypes))
+	for _, reactionType := range reactionTypes {
+		members, err := rm.client.SMembers(rm.ctx, reactionKey(imageName, reactionType)).Result()
+		if err != nil {
+			return fmt.Errorf("errore nella lettura delle reazioni di %s: %v", imageName, err)
+		}
+		guests[reactionType] = members
+	}
+
+	tx, err := rm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("errore nel salvataggio delle reazioni di %s: %v", imageName, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM photo_reactions WHERE image_name = ?`, imageName); err != nil {
+		return fmt.Errorf("errore nel salvataggio delle reazioni di %s: %v", imageName, err)
+	}
+	for reactionType, members := range guests {
+		for _, guestToken := range members {
+			_, err := tx.Exec(`INSERT INTO photo_reactions (image_name, type, guest_token) VALUES (?, ?, ?)`,
+				imageName, reactionType, guestToken)
+			if err != nil {
+				return fmt.Errorf("errore nel salvataggio delle reazioni di %s: %v", imageName, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RestoreIfEmpty ricarica in Redis le reazioni salvate nel database, se Redis le ha perse (es. dopo un riavvio)
+func (rm *ReactionManager) RestoreIfEmpty() error {
+	loaded, err := rm.client.Exists(rm.ctx, REACTIONS_LOADED_KEY).Result()
+	if err != nil {
+		return fmt.Errorf("errore nella verifica delle reazioni in Redis: %v", err)
+	}
+	if loaded > 0 {
+		return nil
+	}
+
+	rows, err := rm.db.Query(`SELECT image_name, type, guest_token FROM photo_reactions`)
+	if err != nil {
+		return fmt.Errorf("errore nel recupero delle reazioni salvate: %v", err)
+	}
+	defer rows.Close()
+
+	pipe := rm.client.Pipeline()
+	for rows.Next() {
+		var imageName, reactionType, guestToken string
+		if err := rows.Scan(&imageName, &reactionType, &guestToken); err != nil {
+			return fmt.Errorf("errore nella lettura delle reazioni salvate: %v", err)
+		}
+		pipe.SAdd(rm.ctx, reactionKey(imageName, reactionType), guestToken)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("errore nella lettura delle reazioni salvate: %v", err)
+	}
+	pipe.Set(rm.ctx, REACTIONS_LOADED_KEY, 1, 0)
+
+	if _, err := pipe.Exec(rm.ctx); err != nil {
+		return fmt.Errorf("errore nel ripristino delle reazioni in Redis: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package manager
+
+// ReactionStore conserva le reazioni degli ospiti alle foto, cioè gli ospiti che hanno reagito a ogni
+// foto con ogni tipo
+type ReactionStore interface {
+	// AddReaction registra la reazione di un ospite, restituisce false se era già presente
+	AddReaction(imageName, reactionType, guestToken string) (bool, error)
+	// RemoveReaction rimuove la reazione di un ospite, restituisce false se non era presente
+	RemoveReaction(imageName, reactionType, guestToken string) (bool, error)
+	// DeletePhoto rimuove tutte le reazioni di una foto
+	DeletePhoto(imageName string, reactionTypes []string) error
+	// GetCounts restituisce, per ogni foto, il numero di reazioni per tipo (i tipi senza reazioni sono omessi)
+	GetCounts(imageNames []string, reactionTypes []string) (map[string]map[string]int, error)
+	// PersistDirty copia nel database dei metadati le reazioni modificate dall'ultimo salvataggio
+	PersistDirty(reactionTypes []string) error
+	// RestoreIfEmpty ricarica le reazioni salvate nel database dei metadati, se sono andate perse
+	RestoreIfEmpty() error
+}
@@ -0,0 +1,83 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	EXPORT_QUEUE      = "export_queue"
+	EXPORT_KEY_PREFIX = "export:"
+)
+
+// RedisExportJobStore conserva le esportazioni in Redis, condivise da tutte le istanze
+type RedisExportJobStore struct {
+	client    *redis.Client
+	ctx       context.Context
+	retention time.Duration
+}
+
+// NewRedisExportJobStore crea una nuova istanza dello store; lo stato delle esportazioni scade dopo retention
+func NewRedisExportJobStore(client *redis.Client, retention time.Duration) *RedisExportJobStore {
+	return &RedisExportJobStore{
+		client:    client,
+		ctx:       context.Background(),
+		retention: retention,
+	}
+}
+
+// SaveJob salva lo stato di un'esportazione
+func (rs *RedisExportJobStore) SaveJob(job *ExportJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("errore nella codifica dell'esportazione: %v", err)
+	}
+	if err := rs.client.Set(rs.ctx, EXPORT_KEY_PREFIX+job.ID, data, rs.retention).Err(); err != nil {
+		return fmt.Errorf("errore nel salvataggio dell'esportazione: %v", err)
+	}
+	return nil
+}
+
+// GetJob restituisce un'esportazione, o nil se non esiste o è scaduta
+func (rs *RedisExportJobStore) GetJob(id string) (*ExportJob, error) {
+	data, err := rs.client.Get(rs.ctx, EXPORT_KEY_PREFIX+id).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero dell'esportazione: %v", err)
+	}
+
+	var job ExportJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("errore nella lettura dell'esportazione: %v", err)
+	}
+	return &job, nil
+}
+
+// PushJob aggiunge un'esportazione alla coda
+func (rs *RedisExportJobStore) PushJob(id string) error {
+	if err := rs.client.LPush(rs.ctx, EXPORT_QUEUE, id).Err(); err != nil {
+		return fmt.Errorf("errore nell'aggiunta dell'esportazione alla coda: %v", err)
+	}
+	return nil
+}
+
+// PopJob recupera la prossima esportazione dalla coda (operazione bloccante)
+func (rs *RedisExportJobStore) PopJob(ctx context.Context, timeout time.Duration) (string, error) {
+	result, err := rs.client.BRPop(ctx, timeout, EXPORT_QUEUE).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("errore nel recupero dell'esportazione dalla coda: %v", err)
+	}
+	if len(result) < 2 {
+		return "", fmt.Errorf("risposta Redis malformata")
+	}
+	return result[1], nil
+}
@@ -0,0 +1,56 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisManager gestisce la connessione a Redis condivisa da coda, reazioni, eventi, slideshow,
+// esportazioni e rate limiting
+type RedisManager struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+// NewRedisManager crea una nuova istanza del manager
+func NewRedisManager(redisAddr, redisPassword string, redisDB int) *RedisManager {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     redisAddr,
+		Password: redisPassword,
+		DB:       redisDB,
+	})
+
+	return &RedisManager{
+		client: rdb,
+		ctx:    context.Background(),
+	}
+}
+
+// TestConnection testa la connessione a Redis
+func (rm *RedisManager) TestConnection() error {
+	_, err := rm.client.Ping(rm.ctx).Result()
+	if err != nil {
+		return fmt.Errorf("errore nella connessione a Redis: %v", err)
+	}
+	return nil
+}
+
+// Ping verifica la connessione a Redis entro il tempo del context
+func (rm *RedisManager) Ping(ctx context.Context) error {
+	if err := rm.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("errore nella connessione a Redis: %v", err)
+	}
+	return nil
+}
+
+// Client restituisce il client Redis, condiviso con gli altri manager che usano Redis
+func (rm *RedisManager) Client() *redis.Client {
+	return rm.client
+}
+
+// Close chiude la connessione Redis
+func (rm *RedisManager) Close() error {
+	return rm.client.Close()
+}
@@ -0,0 +1,131 @@
+package manager
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SearchParams raccoglie i criteri di ricerca delle foto; i campi vuoti non filtrano
+type SearchParams struct {
+	Text      string
+	Uploader  string
+	Camera    string
+	MediaType string
+	From      time.Time
+	To        time.Time
+}
+
+// SearchManager gestisce l'indice full-text delle foto (SQLite FTS5) e le ricerche
+type SearchManager struct {
+	db *sql.DB
+}
+
+// NewSearchManager crea una nuova istanza del manager
+func NewSearchManager(metadataManager *MetadataManager) *SearchManager {
+	return &SearchManager{
+		db: metadataManager.DB(),
+	}
+}
+
+// IndexPhoto aggiorna la voce dell'indice di una foto con didascalia, tag e autore attuali
+func (sm *SearchManager) IndexPhoto(imageName string) error {
+	tx, err := sm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("errore nell'indicizzazione della foto %s: %v", imageName, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM photo_search WHERE image_name = ?`, imageName); err != nil {
+		return fmt.Errorf("errore nell'indicizzazione della foto %s: %v", imageName, err)
+	}
+	_, err = tx.Exec(`INSERT INTO photo_search (image_name, caption, tags, uploader)
+		SELECT p.image_name, p.caption,
+			COALESCE((SELECT group_concat(t.name, ' ') FROM photo_tags t WHERE t.image_name = p.image_name), ''),
+			p.uploader_name
+		FROM photos p WHERE p.image_name = ?`, imageName)
+	if err != nil {
+		return fmt.Errorf("errore nell'indicizzazione della foto %s: %v", imageName, err)
+	}
+
+	return tx.Commit()
+}
+
+// Search restituisce i metadati delle foto che soddisfano i criteri, le più pertinenti per prime
+// se è indicato un testo, altrimenti le più recenti
+func (sm *SearchManager) Search(params SearchParams) ([]PhotoRecord, error) {
+	query := `SELECT ` + prefixColumns("p", photoColumns) + ` FROM photos p`
+	var conditions []string
+	var args []any
+	orderBy := ` ORDER BY p.image_name DESC`
+
+	if match := ftsQuery(params.Text); match != "" {
+		query += ` JOIN photo_search s ON s.image_name = p.image_name`
+		conditions = append(conditions, `photo_search MATCH ?`)
+		args = append(args, match)
+		orderBy = ` ORDER BY bm25(photo_search), p.image_name DESC`
+	}
+	if params.Uploader != "" {
+		conditions = append(conditions, `p.uploader_name = ? COLLATE NOCASE`)
+		args = append(args, params.Uploader)
+	}
+	if params.Camera != "" {
+		conditions = append(conditions, `p.camera = ? COLLATE NOCASE`)
+		args = append(args, params.Camera)
+	}
+	if params.MediaType != "" {
+		conditions = append(conditions, `p.media_type = ?`)
+		args = append(args, params.MediaType)
+	}
+
+	// Le date si riferiscono allo scatto, o al caricamento se la foto non ha dati EXIF
+	if !params.From.IsZero() {
+		conditions = append(conditions, `COALESCE(NULLIF(p.taken_at, 0), p.created_at) >= ?`)
+		args = append(args, params.From.Unix())
+	}
+	if !params.To.IsZero() {
+		conditions = append(conditions, `COALESCE(NULLIF(p.taken_at, 0), p.created_at) < ?`)
+		args = append(args, params.To.Unix())
+	}
+
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+
+	rows, err := sm.db.Query(query+orderBy, args...)
+	if err != nil {
+		return nil, fmt.Errorf("errore nella ricerca delle foto: %v", err)
+	}
+	defer rows.Close()
+
+	records := []PhotoRecord{}
+	for rows.Next() {
+		record, err := scanPhoto(rows)
+		if err != nil {
+			return nil, fmt.Errorf("errore nella lettura dei risultati della ricerca: %v", err)
+		}
+		records = append(records, *record)
+	}
+	return records, rows.Err()
+}
+
+// ftsQuery converte il testo cercato dall'utente in una query FTS5: ogni parola è cercata
+// come prefisso e tutte le parole devono essere presenti
+func ftsQuery(text string) string {
+	words := strings.Fields(text)
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// prefixColumns aggiunge l'alias di tabella a un elenco di colonne separate da virgola
+func prefixColumns(alias, columns string) string {
+	parts := strings.Split(columns, ",")
+	for i, part := range parts {
+		parts[i] = alias + "." + strings.TrimSpace(part)
+	}
+	return strings.Join(parts, ", ")
+}
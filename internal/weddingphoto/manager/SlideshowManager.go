@@ -0,0 +1,241 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	SLIDESHOW_STATE_KEY  = "slideshow:state"
+	SLIDESHOW_RECENT_KEY = "slideshow:recent"
+	SLIDESHOW_SEEN_KEY   = "slideshow:seen"
+	SLIDESHOW_QUEUE_KEY  = "slideshow:queue"
+	SLIDESHOW_PINNED_KEY = "slideshow:pinned"
+	SLIDESHOW_HIDDEN_KEY = "slideshow:hidden"
+	SLIDESHOW_LOCK_KEY   = "slideshow:lock"
+)
+
+// SlideshowState rappresenta lo stato condiviso dello slideshow; i campi a zero indicano i valori di default
+type SlideshowState struct {
+	Current         string        // Foto mostrata
+	StartedAt       time.Time     // Inizio della slide corrente, spostato in avanti alla ripresa dopo una pausa
+	Paused          bool          // Slideshow in pausa
+	PausedRemaining time.Duration // Tempo rimanente della slide corrente al momento della pausa
+	SlideDuration   time.Duration // Durata di ogni slide
+	PinEvery        int           // Ogni quante slide viene mostrata una foto fissata
+	RepeatWindow    int           // Numero di slide entro cui una foto non viene ripetuta
+	SlideCount      int64         // Numero di slide mostrate
+}
+
+// SlideshowManager gestisce in Redis lo stato dello slideshow, condiviso da tutti gli schermi e le istanze
+type SlideshowManager struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+// NewSlideshowManager crea una nuova istanza del manager
+func NewSlideshowManager(client *redis.Client) *SlideshowManager {
+	return &SlideshowManager{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+// GetState restituisce lo stato dello slideshow
+func (sm *SlideshowManager) GetState() (*SlideshowState, error) {
+	values, err := sm.client.HGetAll(sm.ctx, SLIDESHOW_STATE_KEY).Result()
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero dello stato dello slideshow: %v", err)
+	}
+
+	integer := func(field string) int64 {
+		n, _ := strconv.ParseInt(values[field], 10, 64)
+		return n
+	}
+
+	state := &SlideshowState{
+		Current:         values["current"],
+		Paused:          values["paused"] == "1",
+		PausedRemaining: time.Duration(integer("paused_remaining_ms")) * time.Millisecond,
+		SlideDuration:   time.Duration(integer("slide_duration_ms")) * time.Millisecond,
+		PinEvery:        int(integer("pin_every")),
+		RepeatWindow:    int(integer("repeat_window")),
+		SlideCount:      integer("slide_count"),
+	}
+	if startedAt := integer("started_at_ms"); startedAt > 0 {
+		state.StartedAt = time.UnixMilli(startedAt)
+	}
+	return state, nil
+}
+
+// SaveState salva lo stato dello slideshow
+func (sm *SlideshowManager) SaveState(state *SlideshowState) error {
+	paused := "0"
+	if state.Paused {
+		paused = "1"
+	}
+	startedAt := int64(0)
+	if !state.StartedAt.IsZero() {
+		startedAt = state.StartedAt.UnixMilli()
+	}
+
+	err := sm.client.HSet(sm.ctx, SLIDESHOW_STATE_KEY,
+		"current", state.Current,
+		"started_at_ms", startedAt,
+		"paused", paused,
+		"paused_remaining_ms", state.PausedRemaining.Milliseconds(),
+		"slide_duration_ms", state.SlideDuration.Milliseconds(),
+		"pin_every", state.PinEvery,
+		"repeat_window", state.RepeatWindow,
+		"slide_count", state.SlideCount,
+	).Err()
+	if err != nil {
+		return fmt.Errorf("errore nel salvataggio dello stato dello slideshow: %v", err)
+	}
+	return nil
+}
+
+// Lock acquisisce il lock per modificare lo stato, restituisce false se è già acquisito da un'altra richiesta
+func (sm *SlideshowManager) Lock(ttl time.Duration) (bool, error) {
+	ok, err := sm.client.SetNX(sm.ctx, SLIDESHOW_LOCK_KEY, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("errore nell'acquisizione del lock dello slideshow: %v", err)
+	}
+	return ok, nil
+}
+
+// Unlock rilascia il lock dello stato
+func (sm *SlideshowManager) Unlock() {
+	sm.client.Del(sm.ctx, SLIDESHOW_LOCK_KEY)
+}
+
+// AddShown registra una foto come mostrata: la aggiunge alle slide recenti (limitate a window) e alle foto già viste
+func (sm *SlideshowManager) AddShown(imageName string, window int) error {
+	pipe := sm.client.TxPipeline()
+	pipe.LPush(sm.ctx, SLIDESHOW_RECENT_KEY, imageName)
+	pipe.LTrim(sm.ctx, SLIDESHOW_RECENT_KEY, 0, int64(max(window, 1)-1))
+	pipe.SAdd(sm.ctx, SLIDESHOW_SEEN_KEY, imageName)
+	if _, err := pipe.Exec(sm.ctx); err != nil {
+		return fmt.Errorf("errore nel salvataggio delle slide mostrate: %v", err)
+	}
+	return nil
+}
+
+// GetRecent restituisce le ultime foto mostrate, dalla più recente
+func (sm *SlideshowManager) GetRecent(limit int) ([]string, error) {
+	recent, err := sm.client.LRange(sm.ctx, SLIDESHOW_RECENT_KEY, 0, int64(max(limit, 1)-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero delle slide recenti: %v", err)
+	}
+	return recent, nil
+}
+
+// GetSeen restituisce l'insieme delle foto mostrate almeno una volta
+func (sm *SlideshowManager) GetSeen() (map[string]bool, error) {
+	return sm.members(SLIDESHOW_SEEN_KEY)
+}
+
+// GetQueue restituisce le foto in coda nello slideshow, nell'ordine in cui verranno mostrate
+func (sm *SlideshowManager) GetQueue() ([]string, error) {
+	queue, err := sm.client.LRange(sm.ctx, SLIDESHOW_QUEUE_KEY, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero della coda dello slideshow: %v", err)
+	}
+	return queue, nil
+}
+
+// PopQueue estrae la prossima foto dalla coda, restituisce una stringa vuota se la coda è vuota
+func (sm *SlideshowManager) PopQueue() (string, error) {
+	imageName, err := sm.client.LPop(sm.ctx, SLIDESHOW_QUEUE_KEY).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("errore nel recupero della coda dello slideshow: %v", err)
+	}
+	return imageName, nil
+}
+
+// FillQueue sostituisce la coda con le foto indicate in ordine casuale
+func (sm *SlideshowManager) FillQueue(imageNames []string) error {
+	shuffled := make([]any, len(imageNames))
+	for i, imageName := range imageNames {
+		shuffled[i] = imageName
+	}
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	pipe := sm.client.TxPipeline()
+	pipe.Del(sm.ctx, SLIDESHOW_QUEUE_KEY)
+	if len(shuffled) > 0 {
+		pipe.RPush(sm.ctx, SLIDESHOW_QUEUE_KEY, shuffled...)
+	}
+	if _, err := pipe.Exec(sm.ctx); err != nil {
+		return fmt.Errorf("errore nel salvataggio della coda dello slideshow: %v", err)
+	}
+	return nil
+}
+
+// GetPinned restituisce le foto fissate, nell'ordine in cui sono state fissate
+func (sm *SlideshowManager) GetPinned() ([]string, error) {
+	pinned, err := sm.client.ZRange(sm.ctx, SLIDESHOW_PINNED_KEY, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero delle foto fissate: %v", err)
+	}
+	return pinned, nil
+}
+
+// SetPinned fissa o sblocca una foto
+func (sm *SlideshowManager) SetPinned(imageName string, pinned bool) error {
+	var err error
+	if pinned {
+		err = sm.client.ZAddNX(sm.ctx, SLIDESHOW_PINNED_KEY, redis.Z{
+			Score:  float64(time.Now().UnixMilli()),
+			Member: imageName,
+		}).Err()
+	} else {
+		err = sm.client.ZRem(sm.ctx, SLIDESHOW_PINNED_KEY, imageName).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("errore nell'aggiornamento delle foto fissate: %v", err)
+	}
+	return nil
+}
+
+// GetHidden restituisce l'insieme delle foto escluse dallo slideshow
+func (sm *SlideshowManager) GetHidden() (map[string]bool, error) {
+	return sm.members(SLIDESHOW_HIDDEN_KEY)
+}
+
+// SetHidden esclude una foto dallo slideshow o la reinserisce
+func (sm *SlideshowManager) SetHidden(imageName string, hidden bool) error {
+	var err error
+	if hidden {
+		err = sm.client.SAdd(sm.ctx, SLIDESHOW_HIDDEN_KEY, imageName).Err()
+	} else {
+		err = sm.client.SRem(sm.ctx, SLIDESHOW_HIDDEN_KEY, imageName).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("errore nell'aggiornamento delle foto nascoste: %v", err)
+	}
+	return nil
+}
+
+// members restituisce gli elementi di un set Redis
+func (sm *SlideshowManager) members(key string) (map[string]bool, error) {
+	values, err := sm.client.SMembers(sm.ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("errore nella lettura dello stato dello slideshow: %v", err)
+	}
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set, nil
+}
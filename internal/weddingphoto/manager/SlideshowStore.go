@@ -0,0 +1,35 @@
+package manager
+
+import "time"
+
+// SlideshowStore conserva lo stato dello slideshow, condiviso da tutti gli schermi
+type SlideshowStore interface {
+	// GetState restituisce lo stato dello slideshow
+	GetState() (*SlideshowState, error)
+	// SaveState salva lo stato dello slideshow
+	SaveState(state *SlideshowState) error
+	// Lock acquisisce il lock per modificare lo stato, restituisce false se è già acquisito da un'altra richiesta
+	Lock(ttl time.Duration) (bool, error)
+	// Unlock rilascia il lock dello stato
+	Unlock()
+	// AddShown registra una foto come mostrata: la aggiunge alle slide recenti (limitate a window) e alle foto già viste
+	AddShown(imageName string, window int) error
+	// GetRecent restituisce le ultime foto mostrate, dalla più recente
+	GetRecent(limit int) ([]string, error)
+	// GetSeen restituisce l'insieme delle foto mostrate almeno una volta
+	GetSeen() (map[string]bool, error)
+	// GetQueue restituisce le foto in coda nello slideshow, nell'ordine in cui verranno mostrate
+	GetQueue() ([]string, error)
+	// PopQueue estrae la prossima foto dalla coda, restituisce una stringa vuota se la coda è vuota
+	PopQueue() (string, error)
+	// FillQueue sostituisce la coda con le foto indicate in ordine casuale
+	FillQueue(imageNames []string) error
+	// GetPinned restituisce le foto fissate, nell'ordine in cui sono state fissate
+	GetPinned() ([]string, error)
+	// SetPinned fissa o sblocca una foto
+	SetPinned(imageName string, pinned bool) error
+	// GetHidden restituisce l'insieme delle foto escluse dallo slideshow
+	GetHidden() (map[string]bool, error)
+	// SetHidden esclude una foto dallo slideshow o la reinserisce
+	SetHidden(imageName string, hidden bool) error
+}
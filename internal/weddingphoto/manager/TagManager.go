@@ -0,0 +1,166 @@
+package manager
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TagRecord rappresenta un tag o una persona associata a una foto
+type TagRecord struct {
+	ImageName  string
+	Kind       string
+	Name       string
+	Normalized string
+	AddedBy    string
+}
+
+// TagLabel identifica un tag o una persona tramite tipo e nome normalizzato
+type TagLabel struct {
+	Kind       string
+	Normalized string
+}
+
+// TagSuggestion rappresenta un tag esistente proposto per l'autocompletamento
+type TagSuggestion struct {
+	Kind       string
+	Name       string
+	PhotoCount int
+}
+
+// TagManager gestisce la persistenza dei tag e delle persone associate alle foto
+type TagManager struct {
+	db *sql.DB
+}
+
+// NewTagManager crea una nuova istanza del manager
+func NewTagManager(metadataManager *MetadataManager) *TagManager {
+	return &TagManager{
+		db: metadataManager.DB(),
+	}
+}
+
+// AddTags associa i tag a una foto, ignorando quelli già presenti
+func (tm *TagManager) AddTags(tags []TagRecord) error {
+	tx, err := tm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("errore nel salvataggio dei tag: %v", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	for _, tag := range tags {
+		_, err := tx.Exec(
+			`INSERT OR IGNORE INTO photo_tags (image_name, kind, name, normalized, added_by, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			tag.ImageName, tag.Kind, tag.Name, tag.Normalized, tag.AddedBy, now)
+		if err != nil {
+			return fmt.Errorf("errore nel salvataggio del tag %s: %v", tag.Name, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RemoveTag rimuove un tag da una foto, restituisce false se non era presente
+func (tm *TagManager) RemoveTag(imageName string, label TagLabel) (bool, error) {
+	result, err := tm.db.Exec(`DELETE FROM photo_tags WHERE image_name = ? AND kind = ? AND normalized = ?`,
+		imageName, label.Kind, label.Normalized)
+	if err != nil {
+		return false, fmt.Errorf("errore nella rimozione del tag: %v", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// GetTags restituisce i tag delle foto indicate, raggruppati per foto e ordinati per nome
+func (tm *TagManager) GetTags(imageNames []string) (map[string][]TagRecord, error) {
+	tags := make(map[string][]TagRecord, len(imageNames))
+	if len(imageNames) == 0 {
+		return tags, nil
+	}
+
+	in, args := inClause(imageNames)
+	rows, err := tm.db.Query(`SELECT image_name, kind, name, normalized, added_by FROM photo_tags
+		WHERE image_name IN `+in+` ORDER BY normalized`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero dei tag: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag TagRecord
+		if err := rows.Scan(&tag.ImageName, &tag.Kind, &tag.Name, &tag.Normalized, &tag.AddedBy); err != nil {
+			return nil, fmt.Errorf("errore nella lettura dei tag: %v", err)
+		}
+		tags[tag.ImageName] = append(tags[tag.ImageName], tag)
+	}
+	return tags, rows.Err()
+}
+
+// FindPhotos restituisce i nomi delle foto che hanno tutti i tag indicati (matchAll) o almeno uno
+func (tm *TagManager) FindPhotos(labels []TagLabel, matchAll bool) ([]string, error) {
+	if len(labels) == 0 {
+		return []string{}, nil
+	}
+
+	conditions := make([]string, len(labels))
+	args := make([]any, 0, len(labels)*2+1)
+	for i, label := range labels {
+		conditions[i] = "(kind = ? AND normalized = ?)"
+		args = append(args, label.Kind, label.Normalized)
+	}
+
+	query := `SELECT image_name FROM photo_tags WHERE ` + strings.Join(conditions, " OR ") + ` GROUP BY image_name`
+	if matchAll {
+		query += ` HAVING COUNT(*) = ?`
+		args = append(args, len(labels))
+	}
+
+	rows, err := tm.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("errore nella ricerca delle foto per tag: %v", err)
+	}
+	defer rows.Close()
+
+	imageNames := []string{}
+	for rows.Next() {
+		var imageName string
+		if err := rows.Scan(&imageName); err != nil {
+			return nil, fmt.Errorf("errore nella lettura delle foto per tag: %v", err)
+		}
+		imageNames = append(imageNames, imageName)
+	}
+	return imageNames, rows.Err()
+}
+
+// SuggestTags restituisce i tag che iniziano con il prefisso indicato, dai più usati; kind vuoto include tutti i tipi
+func (tm *TagManager) SuggestTags(prefix, kind string, limit int) ([]TagSuggestion, error) {
+	// Escape dei caratteri speciali di LIKE
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+
+	query := `SELECT kind, MIN(name), COUNT(*) AS photo_count FROM photo_tags WHERE normalized LIKE ? ESCAPE '\'`
+	args := []any{escaped + "%"}
+	if kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, kind)
+	}
+	query += ` GROUP BY kind, normalized ORDER BY photo_count DESC, normalized LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := tm.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("errore nel recupero dei suggerimenti: %v", err)
+	}
+	defer rows.Close()
+
+	suggestions := []TagSuggestion{}
+	for rows.Next() {
+		var suggestion TagSuggestion
+		if err := rows.Scan(&suggestion.Kind, &suggestion.Name, &suggestion.PhotoCount); err != nil {
+			return nil, fmt.Errorf("errore nella lettura dei suggerimenti: %v", err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, rows.Err()
+}
@@ -1,13 +1,44 @@
 package manager
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
 )
 
+// minSigningSecretLength è la lunghezza minima dei segreti usati per firmare gli URL
+const minSigningSecretLength = 16
+
+var (
+	// ErrUrlSignatureMissing indica un URL senza firma quando la firma è obbligatoria
+	ErrUrlSignatureMissing = apperror.New(apperror.KindForbidden, "media_url_unsigned", "URL non firmato")
+	// ErrUrlSignatureInvalid indica una firma errata o creata con una chiave non più valida
+	ErrUrlSignatureInvalid = apperror.New(apperror.KindForbidden, "media_url_invalid_signature", "firma dell'URL non valida")
+	// ErrUrlExpired indica un URL firmato scaduto
+	ErrUrlExpired = apperror.New(apperror.KindGone, "media_url_expired", "URL scaduto")
+)
+
+// SigningKey è una chiave per la firma degli URL, identificata da un ID incluso nell'URL
+type SigningKey struct {
+	ID     string
+	Secret []byte
+}
+
 // UrlManager gestisce la generazione degli URL per le immagini
 type UrlManager struct {
 	baseUrl string
+
+	// Firma degli URL dei file multimediali: la prima chiave firma, tutte sono accettate in verifica
+	signingKeys []SigningKey
+	signedTTL   time.Duration
 }
 
 // NewUrlManager crea una nuova istanza del manager URL
@@ -20,17 +51,133 @@ func NewUrlManager(baseUrl string) *UrlManager {
 	}
 }
 
-// GetImageUrl restituisce l'URL completo per un'immagine dato il nome del file
+// EnableSigning attiva la firma degli URL di thumbnail e preview, con scadenza dopo ttl.
+// Gli URL vengono firmati con la prima chiave; le successive restano valide in verifica,
+// così una chiave può essere sostituita senza invalidare i link già distribuiti.
+func (um *UrlManager) EnableSigning(keys []SigningKey, ttl time.Duration) error {
+	if len(keys) == 0 {
+		return errors.New("nessuna chiave di firma indicata")
+	}
+	if ttl <= 0 {
+		return errors.New("la durata degli URL firmati deve essere positiva")
+	}
+	for _, key := range keys {
+		if len(key.Secret) < minSigningSecretLength {
+			return fmt.Errorf("il segreto della chiave %q deve essere lungo almeno %d caratteri", key.ID, minSigningSecretLength)
+		}
+	}
+
+	um.signingKeys = keys
+	um.signedTTL = ttl
+	return nil
+}
+
+// SigningEnabled indica se gli URL dei file multimediali sono firmati
+func (um *UrlManager) SigningEnabled() bool {
+	return len(um.signingKeys) > 0
+}
+
+// GetImageUrl restituisce l'URL completo per scaricare l'immagine originale dato il nome del file
 func (um *UrlManager) GetImageUrl(imageName string) string {
-	return fmt.Sprintf("%s/media/%s", um.baseUrl, imageName)
+	return fmt.Sprintf("%s/api/photos/%s/original", um.baseUrl, imageName)
 }
 
 // GetThumbnailUrl restituisce l'URL completo per un thumbnail dato il nome del file
 func (um *UrlManager) GetThumbnailUrl(imageName string) string {
-	return fmt.Sprintf("%s/media/thumbnails/%s", um.baseUrl, imageName)
+	return um.mediaUrl("/media/thumbnails/" + RenditionName(imageName))
 }
 
 // GetPreviewUrl restituisce l'URL completo per un'anteprima dato il nome del file
 func (um *UrlManager) GetPreviewUrl(imageName string) string {
-	return fmt.Sprintf("%s/media/previews/%s", um.baseUrl, imageName)
+	return um.mediaUrl("/media/previews/" + RenditionName(imageName))
+}
+
+// GetExportDownloadUrl restituisce l'URL completo per scaricare un'esportazione asincrona
+func (um *UrlManager) GetExportDownloadUrl(exportID string) string {
+	return fmt.Sprintf("%s/api/exports/%s/download", um.baseUrl, exportID)
+}
+
+// mediaUrl restituisce l'URL completo di un file multimediale, firmato se la firma è attiva
+func (um *UrlManager) mediaUrl(path string) string {
+	if !um.SigningEnabled() {
+		return um.baseUrl + path
+	}
+
+	// La scadenza è arrotondata a un quarto della durata: lo stesso URL resta valido
+	// per più richieste e le immagini possono essere messe in cache dal browser
+	step := int64(um.signedTTL / 4 / time.Second)
+	if step < 1 {
+		step = 1
+	}
+	expires := time.Now().Add(um.signedTTL).Unix()
+	expires += step - expires%step
+
+	key := um.signingKeys[0]
+	query := url.Values{}
+	query.Set("exp", strconv.FormatInt(expires, 10))
+	query.Set("kid", key.ID)
+	query.Set("sig", sign(key.Secret, path, expires))
+	return um.baseUrl + path + "?" + query.Encode()
+}
+
+// VerifyMediaUrl verifica firma e scadenza dei parametri di un URL di un file multimediale;
+// con la firma disattivata tutti gli URL sono validi
+func (um *UrlManager) VerifyMediaUrl(path string, query url.Values) error {
+	if !um.SigningEnabled() {
+		return nil
+	}
+
+	expParam, keyID, signature := query.Get("exp"), query.Get("kid"), query.Get("sig")
+	if expParam == "" || signature == "" {
+		return ErrUrlSignatureMissing
+	}
+	expires, err := strconv.ParseInt(expParam, 10, 64)
+	if err != nil {
+		return ErrUrlSignatureInvalid
+	}
+
+	for _, key := range um.signingKeys {
+		if key.ID != keyID {
+			continue
+		}
+		if !hmac.Equal([]byte(signature), []byte(sign(key.Secret, path, expires))) {
+			return ErrUrlSignatureInvalid
+		}
+		if time.Now().Unix() > expires {
+			return ErrUrlExpired
+		}
+		return nil
+	}
+	return ErrUrlSignatureInvalid
+}
+
+// sign calcola la firma HMAC-SHA256 di un percorso con la sua scadenza
+func sign(secret []byte, path string, expires int64) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%s\n%d", path, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ParseSigningKeys interpreta un elenco di chiavi nel formato "id:segreto,id:segreto",
+// con la chiave usata per firmare al primo posto
+func ParseSigningKeys(value string) ([]SigningKey, error) {
+	var keys []SigningKey
+	seen := map[string]bool{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, secret, found := strings.Cut(item, ":")
+		id = strings.TrimSpace(id)
+		if !found || id == "" || secret == "" {
+			return nil, fmt.Errorf("chiave di firma non valida, formato atteso id:segreto")
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("chiave di firma %q ripetuta", id)
+		}
+		seen[id] = true
+		keys = append(keys, SigningKey{ID: id, Secret: []byte(secret)})
+	}
+	return keys, nil
 }
@@ -0,0 +1,254 @@
+package metrics
+
+import (
+	"net/http"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace è il prefisso comune delle metriche
+const namespace = "wedding"
+
+// Esiti degli upload e dei job usati come valori delle label
+const (
+	OutcomeSuccess  = "success"
+	OutcomeRejected = "rejected"
+	OutcomeError    = "error"
+)
+
+// Tipi di contenuto usati come valori della label kind di MediaBytesServed
+const (
+	KindOriginal  = "original"
+	KindThumbnail = "thumbnail"
+	KindPreview   = "preview"
+	KindExport    = "export"
+)
+
+// MimeUnknown è la label delle richieste di cui non è stato ancora letto il contenuto,
+// MimeOther quella dei formati non supportati, raggruppati per limitare le serie
+const (
+	MimeUnknown = "unknown"
+	MimeOther   = "other"
+)
+
+// Registry contiene le metriche esposte da /metrics, comprese quelle del runtime Go e del processo
+var Registry = prometheus.NewRegistry()
+
+var (
+	// UploadsTotal conta gli upload elaborati dal service per esito e MIME type rilevato
+	UploadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploads_total",
+		Help:      "Upload elaborati, per esito e MIME type rilevato.",
+	}, []string{"outcome", "mime_type"})
+
+	// UploadBytesTotal somma la dimensione dichiarata dei file caricati
+	UploadBytesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_bytes_total",
+		Help:      "Byte caricati, per esito e MIME type rilevato.",
+	}, []string{"outcome", "mime_type"})
+
+	// UploadDuration misura il tempo di elaborazione di un upload nel service, dopo la ricezione del file
+	UploadDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upload_duration_seconds",
+		Help:      "Tempo di elaborazione degli upload (verifica, salvataggio, metadati e coda), per esito e MIME type.",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"outcome", "mime_type"})
+
+	// UploadStageDuration misura le singole fasi di un upload
+	UploadStageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upload_stage_duration_seconds",
+		Help:      "Durata delle fasi degli upload: sniff, save, metadata, enqueue.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"stage"})
+
+	// UploadRequestDuration misura l'intera richiesta di upload, compresa la ricezione del file
+	UploadRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upload_request_duration_seconds",
+		Help:      "Durata delle richieste di upload compresa la ricezione del file, per esito.",
+		Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"outcome"})
+
+	// UploadRejectionsTotal conta gli upload rifiutati per codice di errore
+	UploadRejectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_rejections_total",
+		Help:      "Upload rifiutati, per codice di errore (es. unsupported_image, upload_too_large).",
+	}, []string{"reason"})
+
+	// RateLimitedTotal conta le richieste respinte dai limiti di richieste
+	RateLimitedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Richieste respinte dai limiti di richieste, per budget.",
+	}, []string{"budget"})
+
+	// EnqueueFailuresTotal conta le immagini che non è stato possibile aggiungere alla coda di elaborazione
+	EnqueueFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "enqueue_failures_total",
+		Help:      "Immagini non aggiunte alla coda di elaborazione.",
+	})
+
+	// RenditionDuration misura il tempo tra l'upload e la disponibilità di thumbnail e preview
+	RenditionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rendition_duration_seconds",
+		Help:      "Tempo tra l'upload e la disponibilità della versione ridotta, per tipo (thumbnail, preview).",
+		Buckets:   []float64{1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800},
+	}, []string{"rendition"})
+
+	// RenditionFailuresTotal conta le versioni ridotte non generate entro il tempo massimo
+	RenditionFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rendition_failures_total",
+		Help:      "Versioni ridotte non generate entro il tempo massimo, per tipo (thumbnail, preview).",
+	}, []string{"rendition"})
+
+	// JobDuration misura la generazione di ogni versione ridotta nel worker di elaborazione
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Durata della generazione delle versioni ridotte nel worker, per tipo (thumbnail, preview).",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"rendition"})
+
+	// JobFailuresTotal conta i tentativi del worker di generare una versione ridotta non riusciti
+	JobFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_failures_total",
+		Help:      "Tentativi del worker di generare una versione ridotta non riusciti, per tipo (thumbnail, preview).",
+	}, []string{"rendition"})
+
+	// ExportDuration misura le esportazioni asincrone elaborate dal worker
+	ExportDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "export_duration_seconds",
+		Help:      "Durata delle esportazioni ZIP elaborate dal worker, per esito.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+	}, []string{"outcome"})
+
+	// MediaBytesServed somma i byte inviati per originali, versioni ridotte ed esportazioni
+	MediaBytesServed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "media_served_bytes_total",
+		Help:      "Byte inviati ai client, per tipo (original, thumbnail, preview, export).",
+	}, []string{"kind"})
+
+	// HTTPRequestsTotal conta le richieste HTTP per route e status
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Richieste HTTP, per metodo, route e status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration misura la durata delle richieste HTTP per route
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Durata delle richieste HTTP, per metodo e route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		UploadsTotal,
+		UploadBytesTotal,
+		UploadDuration,
+		UploadStageDuration,
+		UploadRequestDuration,
+		UploadRejectionsTotal,
+		RateLimitedTotal,
+		EnqueueFailuresTotal,
+		RenditionDuration,
+		RenditionFailuresTotal,
+		JobDuration,
+		JobFailuresTotal,
+		ExportDuration,
+		MediaBytesServed,
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+	)
+}
+
+// Outcome restituisce l'esito di un'operazione: rejected per gli errori del client, error per quelli interni
+func Outcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case apperror.Status(err) < http.StatusInternalServerError:
+		return OutcomeRejected
+	default:
+		return OutcomeError
+	}
+}
+
+// RegisterGauge espone una metrica letta al momento della raccolta, ad esempio la lunghezza della coda;
+// se read restituisce un errore la metrica viene omessa invece di riportare un valore falso
+func RegisterGauge(name, help string, read func() (float64, error)) {
+	Registry.MustRegister(&gaugeCollector{
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, nil, nil),
+		read: read,
+	})
+}
+
+// RegisterLabeledGauge espone una metrica con un valore per ogni valore dell'etichetta label, ad esempio
+// la lunghezza di ogni corsia della coda; come per RegisterGauge gli errori omettono la metrica
+func RegisterLabeledGauge(name, help, label string, read func() (map[string]float64, error)) {
+	Registry.MustRegister(&labeledGaugeCollector{
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, []string{label}, nil),
+		read: read,
+	})
+}
+
+// gaugeCollector è una gauge calcolata a ogni raccolta
+type gaugeCollector struct {
+	desc *prometheus.Desc
+	read func() (float64, error)
+}
+
+func (gc *gaugeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- gc.desc
+}
+
+func (gc *gaugeCollector) Collect(ch chan<- prometheus.Metric) {
+	if value, err := gc.read(); err == nil {
+		ch <- prometheus.MustNewConstMetric(gc.desc, prometheus.GaugeValue, value)
+	}
+}
+
+// labeledGaugeCollector è una gauge con etichetta calcolata a ogni raccolta
+type labeledGaugeCollector struct {
+	desc *prometheus.Desc
+	read func() (map[string]float64, error)
+}
+
+func (gc *labeledGaugeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- gc.desc
+}
+
+func (gc *labeledGaugeCollector) Collect(ch chan<- prometheus.Metric) {
+	values, err := gc.read()
+	if err != nil {
+		return
+	}
+	for label, value := range values {
+		ch <- prometheus.MustNewConstMetric(gc.desc, prometheus.GaugeValue, value, label)
+	}
+}
+
+// Handler restituisce l'handler HTTP che espone le metriche in formato Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
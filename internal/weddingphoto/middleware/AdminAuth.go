@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrAdminRequired indica un'operazione riservata agli amministratori richiesta senza token valido
+var ErrAdminRequired = apperror.New(apperror.KindUnauthorized, "admin_required", "Operazione riservata agli amministratori")
+
+// AdminAuth verifica il token degli amministratori (gli sposi) per le operazioni riservate
+type AdminAuth struct {
+	token string
+}
+
+// NewAdminAuth crea una nuova istanza; con token vuoto tutte le richieste sono considerate amministrative
+func NewAdminAuth(token string) *AdminAuth {
+	return &AdminAuth{
+		token: token,
+	}
+}
+
+// Enabled indica se è configurato un token amministrativo
+func (aa *AdminAuth) Enabled() bool {
+	return aa.token != ""
+}
+
+// IsAdmin verifica se la richiesta contiene il token amministrativo nell'header Authorization
+func (aa *AdminAuth) IsAdmin(c *gin.Context) bool {
+	if aa.token == "" {
+		return true
+	}
+
+	provided, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(aa.token)) == 1
+}
+
+// Require restituisce un middleware che blocca le richieste senza token amministrativo
+func (aa *AdminAuth) Require() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !aa.IsAdmin(c) {
+			AbortWithError(c, ErrAdminRequired)
+			return
+		}
+		c.Next()
+	}
+}
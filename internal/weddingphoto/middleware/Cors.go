@@ -0,0 +1,247 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultCorsAllowedHeaders sono gli header che i frontend possono inviare: autenticazione, ospite,
+// lingua, ripresa dei download e degli eventi, upload riprendibili tus
+var DefaultCorsAllowedHeaders = []string{
+	"Accept-Language", "Authorization", "Content-Type", "If-Range", "Last-Event-ID", "Range",
+	"Tus-Resumable", "Upload-Concat", "Upload-Defer-Length", "Upload-Length", "Upload-Metadata", "Upload-Offset",
+	"X-Guest-Token", "X-Request-ID",
+}
+
+// DefaultCorsExposedHeaders sono gli header delle risposte leggibili dai frontend: download,
+// limiti di richieste e avanzamento degli upload tus
+var DefaultCorsExposedHeaders = []string{
+	"Content-Disposition", "Content-Language", "Content-Length", "Content-Range", "ETag", "Location",
+	"Retry-After", "Tus-Extension", "Tus-Max-Size", "Tus-Resumable", "Tus-Version", "Upload-Expires",
+	"Upload-Length", "Upload-Offset", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-Request-ID",
+}
+
+// DefaultCorsAllowedMethods sono i metodi consentiti nelle richieste cross-origin
+var DefaultCorsAllowedMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// CorsPolicy descrive quali origini possono chiamare un gruppo di route dal browser
+type CorsPolicy struct {
+	// AllowedOrigins contiene origini complete (https://sposi.it), origini con sottodomini
+	// qualsiasi (https://*.sposi.it) oppure "*" per tutte le origini
+	AllowedOrigins   []string
+	AllowCredentials bool
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	MaxAge           time.Duration
+}
+
+// originPattern è un'origine consentita: exact per le origini complete, altrimenti schema,
+// suffisso del dominio e porta per i sottodomini
+type originPattern struct {
+	exact  string
+	scheme string
+	suffix string
+	port   string
+}
+
+// compiledPolicy è una CorsPolicy pronta per essere applicata alle richieste
+type compiledPolicy struct {
+	prefix         string
+	allowAll       bool
+	origins        []originPattern
+	credentials    bool
+	allowedMethods string
+	allowedHeaders string
+	exposedHeaders string
+	maxAge         string
+}
+
+// Cors applica le policy CORS; ogni policy vale per le route che iniziano con il suo prefisso,
+// quella con il prefisso più lungo ha la precedenza
+type Cors struct {
+	policies []compiledPolicy
+}
+
+// NewCors crea il middleware con la policy di default per tutte le route
+func NewCors(policy CorsPolicy) (*Cors, error) {
+	cors := &Cors{}
+	if err := cors.AddRoute("/", policy); err != nil {
+		return nil, err
+	}
+	return cors, nil
+}
+
+// AddRoute imposta una policy diversa per le route che iniziano con prefix, ad esempio /media
+func (cs *Cors) AddRoute(prefix string, policy CorsPolicy) error {
+	compiled, err := compilePolicy(prefix, policy)
+	if err != nil {
+		return fmt.Errorf("policy CORS per %s non valida: %w", prefix, err)
+	}
+
+	cs.policies = append(cs.policies, compiled)
+	sort.SliceStable(cs.policies, func(i, j int) bool {
+		return len(cs.policies[i].prefix) > len(cs.policies[j].prefix)
+	})
+	return nil
+}
+
+// Handler restituisce il middleware da registrare su tutto il router, in modo che risponda
+// anche alle richieste preflight per cui non esiste una route OPTIONS
+func (cs *Cors) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy := cs.policyFor(c.Request.URL.Path)
+		origin := c.GetHeader("Origin")
+		header := c.Writer.Header()
+
+		// La risposta cambia in base all'origine, salvo quando tutte le origini ricevono "*"
+		if !policy.allowAll || policy.credentials {
+			header.Add("Vary", "Origin")
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if origin != "" && policy.allows(origin) {
+			if policy.allowAll && !policy.credentials {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+			if policy.credentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if preflight {
+				header.Set("Access-Control-Allow-Methods", policy.allowedMethods)
+				header.Set("Access-Control-Allow-Headers", policy.allowedHeaders)
+				if policy.maxAge != "" {
+					header.Set("Access-Control-Max-Age", policy.maxAge)
+				}
+			} else if policy.exposedHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", policy.exposedHeaders)
+			}
+		}
+
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+// AllowsOrigin verifica se la policy del percorso consente l'origine, per le richieste che non passano
+// dagli header CORS come l'apertura di un WebSocket
+func (cs *Cors) AllowsOrigin(path, origin string) bool {
+	return cs.policyFor(path).allows(origin)
+}
+
+// policyFor restituisce la policy con il prefisso più lungo che corrisponde al percorso
+func (cs *Cors) policyFor(path string) *compiledPolicy {
+	for i := range cs.policies {
+		prefix := cs.policies[i].prefix
+		if prefix == "/" || path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return &cs.policies[i]
+		}
+	}
+	return &cs.policies[len(cs.policies)-1]
+}
+
+// allows verifica se l'origine è consentita dalla policy
+func (cp *compiledPolicy) allows(origin string) bool {
+	if cp.allowAll {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	for _, pattern := range cp.origins {
+		if pattern.exact != "" {
+			if pattern.exact == origin {
+				return true
+			}
+			continue
+		}
+		if parsed.Scheme == pattern.scheme && parsed.Port() == pattern.port &&
+			strings.HasSuffix(parsed.Hostname(), pattern.suffix) && len(parsed.Hostname()) > len(pattern.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePolicy verifica una policy e prepara i valori degli header
+func compilePolicy(prefix string, policy CorsPolicy) (compiledPolicy, error) {
+	compiled := compiledPolicy{
+		prefix:         prefix,
+		credentials:    policy.AllowCredentials,
+		allowedMethods: strings.Join(defaultList(policy.AllowedMethods, DefaultCorsAllowedMethods), ", "),
+		allowedHeaders: strings.Join(defaultList(policy.AllowedHeaders, DefaultCorsAllowedHeaders), ", "),
+		exposedHeaders: strings.Join(defaultList(policy.ExposedHeaders, DefaultCorsExposedHeaders), ", "),
+	}
+	if policy.MaxAge > 0 {
+		compiled.maxAge = strconv.Itoa(int(policy.MaxAge / time.Second))
+	}
+
+	for _, origin := range policy.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+		if origin == "" {
+			continue
+		}
+		if origin == "*" {
+			compiled.allowAll = true
+			continue
+		}
+
+		parsed, err := url.Parse(strings.Replace(origin, "*.", "wildcard.", 1))
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+			(parsed.Path != "" && parsed.Path != "/") {
+			return compiledPolicy{}, fmt.Errorf("origine %q non valida, usare schema://dominio[:porta]", origin)
+		}
+
+		if !strings.Contains(origin, "*") {
+			compiled.origins = append(compiled.origins, originPattern{exact: origin})
+			continue
+		}
+		hostname := parsed.Hostname()
+		if !strings.HasPrefix(hostname, "wildcard.") || strings.Count(origin, "*") > 1 {
+			return compiledPolicy{}, fmt.Errorf("origine %q non valida, il carattere jolly è ammesso solo come primo sottodominio (https://*.dominio.it)", origin)
+		}
+		compiled.origins = append(compiled.origins, originPattern{
+			scheme: parsed.Scheme,
+			suffix: strings.TrimPrefix(hostname, "wildcard"),
+			port:   parsed.Port(),
+		})
+	}
+
+	// Con le credenziali il browser non accetta "*": rifletterebbe ogni origine, esponendo i cookie a qualsiasi sito
+	if compiled.allowAll && compiled.credentials {
+		return compiledPolicy{}, errors.New("le credenziali non possono essere abilitate per tutte le origini (*), indicare le origini consentite")
+	}
+	return compiled, nil
+}
+
+// defaultList restituisce values se non vuoto, altrimenti i valori di default
+func defaultList(values, defaults []string) []string {
+	if len(values) == 0 {
+		return defaults
+	}
+	return values
+}
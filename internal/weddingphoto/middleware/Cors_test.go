@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCorsPolicyAllows(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{name: "origine esatta", allowed: []string{"https://sposi.it"}, origin: "https://sposi.it", want: true},
+		{name: "origine esatta con barra finale nella configurazione", allowed: []string{"https://sposi.it/"}, origin: "https://sposi.it", want: true},
+		{name: "origine esatta con schema diverso", allowed: []string{"https://sposi.it"}, origin: "http://sposi.it", want: false},
+		{name: "origine esatta non copre i sottodomini", allowed: []string{"https://sposi.it"}, origin: "https://foto.sposi.it", want: false},
+		{name: "sottodominio", allowed: []string{"https://*.sposi.it"}, origin: "https://foto.sposi.it", want: true},
+		{name: "sottodominio di secondo livello", allowed: []string{"https://*.sposi.it"}, origin: "https://a.foto.sposi.it", want: true},
+		{name: "maiuscole", allowed: []string{"https://*.Sposi.it"}, origin: "https://FOTO.sposi.IT", want: true},
+		{name: "dominio senza sottodominio", allowed: []string{"https://*.sposi.it"}, origin: "https://sposi.it", want: false},
+		{name: "dominio con lo stesso suffisso", allowed: []string{"https://*.sposi.it"}, origin: "https://evilsposi.it", want: false},
+		{name: "dominio che contiene il suffisso", allowed: []string{"https://*.sposi.it"}, origin: "https://foto.sposi.it.evil.com", want: false},
+		{name: "sottodominio con schema diverso", allowed: []string{"https://*.sposi.it"}, origin: "http://foto.sposi.it", want: false},
+		{name: "sottodominio con porta non prevista", allowed: []string{"https://*.sposi.it"}, origin: "https://foto.sposi.it:8443", want: false},
+		{name: "sottodominio con porta", allowed: []string{"https://*.sposi.it:8443"}, origin: "https://foto.sposi.it:8443", want: true},
+		{name: "sottodominio senza la porta prevista", allowed: []string{"https://*.sposi.it:8443"}, origin: "https://foto.sposi.it", want: false},
+		{name: "origine null", allowed: []string{"https://*.sposi.it"}, origin: "null", want: false},
+		{name: "più origini", allowed: []string{"https://sposi.it", "http://*.localhost:3000"}, origin: "http://app.localhost:3000", want: true},
+		{name: "tutte le origini", allowed: []string{"*"}, origin: "https://qualsiasi.example", want: true},
+		{name: "nessuna origine", allowed: nil, origin: "https://sposi.it", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := compilePolicy("/", CorsPolicy{AllowedOrigins: tt.allowed})
+			if err != nil {
+				t.Fatalf("compilePolicy(%v): %v", tt.allowed, err)
+			}
+			if got := policy.allows(tt.origin); got != tt.want {
+				t.Errorf("allows(%q) con %v = %v, atteso %v", tt.origin, tt.allowed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompilePolicyInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy CorsPolicy
+	}{
+		{name: "due caratteri jolly", policy: CorsPolicy{AllowedOrigins: []string{"https://*.*.sposi.it"}}},
+		{name: "carattere jolly non iniziale", policy: CorsPolicy{AllowedOrigins: []string{"https://foto.*.it"}}},
+		{name: "carattere jolly senza punto", policy: CorsPolicy{AllowedOrigins: []string{"https://*sposi.it"}}},
+		{name: "senza schema", policy: CorsPolicy{AllowedOrigins: []string{"*.sposi.it"}}},
+		{name: "schema non http", policy: CorsPolicy{AllowedOrigins: []string{"ftp://*.sposi.it"}}},
+		{name: "con percorso", policy: CorsPolicy{AllowedOrigins: []string{"https://sposi.it/galleria"}}},
+		{name: "tutte le origini con credenziali", policy: CorsPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCors(tt.policy); err == nil {
+				t.Errorf("NewCors(%+v) senza errore, atteso un errore", tt.policy)
+			}
+		})
+	}
+}
+
+func TestCorsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cors, err := NewCors(CorsPolicy{AllowedOrigins: []string{"https://*.sposi.it"}, AllowCredentials: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cors.AddRoute("/media", CorsPolicy{AllowedOrigins: []string{"*"}}); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.Use(cors.Handler())
+	router.GET("/api/photos", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/media/thumbnails/a.jpg", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		origin          string
+		wantStatus      int
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{name: "sottodominio consentito", method: http.MethodGet, path: "/api/photos", origin: "https://foto.sposi.it", wantStatus: http.StatusOK, wantOrigin: "https://foto.sposi.it", wantCredentials: "true"},
+		{name: "origine non consentita", method: http.MethodGet, path: "/api/photos", origin: "https://evil.example", wantStatus: http.StatusOK},
+		{name: "preflight", method: http.MethodOptions, path: "/api/photos", origin: "https://foto.sposi.it", wantStatus: http.StatusNoContent, wantOrigin: "https://foto.sposi.it", wantCredentials: "true"},
+		{name: "media aperti a tutti", method: http.MethodGet, path: "/media/thumbnails/a.jpg", origin: "https://evil.example", wantStatus: http.StatusOK, wantOrigin: "*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, tt.path, nil)
+			request.Header.Set("Origin", tt.origin)
+			if tt.method == http.MethodOptions {
+				request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status %d, atteso %d", recorder.Code, tt.wantStatus)
+			}
+			if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, atteso %q", got, tt.wantOrigin)
+			}
+			if got := recorder.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, atteso %q", got, tt.wantCredentials)
+			}
+		})
+	}
+}
+
+func TestCorsAllowsOrigin(t *testing.T) {
+	cors, err := NewCors(CorsPolicy{AllowedOrigins: []string{"https://*.sposi.it"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cors.AddRoute("/media", CorsPolicy{AllowedOrigins: []string{"*"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   string
+		origin string
+		want   bool
+	}{
+		{path: "/api/events/ws", origin: "https://foto.sposi.it", want: true},
+		{path: "/api/events/ws", origin: "https://evil.example", want: false},
+		{path: "/api/events/ws", origin: "https://sposi.it", want: false},
+		{path: "/media/thumbnails/a.jpg", origin: "https://evil.example", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := cors.AllowsOrigin(tt.path, tt.origin); got != tt.want {
+			t.Errorf("AllowsOrigin(%q, %q) = %v, atteso %v", tt.path, tt.origin, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/i18n"
+	"wedding-photo-backend/internal/weddingphoto/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// localeKey è la chiave del context con la lingua scelta per la risposta
+const localeKey = "locale"
+
+// Locale restituisce un middleware che sceglie la lingua dei messaggi dal parametro lang
+// o dall'header Accept-Language
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language"))
+		c.Set(localeKey, locale)
+		c.Header("Content-Language", locale)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
+
+// GetLocale restituisce la lingua della risposta, negoziandola se il middleware Locale non è stato eseguito
+func GetLocale(c *gin.Context) string {
+	if locale := c.GetString(localeKey); locale != "" {
+		return locale
+	}
+	return i18n.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language"))
+}
+
+// ErrorResponse costruisce la risposta per un errore con il messaggio nella lingua della richiesta;
+// se il catalogo non contiene il codice resta il messaggio originale dell'errore
+func ErrorResponse(c *gin.Context, err error) model.ErrorResponse {
+	response := apperror.Response(err)
+	if message, ok := i18n.Message(GetLocale(c), response.Code, response.Details); ok {
+		response.Message = message
+	}
+	return response
+}
+
+// AbortWithError interrompe la richiesta rispondendo con lo status e il messaggio tradotto dell'errore
+func AbortWithError(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(apperror.Status(err), ErrorResponse(c, err))
+}
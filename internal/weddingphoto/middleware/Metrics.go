@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestMetrics registra numero e durata delle richieste HTTP per route; le richieste che non
+// corrispondono a nessuna route sono raggruppate in "unmatched" per limitare le serie
+func RequestMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequestsTotal.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// CountServedBytes somma i byte del corpo delle risposte riuscite alla metrica dei media inviati
+func CountServedBytes(kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if size := c.Writer.Size(); size > 0 && c.Writer.Status() < 400 {
+			metrics.MediaBytesServed.WithLabelValues(kind).Add(float64(size))
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrRateLimited indica un client che ha superato il numero di richieste consentite
+var ErrRateLimited = apperror.New(apperror.KindTooManyRequests, "rate_limited", "Troppe richieste, riprovare più tardi")
+
+// RateLimiter limita le richieste per indirizzo IP e token dell'ospite
+type RateLimiter struct {
+	rateLimitManager *manager.RateLimitManager
+	adminAuth        *AdminAuth
+}
+
+// NewRateLimiter crea una nuova istanza del middleware
+func NewRateLimiter(rateLimitManager *manager.RateLimitManager, adminAuth *AdminAuth) *RateLimiter {
+	return &RateLimiter{
+		rateLimitManager: rateLimitManager,
+		adminAuth:        adminAuth,
+	}
+}
+
+// Require restituisce un middleware che consuma una richiesta del budget indicato
+// e risponde 429 con Retry-After quando il limite è superato
+func (rl *RateLimiter) Require(budget string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rl.limit(c, budget)
+	}
+}
+
+// RequireReads restituisce un middleware che applica il budget indicato alle sole richieste GET e HEAD
+func (rl *RateLimiter) RequireReads(budget string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		rl.limit(c, budget)
+	}
+}
+
+// limit verifica il budget per la richiesta corrente
+func (rl *RateLimiter) limit(c *gin.Context, budget string) {
+	// Gli sposi possono caricare e consultare le foto senza limiti, se è configurato un token
+	if rl.adminAuth.Enabled() && rl.adminAuth.IsAdmin(c) {
+		c.Next()
+		return
+	}
+
+	guestToken := c.GetHeader("X-Guest-Token")
+	if len(guestToken) > 128 {
+		guestToken = guestToken[:128]
+	}
+	result := rl.rateLimitManager.Allow(c.Request.Context(), budget, c.ClientIP(), guestToken)
+	if result.Limit.Requests > 0 {
+		c.Header("X-RateLimit-Limit", result.Limit.String())
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(max(result.Remaining, 0)))
+	}
+
+	if !result.Allowed {
+		metrics.RateLimitedTotal.WithLabelValues(budget).Inc()
+		retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		AbortWithError(c, apperror.WithDetails(fmt.Errorf("%w (%s)", ErrRateLimited, budget), map[string]any{
+			"budget":      budget,
+			"retry_after": retryAfter,
+		}))
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/manager"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRateLimiterForwardedFor verifica che X-Forwarded-For cambi l'indirizzo del bucket solo se la
+// richiesta arriva da un proxy fidato
+func TestRateLimiterForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		wantLimited    bool
+	}{
+		{name: "nessun proxy fidato", trustedProxies: nil, wantLimited: true},
+		{name: "proxy diverso da chi si connette", trustedProxies: []string{"10.0.0.0/8"}, wantLimited: true},
+		{name: "richiesta dal proxy fidato", trustedProxies: []string{"192.0.2.1"}, wantLimited: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Senza Redis i bucket sono in memoria; con moltiplicatore 1 l'IP ha lo stesso limite dell'ospite
+			limits := map[string]manager.RateLimit{manager.RateLimitRead: {Requests: 3, Period: time.Minute}}
+			limiter := NewRateLimiter(manager.NewRateLimitManager(nil, limits, 1), NewAdminAuth(""))
+
+			router := gin.New()
+			if err := router.SetTrustedProxies(tt.trustedProxies); err != nil {
+				t.Fatal(err)
+			}
+			router.GET("/api/photos", limiter.Require(manager.RateLimitRead), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			limited := false
+			for i := 0; i < 10; i++ {
+				// Ogni richiesta indica un indirizzo e un ospite diversi, dalla stessa connessione
+				request := httptest.NewRequest(http.MethodGet, "/api/photos", nil)
+				request.RemoteAddr = "192.0.2.1:40000"
+				request.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i+1))
+				request.Header.Set("X-Guest-Token", fmt.Sprintf("%032x", i+1))
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, request)
+
+				if recorder.Code == http.StatusTooManyRequests {
+					limited = true
+					if i < 3 {
+						t.Fatalf("richiesta %d limitata prima di esaurire il bucket", i+1)
+					}
+					break
+				}
+			}
+			if limited != tt.wantLimited {
+				t.Errorf("richieste limitate = %v, atteso %v", limited, tt.wantLimited)
+			}
+		})
+	}
+}
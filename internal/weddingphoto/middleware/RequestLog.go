@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader è l'header con l'identificativo della richiesta, accettato dal client o dal proxy
+	// e restituito nella risposta
+	RequestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLength limita la lunghezza degli identificativi ricevuti, che finiscono nei log e nella coda
+	maxRequestIDLength = 128
+)
+
+// RequestID assegna a ogni richiesta un identificativo, riusando quello dell'header X-Request-ID se
+// valido, e lo inserisce nel context della richiesta perché compaia nei log di service e worker
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = logging.NewRequestID()
+		}
+
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// RequestLogger registra una riga di log per ogni richiesta completata, con livello error per gli
+// errori del server e warn per quelli del client
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		slog.LogAttrs(c.Request.Context(), level, "Richiesta HTTP", attrs...)
+	}
+}
+
+// Recovery intercetta i panic dei handler, li registra con lo stack e risponde con un errore interno
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		slog.ErrorContext(c.Request.Context(), "Panic nella gestione della richiesta", "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse(c, errors.New("errore interno")))
+	})
+}
+
+// validRequestID accetta identificativi non vuoti di lettere, cifre, punti, trattini e trattini bassi
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"wedding-photo-backend/internal/weddingphoto/manager"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SignedMedia verifica la firma degli URL dei file multimediali generati da UrlManager
+type SignedMedia struct {
+	urlManager *manager.UrlManager
+}
+
+// NewSignedMedia crea una nuova istanza; se la firma non è attiva tutte le richieste sono accettate
+func NewSignedMedia(urlManager *manager.UrlManager) *SignedMedia {
+	return &SignedMedia{
+		urlManager: urlManager,
+	}
+}
+
+// Require restituisce un middleware che blocca le richieste con URL non firmato, alterato o scaduto
+func (sm *SignedMedia) Require() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := sm.urlManager.VerifyMediaUrl(c.Request.URL.Path, c.Request.URL.Query()); err != nil {
+			AbortWithError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
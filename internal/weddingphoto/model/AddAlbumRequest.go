@@ -0,0 +1,9 @@
+package model
+
+// AddAlbumRequest rappresenta la richiesta per creare un album
+type AddAlbumRequest struct {
+	Title        string `json:"title" binding:"required"` // Titolo dell'album
+	Description  string `json:"description"`              // Descrizione dell'album
+	CoverPhoto   string `json:"cover_photo"`              // Nome della foto di copertina
+	GuestUploads *bool  `json:"guest_uploads"`            // Consente l'upload agli ospiti (default: true)
+}
@@ -0,0 +1,8 @@
+package model
+
+// AddCommentRequest rappresenta la richiesta per commentare una foto o rispondere a un commento
+type AddCommentRequest struct {
+	Body       string `json:"body" binding:"required"` // Testo del commento
+	AuthorName string `json:"author_name"`             // Nome dell'autore
+	ParentID   *int64 `json:"parent_id"`               // Commento a cui si risponde
+}
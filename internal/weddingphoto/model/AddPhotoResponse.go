@@ -2,5 +2,6 @@ package model
 
 // AddPhotoResponse rappresenta la risposta per l'aggiunta di una foto
 type AddPhotoResponse struct {
-	Photo Photo `json:"photo" binding:"required"` // Nome della foto aggiunta
+	Photo      Photo          `json:"photo" binding:"required"` // Nome della foto aggiunta
+	AlbumError *ErrorResponse `json:"album_error,omitempty"`    // Errore nell'aggiunta all'album indicato: la foto è comunque salvata e non va ricaricata
 }
@@ -0,0 +1,6 @@
+package model
+
+// AddReactionRequest rappresenta la richiesta per aggiungere una reazione a una foto
+type AddReactionRequest struct {
+	Type string `json:"type" binding:"required"` // Tipo di reazione (es. heart, laugh)
+}
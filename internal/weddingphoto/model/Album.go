@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Album rappresenta una raccolta curata di foto all'interno dell'evento
+type Album struct {
+	ID           int64     `json:"id" binding:"required"`            // Identificativo dell'album
+	Title        string    `json:"title" binding:"required"`         // Titolo dell'album
+	Description  string    `json:"description"`                      // Descrizione dell'album
+	CoverPhoto   *Photo    `json:"cover_photo,omitempty"`            // Foto di copertina
+	GuestUploads bool      `json:"guest_uploads" binding:"required"` // Indica se gli ospiti possono caricare foto nell'album
+	PhotoCount   int       `json:"photo_count" binding:"required"`   // Numero di foto nell'album
+	CreatedAt    time.Time `json:"created_at" binding:"required"`    // Data di creazione
+	UpdatedAt    time.Time `json:"updated_at" binding:"required"`    // Data dell'ultima modifica
+}
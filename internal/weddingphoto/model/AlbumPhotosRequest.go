@@ -0,0 +1,6 @@
+package model
+
+// AlbumPhotosRequest rappresenta la richiesta per aggiungere o riordinare le foto di un album
+type AlbumPhotosRequest struct {
+	ImageNames []string `json:"image_names" binding:"required"` // Nomi delle foto, nell'ordine desiderato
+}
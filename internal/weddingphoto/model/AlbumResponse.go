@@ -0,0 +1,6 @@
+package model
+
+// AlbumResponse rappresenta la risposta con un singolo album
+type AlbumResponse struct {
+	Album Album `json:"album" binding:"required"` // Album richiesto
+}
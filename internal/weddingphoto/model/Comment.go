@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Comment rappresenta il commento di un ospite a una foto, con le relative risposte
+type Comment struct {
+	ID         int64     `json:"id" binding:"required"`         // Identificativo del commento
+	ParentID   *int64    `json:"parent_id,omitempty"`           // Commento a cui risponde
+	AuthorName string    `json:"author_name"`                   // Nome dell'autore
+	Body       string    `json:"body" binding:"required"`       // Testo del commento
+	IsMine     bool      `json:"is_mine" binding:"required"`    // Indica se il commento è dell'ospite che lo richiede
+	CreatedAt  time.Time `json:"created_at" binding:"required"` // Data di creazione
+	Replies    []Comment `json:"replies" binding:"required"`    // Risposte al commento
+}
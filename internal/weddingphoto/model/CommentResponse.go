@@ -0,0 +1,6 @@
+package model
+
+// CommentResponse rappresenta la risposta con un singolo commento
+type CommentResponse struct {
+	Comment Comment `json:"comment" binding:"required"` // Commento salvato
+}
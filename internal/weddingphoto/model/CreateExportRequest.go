@@ -0,0 +1,8 @@
+package model
+
+// CreateExportRequest rappresenta la richiesta di un'esportazione asincrona delle foto originali
+type CreateExportRequest struct {
+	AlbumID  int64  `json:"album_id"` // Album da esportare, 0 per l'intera galleria
+	Manifest string `json:"manifest"` // Manifest da includere: json, csv o vuoto
+	Layout   string `json:"layout"`   // Struttura delle cartelle: flat (default) o uploader
+}
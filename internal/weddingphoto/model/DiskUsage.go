@@ -0,0 +1,11 @@
+package model
+
+// DiskUsage rappresenta lo spazio del disco che ospita le foto e quello occupato dai media, in byte
+type DiskUsage struct {
+	TotalBytes      uint64 `json:"total_bytes"`      // Dimensione del filesystem
+	FreeBytes       uint64 `json:"free_bytes"`       // Spazio disponibile
+	UsedBytes       uint64 `json:"used_bytes"`       // Spazio occupato, anche da altri file
+	OriginalsBytes  int64  `json:"originals_bytes"`  // Spazio occupato dagli originali
+	ThumbnailsBytes int64  `json:"thumbnails_bytes"` // Spazio occupato dalle thumbnail
+	PreviewsBytes   int64  `json:"previews_bytes"`   // Spazio occupato dalle preview
+}
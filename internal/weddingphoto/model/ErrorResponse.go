@@ -2,5 +2,7 @@ package model
 
 // ErrorResponse rappresenta la risposta per gli errori
 type ErrorResponse struct {
-	Message string `json:"message" binding:"required"` // Messaggio di errore
+	Code    string                 `json:"code" binding:"required" example:"photo_not_found"` // Codice stabile dell'errore, indipendente dalla lingua
+	Message string                 `json:"message" binding:"required"`                        // Messaggio di errore
+	Details map[string]interface{} `json:"details,omitempty"`                                 // Informazioni aggiuntive sull'errore, variabili per codice
 }
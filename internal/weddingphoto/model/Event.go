@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Event rappresenta un evento inviato in tempo reale ai client (SSE o WebSocket)
+type Event struct {
+	ID        int64      `json:"id" binding:"required"`         // Identificativo crescente, da usare come Last-Event-ID
+	Type      string     `json:"type" binding:"required"`       // Tipo: photo.added, photo.processed, photo.deleted, photo.approved, export.ready, export.failed
+	ImageName string     `json:"image_name,omitempty"`          // Nome della foto interessata, per gli eventi sulle foto
+	Photo     *Photo     `json:"photo,omitempty"`               // Foto, se già visibile nella galleria
+	Export    *ExportJob `json:"export,omitempty"`              // Esportazione, per gli eventi sulle esportazioni
+	CreatedAt time.Time  `json:"created_at" binding:"required"` // Data dell'evento
+}
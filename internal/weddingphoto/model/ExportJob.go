@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// ExportJob rappresenta un'esportazione asincrona delle foto originali
+type ExportJob struct {
+	ID          string     `json:"id" binding:"required"`     // Identificativo dell'esportazione
+	Status      string     `json:"status" binding:"required"` // Stato: pending, running, ready, failed
+	AlbumID     int64      `json:"album_id,omitempty"`        // Album esportato, assente per l'intera galleria
+	Manifest    string     `json:"manifest,omitempty"`        // Manifest incluso: json o csv
+	Layout      string     `json:"layout" binding:"required"` // Struttura delle cartelle: flat o uploader
+	Size        int64      `json:"size,omitempty"`            // Dimensione dell'archivio in byte, quando pronto
+	Error       string     `json:"error,omitempty"`           // Motivo del fallimento
+	DownloadUrl string     `json:"download_url,omitempty"`    // URL di download, quando pronto
+	CreatedAt   time.Time  `json:"created_at" binding:"required"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,7 @@
+package model
+
+// FacetValue rappresenta un valore di un filtro con il numero di foto corrispondenti
+type FacetValue struct {
+	Value string `json:"value" binding:"required"` // Valore del filtro
+	Count int    `json:"count" binding:"required"` // Numero di foto tra i risultati con questo valore
+}
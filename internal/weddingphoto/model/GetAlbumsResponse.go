@@ -0,0 +1,6 @@
+package model
+
+// GetAlbumsResponse rappresenta la risposta per il recupero degli album
+type GetAlbumsResponse struct {
+	Albums []Album `json:"albums" binding:"required"` // Lista degli album
+}
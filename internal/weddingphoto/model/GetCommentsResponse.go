@@ -0,0 +1,7 @@
+package model
+
+// GetCommentsResponse rappresenta la risposta per il recupero dei commenti di una foto
+type GetCommentsResponse struct {
+	Comments   []Comment `json:"comments" binding:"required"`    // Commenti principali, con le risposte annidate
+	TotalCount int       `json:"total_count" binding:"required"` // Numero totale di commenti, risposte incluse
+}
@@ -3,6 +3,10 @@ package model
 // GetPhotosResponse rappresenta la risposta per il recupero delle foto con paginazione
 type GetPhotosResponse struct {
 	Photos     []Photo `json:"photos" binding:"required"`      // Lista delle foto
-	Page       int     `json:"page" binding:"required"`        // Pagina corrente
+	Page       int     `json:"page" binding:"required"`        // Pagina corrente, 0 se la richiesta usa un cursore
 	TotalPages int     `json:"total_pages" binding:"required"` // Numero totale di pagine
+	TotalCount int     `json:"total_count" binding:"required"` // Numero totale di foto
+	HasMore    bool    `json:"has_more" binding:"required"`    // Indica se ci sono altre foto dopo quelle restituite
+	NextCursor string  `json:"next_cursor,omitempty"`          // Cursore da passare in after per le foto successive
+	PrevCursor string  `json:"prev_cursor,omitempty"`          // Cursore da passare in before per le foto precedenti
 }
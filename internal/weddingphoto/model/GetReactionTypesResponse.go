@@ -0,0 +1,6 @@
+package model
+
+// GetReactionTypesResponse rappresenta la risposta con i tipi di reazione ammessi
+type GetReactionTypesResponse struct {
+	ReactionTypes []ReactionType `json:"reaction_types" binding:"required"` // Tipi di reazione
+}
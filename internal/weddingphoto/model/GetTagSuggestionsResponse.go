@@ -0,0 +1,6 @@
+package model
+
+// GetTagSuggestionsResponse rappresenta la risposta per l'autocompletamento dei tag
+type GetTagSuggestionsResponse struct {
+	Suggestions []TagSuggestion `json:"suggestions" binding:"required"` // Tag suggeriti, dai più usati
+}
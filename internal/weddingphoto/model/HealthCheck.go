@@ -0,0 +1,10 @@
+package model
+
+// HealthCheck rappresenta l'esito di una verifica sullo stato dell'istanza
+type HealthCheck struct {
+	Name       string                 `json:"name" example:"redis"` // Nome della verifica: redis, media, disk o metadata
+	Status     string                 `json:"status" example:"ok"`  // ok oppure fail
+	DurationMs int64                  `json:"duration_ms"`          // Durata della verifica, in millisecondi
+	Error      string                 `json:"error,omitempty"`      // Motivo dell'esito negativo
+	Details    map[string]interface{} `json:"details,omitempty"`    // Valori misurati, ad esempio lo spazio libero
+}
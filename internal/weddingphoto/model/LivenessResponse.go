@@ -0,0 +1,7 @@
+package model
+
+// LivenessResponse indica che il processo è attivo e risponde
+type LivenessResponse struct {
+	Status        string `json:"status" example:"ok"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+}
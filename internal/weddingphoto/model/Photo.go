@@ -1,9 +1,20 @@
 package model
 
+import "time"
+
 // AddPhotoRequest rappresenta la richiesta per aggiungere una foto
 type Photo struct {
-	ImageName    string `json:"image_name" binding:"required"`    // Nome dell'immagine
-	ImageUrl     string `json:"image_url" binding:"required"`     // URL dell'immagine
-	ThumbnailUrl string `json:"thumbnail_url" binding:"required"` // URL del thumbnail
-	PreviewUrl   string `json:"preview_url" binding:"required"`   // URL dell'anteprima
+	ImageName    string         `json:"image_name" binding:"required"`    // Nome dell'immagine
+	ImageUrl     string         `json:"image_url" binding:"required"`     // URL dell'immagine
+	ThumbnailUrl string         `json:"thumbnail_url" binding:"required"` // URL del thumbnail
+	PreviewUrl   string         `json:"preview_url" binding:"required"`   // URL dell'anteprima
+	Caption      string         `json:"caption"`                          // Didascalia inserita da chi ha caricato la foto
+	UploaderName string         `json:"uploader_name"`                    // Nome di chi ha caricato la foto
+	Camera       string         `json:"camera,omitempty"`                 // Fotocamera, dai dati EXIF
+	TakenAt      *time.Time     `json:"taken_at,omitempty"`               // Data di scatto, dai dati EXIF
+	MediaType    string         `json:"media_type" binding:"required"`    // Tipo di contenuto (es. image)
+	Tags         []string       `json:"tags" binding:"required"`          // Tag liberi
+	People       []string       `json:"people" binding:"required"`        // Persone riconosciute nella foto
+	Reactions    map[string]int `json:"reactions" binding:"required"`     // Numero di reazioni per tipo
+	CommentCount int            `json:"comment_count" binding:"required"` // Numero di commenti
 }
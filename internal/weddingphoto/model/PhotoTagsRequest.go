@@ -0,0 +1,7 @@
+package model
+
+// PhotoTagsRequest rappresenta la richiesta per aggiungere tag e persone a una foto
+type PhotoTagsRequest struct {
+	Tags   []string `json:"tags"`   // Tag liberi da aggiungere
+	People []string `json:"people"` // Persone da aggiungere
+}
@@ -0,0 +1,8 @@
+package model
+
+// PhotoTagsResponse rappresenta tag e persone associate a una foto
+type PhotoTagsResponse struct {
+	ImageName string   `json:"image_name" binding:"required"` // Nome della foto
+	Tags      []string `json:"tags" binding:"required"`       // Tag liberi
+	People    []string `json:"people" binding:"required"`     // Persone riconosciute nella foto
+}
@@ -0,0 +1,10 @@
+package model
+
+// RateLimit rappresenta il limite di richieste in vigore per un budget
+type RateLimit struct {
+	Budget        string `json:"budget" binding:"required" example:"upload"` // Budget: upload o read
+	Requests      int    `json:"requests" binding:"required"`                // Richieste consentite per ospite nel periodo
+	PeriodSeconds int    `json:"period_seconds" binding:"required"`          // Durata del periodo, in secondi
+	IPRequests    int    `json:"ip_requests" binding:"required"`             // Richieste consentite per indirizzo IP nel periodo
+	Overridden    bool   `json:"overridden"`                                 // true se il limite è stato modificato rispetto alla configurazione
+}
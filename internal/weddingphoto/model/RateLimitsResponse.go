@@ -0,0 +1,6 @@
+package model
+
+// RateLimitsResponse rappresenta la risposta con i limiti di richieste in vigore
+type RateLimitsResponse struct {
+	Limits []RateLimit `json:"limits" binding:"required"` // Limiti per budget
+}
@@ -0,0 +1,7 @@
+package model
+
+// ReactionType rappresenta un tipo di reazione ammesso
+type ReactionType struct {
+	Type  string `json:"type" binding:"required"`  // Identificativo della reazione
+	Emoji string `json:"emoji" binding:"required"` // Emoji da mostrare
+}
@@ -0,0 +1,7 @@
+package model
+
+// ReactionsResponse rappresenta i conteggi aggiornati delle reazioni di una foto
+type ReactionsResponse struct {
+	ImageName string         `json:"image_name" binding:"required"` // Nome della foto
+	Reactions map[string]int `json:"reactions" binding:"required"`  // Numero di reazioni per tipo
+}
@@ -0,0 +1,7 @@
+package model
+
+// ReadinessResponse rappresenta lo stato di prontezza dell'istanza a ricevere richieste
+type ReadinessResponse struct {
+	Status string        `json:"status" example:"ready"` // ready, not_ready oppure shutting_down
+	Checks []HealthCheck `json:"checks,omitempty"`       // Esito delle singole verifiche, assente durante l'arresto
+}
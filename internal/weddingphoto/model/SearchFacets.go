@@ -0,0 +1,9 @@
+package model
+
+// SearchFacets raccoglie i conteggi per filtro calcolati sui risultati di una ricerca
+type SearchFacets struct {
+	Uploaders  []FacetValue `json:"uploaders" binding:"required"`   // Autori delle foto
+	Cameras    []FacetValue `json:"cameras" binding:"required"`     // Fotocamere
+	MediaTypes []FacetValue `json:"media_types" binding:"required"` // Tipi di contenuto
+	Tags       []FacetValue `json:"tags" binding:"required"`        // Tag liberi
+}
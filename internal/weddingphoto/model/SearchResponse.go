@@ -0,0 +1,10 @@
+package model
+
+// SearchResponse rappresenta la risposta di una ricerca di foto con paginazione e filtri
+type SearchResponse struct {
+	Photos     []Photo      `json:"photos" binding:"required"`      // Foto trovate nella pagina corrente
+	Page       int          `json:"page" binding:"required"`        // Pagina corrente
+	TotalPages int          `json:"total_pages" binding:"required"` // Numero totale di pagine
+	TotalCount int          `json:"total_count" binding:"required"` // Numero totale di foto trovate
+	Facets     SearchFacets `json:"facets" binding:"required"`      // Conteggi per filtro su tutti i risultati
+}
@@ -0,0 +1,7 @@
+package model
+
+// SlideshowControlRequest rappresenta un comando inviato allo slideshow
+type SlideshowControlRequest struct {
+	Action    string `json:"action" binding:"required"` // Comando: pause, resume, skip, pin, unpin, hide, unhide
+	ImageName string `json:"image_name"`                // Foto per pin, unpin, hide e unhide; se assente la foto corrente
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// SlideshowResponse rappresenta lo stato dello slideshow condiviso da tutti gli schermi
+type SlideshowResponse struct {
+	Paused        bool       `json:"paused" binding:"required"`         // Slideshow in pausa
+	SlideDuration int        `json:"slide_duration" binding:"required"` // Durata di ogni slide, in secondi
+	PinEvery      int        `json:"pin_every" binding:"required"`      // Ogni quante slide viene mostrata una foto fissata
+	RepeatWindow  int        `json:"repeat_window" binding:"required"`  // Numero di slide entro cui una foto non viene ripetuta
+	Current       *Photo     `json:"current,omitempty"`                 // Foto da mostrare, assente se non ci sono foto
+	StartedAt     *time.Time `json:"started_at,omitempty"`              // Inizio della slide corrente
+	RemainingMs   int64      `json:"remaining_ms" binding:"required"`   // Millisecondi al cambio di slide
+	Upcoming      []Photo    `json:"upcoming" binding:"required"`       // Prossime foto previste
+	Pinned        []string   `json:"pinned" binding:"required"`         // Foto fissate
+	Hidden        []string   `json:"hidden" binding:"required"`         // Foto escluse dallo slideshow
+}
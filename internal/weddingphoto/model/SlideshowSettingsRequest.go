@@ -0,0 +1,9 @@
+package model
+
+// SlideshowSettingsRequest rappresenta la richiesta per modificare le impostazioni dello slideshow,
+// i campi assenti non vengono modificati
+type SlideshowSettingsRequest struct {
+	SlideDuration *int `json:"slide_duration"` // Durata di ogni slide, in secondi
+	PinEvery      *int `json:"pin_every"`      // Ogni quante slide viene mostrata una foto fissata, 0 per non mostrarle
+	RepeatWindow  *int `json:"repeat_window"`  // Numero di slide entro cui una foto non viene ripetuta
+}
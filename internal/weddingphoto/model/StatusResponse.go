@@ -0,0 +1,14 @@
+package model
+
+// StatusResponse rappresenta lo stato dell'istanza mostrato agli amministratori
+type StatusResponse struct {
+	Status            string           `json:"status" example:"ready"` // Stato di prontezza, come in /readyz
+	UptimeSeconds     int64            `json:"uptime_seconds"`
+	QueueLength       int64            `json:"queue_length"`          // Immagini in coda di elaborazione, -1 se la coda non è raggiungibile
+	QueueLanes        map[string]int64 `json:"queue_lanes,omitempty"` // Immagini in coda per corsia: interactive, bulk, background
+	Workers           []string         `json:"workers"`               // Worker in background attivi in questa istanza
+	WorkerCount       int              `json:"worker_count"`          // Numero di worker attivi
+	PendingRenditions int              `json:"pending_renditions"`    // Foto di cui thumbnail e preview non sono ancora pronte
+	Disk              *DiskUsage       `json:"disk,omitempty"`        // Spazio su disco, assente se non disponibile
+	Checks            []HealthCheck    `json:"checks"`                // Esito delle verifiche di prontezza
+}
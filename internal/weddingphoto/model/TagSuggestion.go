@@ -0,0 +1,8 @@
+package model
+
+// TagSuggestion rappresenta un tag esistente proposto per l'autocompletamento
+type TagSuggestion struct {
+	Name       string `json:"name" binding:"required"`        // Nome del tag
+	Kind       string `json:"kind" binding:"required"`        // Tipo: tag o person
+	PhotoCount int    `json:"photo_count" binding:"required"` // Numero di foto con il tag
+}
@@ -0,0 +1,9 @@
+package model
+
+// UpdateAlbumRequest rappresenta la richiesta per modificare un album, i campi assenti non vengono modificati
+type UpdateAlbumRequest struct {
+	Title        *string `json:"title"`         // Titolo dell'album
+	Description  *string `json:"description"`   // Descrizione dell'album
+	CoverPhoto   *string `json:"cover_photo"`   // Nome della foto di copertina, vuoto per usare la prima foto
+	GuestUploads *bool   `json:"guest_uploads"` // Consente l'upload agli ospiti
+}
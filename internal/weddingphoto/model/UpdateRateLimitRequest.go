@@ -0,0 +1,7 @@
+package model
+
+// UpdateRateLimitRequest rappresenta la richiesta per modificare il limite di un budget
+type UpdateRateLimitRequest struct {
+	Requests      int `json:"requests" binding:"required,min=1"`       // Richieste consentite per ospite nel periodo
+	PeriodSeconds int `json:"period_seconds" binding:"required,min=1"` // Durata del periodo, in secondi
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/model"
+)
+
+var (
+	// ErrAlbumNotFound indica che l'album richiesto non esiste
+	ErrAlbumNotFound = apperror.New(apperror.KindNotFound, "album_not_found", "album non trovato")
+	// ErrAlbumPhotoNotFound indica che la foto non fa parte dell'album
+	ErrAlbumPhotoNotFound = apperror.New(apperror.KindNotFound, "album_photo_not_found", "la foto non fa parte dell'album")
+	// ErrAlbumUploadsDisabled indica che l'album non accetta upload dagli ospiti
+	ErrAlbumUploadsDisabled = apperror.New(apperror.KindForbidden, "album_uploads_disabled", "l'album non accetta upload dagli ospiti")
+	// ErrAlbumTitleRequired indica che il titolo dell'album è vuoto
+	ErrAlbumTitleRequired = apperror.New(apperror.KindInvalid, "album_title_required", "il titolo dell'album è obbligatorio")
+	// ErrPhotoNotFound indica che la foto richiesta non esiste
+	ErrPhotoNotFound = apperror.New(apperror.KindNotFound, "photo_not_found", "foto non trovata")
+)
+
+// AlbumService gestisce la logica di business per gli album
+type AlbumService struct {
+	albumManager *manager.AlbumManager
+	photoService *PhotoService
+}
+
+// NewAlbumService crea una nuova istanza del service
+func NewAlbumService(albumManager *manager.AlbumManager, photoService *PhotoService) *AlbumService {
+	return &AlbumService{
+		albumManager: albumManager,
+		photoService: photoService,
+	}
+}
+
+// GetAlbums restituisce tutti gli album
+func (as *AlbumService) GetAlbums() ([]model.Album, error) {
+	records, err := as.albumManager.GetAlbums()
+	if err != nil {
+		return nil, err
+	}
+
+	albums := []model.Album{}
+	for i := range records {
+		album, err := as.toAlbum(&records[i])
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, *album)
+	}
+	return albums, nil
+}
+
+// GetAlbum restituisce un singolo album
+func (as *AlbumService) GetAlbum(id int64) (*model.Album, error) {
+	record, err := as.getRecord(id)
+	if err != nil {
+		return nil, err
+	}
+	return as.toAlbum(record)
+}
+
+// CreateAlbum crea un nuovo album
+func (as *AlbumService) CreateAlbum(request model.AddAlbumRequest) (*model.Album, error) {
+	title := strings.TrimSpace(request.Title)
+	if title == "" {
+		return nil, ErrAlbumTitleRequired
+	}
+	if request.CoverPhoto != "" && !as.photoService.PhotoExists(request.CoverPhoto) {
+		return nil, ErrPhotoNotFound
+	}
+
+	guestUploads := true
+	if request.GuestUploads != nil {
+		guestUploads = *request.GuestUploads
+	}
+
+	id, err := as.albumManager.CreateAlbum(title, strings.TrimSpace(request.Description), request.CoverPhoto, guestUploads)
+	if err != nil {
+		return nil, err
+	}
+
+	// La copertina fa parte dell'album anche se non è stata aggiunta esplicitamente
+	if request.CoverPhoto != "" {
+		if err := as.albumManager.AddPhotosToAlbum(id, []string{request.CoverPhoto}); err != nil {
+			return nil, err
+		}
+	}
+
+	return as.GetAlbum(id)
+}
+
+// UpdateAlbum modifica i campi indicati di un album
+func (as *AlbumService) UpdateAlbum(id int64, request model.UpdateAlbumRequest) (*model.Album, error) {
+	record, err := as.getRecord(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Title != nil {
+		title := strings.TrimSpace(*request.Title)
+		if title == "" {
+			return nil, ErrAlbumTitleRequired
+		}
+		record.Title = title
+	}
+	if request.Description != nil {
+		record.Description = strings.TrimSpace(*request.Description)
+	}
+	if request.GuestUploads != nil {
+		record.GuestUploads = *request.GuestUploads
+	}
+	if request.CoverPhoto != nil {
+		if *request.CoverPhoto != "" && !as.photoService.PhotoExists(*request.CoverPhoto) {
+			return nil, ErrPhotoNotFound
+		}
+		record.CoverPhoto = *request.CoverPhoto
+	}
+
+	if err := as.albumManager.UpdateAlbum(record); err != nil {
+		return nil, err
+	}
+
+	if record.CoverPhoto != "" {
+		if err := as.albumManager.AddPhotosToAlbum(id, []string{record.CoverPhoto}); err != nil {
+			return nil, err
+		}
+	}
+
+	return as.GetAlbum(id)
+}
+
+// DeleteAlbum elimina un album senza eliminare le foto
+func (as *AlbumService) DeleteAlbum(id int64) error {
+	if _, err := as.getRecord(id); err != nil {
+		return err
+	}
+	return as.albumManager.DeleteAlbum(id)
+}
+
+// GetAlbumPhotos restituisce le foto di un album nell'ordine dell'album, per pagina o a partire dai
+// cursori after e before come la galleria. I cursori si basano sulla posizione nell'album, quindi
+// restano validi quando vengono aggiunte foto in coda
+func (as *AlbumService) GetAlbumPhotos(id int64, page, perPage int, after, before string) (*model.GetPhotosResponse, error) {
+	if _, err := as.getRecord(id); err != nil {
+		return nil, err
+	}
+
+	imageNames, err := as.albumManager.GetAlbumPhotoNames(id)
+	if err != nil {
+		return nil, err
+	}
+	positions, err := as.albumManager.GetAlbumPhotoPositions(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// La posizione cambia segno perché la paginazione procede per valori decrescenti
+	return paginateByKey(as.photoService.GetPhotosByName(imageNames), page, perPage, SortAlbum, func(photo model.Photo) int {
+		return -positions[photo.ImageName]
+	}, after, before)
+}
+
+// GetAlbumPhotoNames restituisce i nomi di tutte le foto di un album, nell'ordine dell'album
+func (as *AlbumService) GetAlbumPhotoNames(id int64) ([]string, error) {
+	if _, err := as.getRecord(id); err != nil {
+		return nil, err
+	}
+	return as.albumManager.GetAlbumPhotoNames(id)
+}
+
+// AddPhotos aggiunge foto già caricate a un album
+func (as *AlbumService) AddPhotos(id int64, imageNames []string) error {
+	if _, err := as.getRecord(id); err != nil {
+		return err
+	}
+
+	for _, imageName := range imageNames {
+		if !as.photoService.PhotoExists(imageName) {
+			return apperror.WithDetails(fmt.Errorf("%w: %s", ErrPhotoNotFound, imageName), map[string]any{"image_name": imageName})
+		}
+	}
+
+	return as.albumManager.AddPhotosToAlbum(id, imageNames)
+}
+
+// AddGuestUpload aggiunge una foto appena caricata da un ospite a un album
+func (as *AlbumService) AddGuestUpload(id int64, imageName string) error {
+	return as.albumManager.AddPhotosToAlbum(id, []string{imageName})
+}
+
+// CheckGuestUploads verifica che l'album esista e accetti upload dagli ospiti
+func (as *AlbumService) CheckGuestUploads(id int64) error {
+	record, err := as.getRecord(id)
+	if err != nil {
+		return err
+	}
+	if !record.GuestUploads {
+		return ErrAlbumUploadsDisabled
+	}
+	return nil
+}
+
+// RemovePhoto rimuove una foto da un album
+func (as *AlbumService) RemovePhoto(id int64, imageName string) error {
+	record, err := as.getRecord(id)
+	if err != nil {
+		return err
+	}
+
+	removed, err := as.albumManager.RemovePhotoFromAlbum(id, imageName)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrAlbumPhotoNotFound
+	}
+
+	// Se la foto era la copertina si torna alla copertina automatica
+	if record.CoverPhoto == imageName {
+		record.CoverPhoto = ""
+		return as.albumManager.UpdateAlbum(record)
+	}
+	return nil
+}
+
+// ReorderPhotos sposta in testa all'album le foto indicate, nell'ordine indicato;
+// le foto non elencate mantengono il loro ordine relativo e seguono
+func (as *AlbumService) ReorderPhotos(id int64, imageNames []string) error {
+	if _, err := as.getRecord(id); err != nil {
+		return err
+	}
+
+	current, err := as.albumManager.GetAlbumPhotoNames(id)
+	if err != nil {
+		return err
+	}
+
+	inAlbum := make(map[string]bool, len(current))
+	for _, imageName := range current {
+		inAlbum[imageName] = true
+	}
+
+	ordered := make([]string, 0, len(current))
+	listed := make(map[string]bool, len(imageNames))
+	for _, imageName := range imageNames {
+		if !inAlbum[imageName] {
+			return apperror.WithDetails(fmt.Errorf("%w: %s", ErrAlbumPhotoNotFound, imageName), map[string]any{"image_name": imageName})
+		}
+		if listed[imageName] {
+			continue
+		}
+		listed[imageName] = true
+		ordered = append(ordered, imageName)
+	}
+	for _, imageName := range current {
+		if !listed[imageName] {
+			ordered = append(ordered, imageName)
+		}
+	}
+
+	return as.albumManager.SetAlbumPhotoOrder(id, ordered)
+}
+
+// getRecord recupera il record di un album restituendo ErrAlbumNotFound se non esiste
+func (as *AlbumService) getRecord(id int64) (*manager.AlbumRecord, error) {
+	record, err := as.albumManager.GetAlbum(id)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, ErrAlbumNotFound
+	}
+	return record, nil
+}
+
+// toAlbum converte un record in model.Album risolvendo la copertina
+func (as *AlbumService) toAlbum(record *manager.AlbumRecord) (*model.Album, error) {
+	album := &model.Album{
+		ID:           record.ID,
+		Title:        record.Title,
+		Description:  record.Description,
+		GuestUploads: record.GuestUploads,
+		PhotoCount:   record.PhotoCount,
+		CreatedAt:    record.CreatedAt,
+		UpdatedAt:    record.UpdatedAt,
+	}
+
+	// Usa la copertina scelta se è pronta, altrimenti la prima foto elaborata dell'album
+	if record.CoverPhoto != "" {
+		if covers := as.photoService.GetPhotosByName([]string{record.CoverPhoto}); len(covers) > 0 {
+			album.CoverPhoto = &covers[0]
+			return album, nil
+		}
+	}
+
+	imageNames, err := as.albumManager.GetAlbumPhotoNames(record.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, imageName := range imageNames {
+		if covers := as.photoService.GetPhotosByName([]string{imageName}); len(covers) > 0 {
+			album.CoverPhoto = &covers[0]
+			break
+		}
+	}
+
+	return album, nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/model"
+)
+
+const (
+	// MaxCommentLength è la lunghezza massima di un commento, in caratteri
+	MaxCommentLength = 1000
+	// MaxAuthorNameLength è la lunghezza massima del nome dell'autore, in caratteri
+	MaxAuthorNameLength = 50
+)
+
+var (
+	// ErrCommentNotFound indica che il commento richiesto non esiste
+	ErrCommentNotFound = apperror.New(apperror.KindNotFound, "comment_not_found", "commento non trovato")
+	// ErrCommentForbidden indica che l'ospite non può eliminare il commento
+	ErrCommentForbidden = apperror.New(apperror.KindForbidden, "comment_forbidden", "il commento può essere eliminato solo dal suo autore")
+	// ErrCommentEmpty indica un commento senza testo
+	ErrCommentEmpty = apperror.New(apperror.KindInvalid, "comment_empty", "il testo del commento è obbligatorio")
+)
+
+// CommentService gestisce la logica di business per i commenti alle foto
+type CommentService struct {
+	commentManager *manager.CommentManager
+	photoService   *PhotoService
+	contentFilter  ContentFilter
+}
+
+// NewCommentService crea una nuova istanza del service
+func NewCommentService(commentManager *manager.CommentManager, photoService *PhotoService, contentFilter ContentFilter) *CommentService {
+	return &CommentService{
+		commentManager: commentManager,
+		photoService:   photoService,
+		contentFilter:  contentFilter,
+	}
+}
+
+// GetComments restituisce i commenti di una foto organizzati in thread e il numero totale di commenti
+func (cs *CommentService) GetComments(imageName, guestToken string) ([]model.Comment, int, error) {
+	if !cs.photoService.PhotoExists(imageName) {
+		return nil, 0, ErrPhotoNotFound
+	}
+
+	records, err := cs.commentManager.GetComments(imageName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// I commenti sono in ordine cronologico, quindi ogni risposta segue il commento a cui risponde
+	children := make(map[int64][]manager.CommentRecord)
+	var roots []manager.CommentRecord
+	for _, record := range records {
+		if record.ParentID == nil {
+			roots = append(roots, record)
+		} else {
+			children[*record.ParentID] = append(children[*record.ParentID], record)
+		}
+	}
+
+	var build func(records []manager.CommentRecord) []model.Comment
+	build = func(records []manager.CommentRecord) []model.Comment {
+		comments := make([]model.Comment, 0, len(records))
+		for i := range records {
+			comment := toComment(&records[i], guestToken)
+			comment.Replies = build(children[records[i].ID])
+			comments = append(comments, comment)
+		}
+		return comments
+	}
+
+	return build(roots), len(records), nil
+}
+
+// AddComment salva il commento di un ospite, eventualmente in risposta a un altro commento della stessa foto
+func (cs *CommentService) AddComment(imageName, guestToken string, request model.AddCommentRequest) (*model.Comment, error) {
+	if !cs.photoService.PhotoExists(imageName) {
+		return nil, ErrPhotoNotFound
+	}
+
+	body := strings.TrimSpace(request.Body)
+	if body == "" {
+		return nil, ErrCommentEmpty
+	}
+	if err := checkText(cs.contentFilter, body, MaxCommentLength, fieldComment); err != nil {
+		return nil, err
+	}
+
+	authorName := strings.TrimSpace(request.AuthorName)
+	if err := checkText(cs.contentFilter, authorName, MaxAuthorNameLength, fieldName); err != nil {
+		return nil, err
+	}
+
+	if request.ParentID != nil {
+		parent, err := cs.commentManager.GetComment(*request.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil || parent.ImageName != imageName {
+			return nil, ErrCommentNotFound
+		}
+	}
+
+	record := &manager.CommentRecord{
+		ImageName:  imageName,
+		ParentID:   request.ParentID,
+		GuestToken: guestToken,
+		AuthorName: authorName,
+		Body:       body,
+		CreatedAt:  time.Now(),
+	}
+	id, err := cs.commentManager.AddComment(record)
+	if err != nil {
+		return nil, err
+	}
+	record.ID = id
+	record.CreatedAt = record.CreatedAt.UTC().Truncate(time.Second)
+
+	comment := toComment(record, guestToken)
+	return &comment, nil
+}
+
+// DeleteComment elimina un commento e le sue risposte; solo l'autore o un amministratore possono farlo
+func (cs *CommentService) DeleteComment(imageName string, id int64, guestToken string, isAdmin bool) error {
+	record, err := cs.commentManager.GetComment(id)
+	if err != nil {
+		return err
+	}
+	if record == nil || record.ImageName != imageName {
+		return ErrCommentNotFound
+	}
+	if !isAdmin && record.GuestToken != guestToken {
+		return ErrCommentForbidden
+	}
+
+	return cs.commentManager.DeleteComment(id)
+}
+
+// toComment converte un record in model.Comment senza risposte
+func toComment(record *manager.CommentRecord, guestToken string) model.Comment {
+	return model.Comment{
+		ID:         record.ID,
+		ParentID:   record.ParentID,
+		AuthorName: record.AuthorName,
+		Body:       record.Body,
+		IsMine:     guestToken != "" && record.GuestToken == guestToken,
+		CreatedAt:  record.CreatedAt,
+		Replies:    []model.Comment{},
+	}
+}
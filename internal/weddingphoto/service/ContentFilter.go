@@ -0,0 +1,85 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+)
+
+var (
+	// ErrContentRejected indica un testo rifiutato dal filtro dei contenuti
+	ErrContentRejected = apperror.New(apperror.KindInvalid, "content_rejected", "il testo contiene parole non consentite")
+	// ErrTextTooLong indica un testo più lungo del limite consentito
+	ErrTextTooLong = apperror.New(apperror.KindInvalid, "text_too_long", "testo troppo lungo")
+)
+
+// ContentFilter verifica i testi scritti dagli ospiti (didascalie, commenti) prima del salvataggio.
+// Check restituisce un errore che include ErrContentRejected se il testo non è accettabile.
+type ContentFilter interface {
+	Check(text string) error
+}
+
+// WordListFilter rifiuta i testi che contengono una delle parole indicate, senza distinzione tra maiuscole e minuscole
+type WordListFilter struct {
+	words map[string]bool
+}
+
+// NewWordListFilter crea un filtro basato su un elenco di parole vietate; con elenco vuoto accetta ogni testo
+func NewWordListFilter(words []string) *WordListFilter {
+	wordSet := make(map[string]bool, len(words))
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			wordSet[word] = true
+		}
+	}
+
+	return &WordListFilter{
+		words: wordSet,
+	}
+}
+
+// Check verifica che il testo non contenga parole vietate
+func (wf *WordListFilter) Check(text string) error {
+	if len(wf.words) == 0 {
+		return nil
+	}
+
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, token := range tokens {
+		if wf.words[token] {
+			return ErrContentRejected
+		}
+	}
+	return nil
+}
+
+// textField identifica un campo di testo inserito dagli ospiti: key è riportato nei dettagli
+// degli errori, label nel messaggio
+type textField struct {
+	key   string
+	label string
+}
+
+var (
+	fieldCaption = textField{key: "caption", label: "la didascalia"}
+	fieldName    = textField{key: "name", label: "il nome"}
+	fieldTag     = textField{key: "tag", label: "il tag"}
+	fieldComment = textField{key: "comment", label: "il commento"}
+)
+
+// checkText verifica lunghezza massima (in caratteri) e contenuto di un testo inserito da un ospite
+func checkText(filter ContentFilter, text string, maxLength int, field textField) error {
+	if utf8.RuneCountInString(text) > maxLength {
+		return apperror.WithDetails(fmt.Errorf("%w: %s supera i %d caratteri", ErrTextTooLong, field.label, maxLength),
+			map[string]any{"field": field.key, "max_length": maxLength})
+	}
+	if err := filter.Check(text); err != nil {
+		return apperror.WithDetails(fmt.Errorf("%s: %w", field.label, err), map[string]any{"field": field.key})
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/model"
+)
+
+const (
+	// EventPhotoAdded indica una nuova foto caricata e già approvata
+	EventPhotoAdded = "photo.added"
+	// EventPhotoProcessed indica che thumbnail e preview di una foto approvata sono pronte
+	EventPhotoProcessed = "photo.processed"
+	// EventPhotoDeleted indica una foto eliminata
+	EventPhotoDeleted = "photo.deleted"
+	// EventPhotoApproved indica una foto approvata da un amministratore
+	EventPhotoApproved = "photo.approved"
+	// EventExportReady indica un'esportazione asincrona pronta per il download
+	EventExportReady = "export.ready"
+	// EventExportFailed indica un'esportazione asincrona non riuscita
+	EventExportFailed = "export.failed"
+)
+
+// eventBufferSize è il numero di eventi in attesa per client oltre il quale il client viene disconnesso
+const eventBufferSize = 64
+
+// eventData è il contenuto specifico dell'evento, salvato insieme all'identificativo e al tipo
+type eventData struct {
+	ImageName string           `json:"image_name,omitempty"`
+	Photo     *model.Photo     `json:"photo,omitempty"`
+	Export    *model.ExportJob `json:"export,omitempty"`
+}
+
+// EventService distribuisce gli eventi ai client connessi a questa istanza; con Redis gli eventi
+// passano dal canale condiviso, così i client ricevono anche quelli generati dalle altre istanze dell'API
+type EventService struct {
+	eventBus    manager.EventBus
+	mu          sync.Mutex
+	subscribers map[chan model.Event]struct{}
+}
+
+// NewEventService crea una nuova istanza del service
+func NewEventService(eventBus manager.EventBus) *EventService {
+	return &EventService{
+		eventBus:    eventBus,
+		subscribers: make(map[chan model.Event]struct{}),
+	}
+}
+
+// Publish pubblica un evento relativo a una foto; photo può essere nil se la foto non è visibile
+func (es *EventService) Publish(eventType, imageName string, photo *model.Photo) {
+	es.publish(eventType, eventData{
+		ImageName: imageName,
+		Photo:     photo,
+	})
+}
+
+// PublishExport pubblica un evento relativo a un'esportazione asincrona
+func (es *EventService) PublishExport(eventType string, export *model.ExportJob) {
+	es.publish(eventType, eventData{
+		Export: export,
+	})
+}
+
+// publish pubblica l'evento registrando gli errori
+func (es *EventService) publish(eventType string, data eventData) {
+	if _, err := es.eventBus.Publish(eventType, data); err != nil {
+		// Gli eventi non sono essenziali: i client possono sempre rileggere lo stato
+		slog.Warn("Errore nella pubblicazione dell'evento", "event_type", eventType, "error", err)
+	}
+}
+
+// Run riceve gli eventi pubblicati e li inoltra ai client connessi, fino alla cancellazione del context
+func (es *EventService) Run(ctx context.Context) {
+	for record := range es.eventBus.Listen(ctx) {
+		if event, err := toEvent(record); err == nil {
+			es.broadcast(event)
+		}
+	}
+	es.CloseSubscribers()
+}
+
+// Subscribe registra un client e restituisce gli eventi conservati successivi a lastEventID
+// (nessuno se lastEventID è 0), il canale dei nuovi eventi e la funzione per annullare l'iscrizione.
+// Il canale viene chiuso se il client non riesce a stare al passo: il client deve riconnettersi.
+// Gli eventi arrivati durante la lettura dello storico possono comparire in entrambi: il client
+// deve scartare quelli con identificativo già ricevuto.
+func (es *EventService) Subscribe(lastEventID int64) ([]model.Event, <-chan model.Event, func(), error) {
+	events := make(chan model.Event, eventBufferSize)
+	es.mu.Lock()
+	es.subscribers[events] = struct{}{}
+	es.mu.Unlock()
+
+	unsubscribe := func() {
+		es.mu.Lock()
+		defer es.mu.Unlock()
+		if _, ok := es.subscribers[events]; ok {
+			delete(es.subscribers, events)
+			close(events)
+		}
+	}
+
+	backlog := []model.Event{}
+	if lastEventID > 0 {
+		records, err := es.eventBus.GetEventsAfter(lastEventID)
+		if err != nil {
+			unsubscribe()
+			return nil, nil, nil, err
+		}
+		for _, record := range records {
+			if event, err := toEvent(record); err == nil {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+
+	return backlog, events, unsubscribe, nil
+}
+
+// broadcast invia l'evento a tutti i client, disconnettendo quelli con il buffer pieno
+func (es *EventService) broadcast(event model.Event) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	for events := range es.subscribers {
+		select {
+		case events <- event:
+		default:
+			delete(es.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// CloseSubscribers disconnette tutti i client, ad esempio all'arresto del server per chiudere
+// gli stream SSE e WebSocket che altrimenti resterebbero aperti
+func (es *EventService) CloseSubscribers() {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	for events := range es.subscribers {
+		delete(es.subscribers, events)
+		close(events)
+	}
+}
+
+// toEvent converte un evento salvato nel formato inviato ai client
+func toEvent(record manager.EventRecord) (model.Event, error) {
+	var data eventData
+	if err := json.Unmarshal(record.Data, &data); err != nil {
+		slog.Error("Errore nella lettura dell'evento", "event_id", record.ID, "error", err)
+		return model.Event{}, err
+	}
+
+	return model.Event{
+		ID:        record.ID,
+		Type:      record.Type,
+		ImageName: data.ImageName,
+		Photo:     data.Photo,
+		Export:    data.Export,
+		CreatedAt: record.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/logging"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/metrics"
+	"wedding-photo-backend/internal/weddingphoto/model"
+	"wedding-photo-backend/internal/weddingphoto/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ExportManifestJSON e ExportManifestCSV sono i formati del manifest incluso nell'archivio
+	ExportManifestJSON = "json"
+	ExportManifestCSV  = "csv"
+
+	// ExportLayoutFlat mette tutte le foto nella radice dell'archivio
+	ExportLayoutFlat = "flat"
+	// ExportLayoutUploader crea una cartella per ogni ospite che ha caricato foto
+	ExportLayoutUploader = "uploader"
+
+	// Stati delle esportazioni asincrone
+	ExportStatusPending = "pending"
+	ExportStatusRunning = "running"
+	ExportStatusReady   = "ready"
+	ExportStatusFailed  = "failed"
+
+	// ExportRetention indica per quanto tempo vengono conservate le esportazioni asincrone
+	ExportRetention = 24 * time.Hour
+
+	// unknownUploaderFolder è la cartella delle foto caricate senza nome
+	unknownUploaderFolder = "Senza nome"
+)
+
+var (
+	// ErrInvalidExportOptions indica un formato di manifest o una struttura delle cartelle non previsti
+	ErrInvalidExportOptions = apperror.New(apperror.KindInvalid, "invalid_export_options", "opzioni di esportazione non valide: manifest deve essere json o csv, layout flat o uploader")
+	// ErrExportNotFound indica un'esportazione inesistente o scaduta
+	ErrExportNotFound = apperror.New(apperror.KindNotFound, "export_not_found", "esportazione non trovata")
+	// ErrExportNotReady indica un'esportazione non ancora completata
+	ErrExportNotReady = apperror.New(apperror.KindConflict, "export_not_ready", "esportazione non ancora pronta")
+)
+
+// ExportOptions raccoglie le opzioni di un'esportazione delle foto originali
+type ExportOptions struct {
+	AlbumID  int64  // Album da esportare, 0 per l'intera galleria
+	Manifest string // Manifest da includere: ExportManifestJSON, ExportManifestCSV o vuoto
+	Layout   string // Struttura delle cartelle: ExportLayoutFlat o ExportLayoutUploader
+}
+
+// Archive è un archivio ZIP pronto per essere scritto: dimensione, ETag e data sono noti
+// prima di leggere le immagini, così il download può essere ripreso da un offset
+type Archive struct {
+	Filename string
+	Size     int64
+	ETag     string
+	ModTime  time.Time
+
+	entries        []manager.ArchiveEntry
+	archiveManager *manager.ArchiveManager
+}
+
+// Write scrive l'intero archivio sul writer
+func (a *Archive) Write(w io.Writer) error {
+	return a.archiveManager.WriteArchive(w, a.entries)
+}
+
+// WriteRange scrive length byte dell'archivio a partire da offset
+func (a *Archive) WriteRange(w io.Writer, offset, length int64) error {
+	return a.archiveManager.WriteArchiveRange(w, a.entries, offset, length)
+}
+
+// manifestEntry è una riga del manifest incluso nell'archivio
+type manifestEntry struct {
+	Path         string     `json:"path"`
+	ImageName    string     `json:"image_name"`
+	OriginalName string     `json:"original_name"`
+	UploaderName string     `json:"uploader_name"`
+	Caption      string     `json:"caption"`
+	Camera       string     `json:"camera"`
+	TakenAt      *time.Time `json:"taken_at,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	Tags         []string   `json:"tags"`
+	People       []string   `json:"people"`
+	Size         int64      `json:"size"`
+}
+
+// ExportService gestisce l'esportazione delle foto originali in archivi ZIP
+type ExportService struct {
+	archiveManager       *manager.ArchiveManager
+	exportManager        *manager.ExportManager
+	photoManager         *manager.PhotoManager
+	photoMetadataManager *manager.PhotoMetadataManager
+	tagManager           *manager.TagManager
+	urlManager           *manager.UrlManager
+	albumService         *AlbumService
+	eventService         *EventService
+}
+
+// NewExportService crea una nuova istanza del service
+func NewExportService(archiveManager *manager.ArchiveManager, exportManager *manager.ExportManager, photoManager *manager.PhotoManager, photoMetadataManager *manager.PhotoMetadataManager, tagManager *manager.TagManager, urlManager *manager.UrlManager, albumService *AlbumService, eventService *EventService) *ExportService {
+	return &ExportService{
+		archiveManager:       archiveManager,
+		exportManager:        exportManager,
+		photoManager:         photoManager,
+		photoMetadataManager: photoMetadataManager,
+		tagManager:           tagManager,
+		urlManager:           urlManager,
+		albumService:         albumService,
+		eventService:         eventService,
+	}
+}
+
+// PrepareArchive raccoglie le foto da esportare e calcola dimensione ed ETag dell'archivio
+func (es *ExportService) PrepareArchive(options ExportOptions) (*Archive, error) {
+	if err := normalizeExportOptions(&options); err != nil {
+		return nil, err
+	}
+
+	var imageNames []string
+	var err error
+	filename := "foto-matrimonio.zip"
+	if options.AlbumID != 0 {
+		imageNames, err = es.albumService.GetAlbumPhotoNames(options.AlbumID)
+		filename = fmt.Sprintf("album-%d.zip", options.AlbumID)
+	} else {
+		imageNames, err = es.photoMetadataManager.GetImageNames()
+		sort.Strings(imageNames)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := es.photoMetadataManager.GetPhotos(imageNames)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := es.tagManager.GetTags(imageNames)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &Archive{
+		Filename:       filename,
+		archiveManager: es.archiveManager,
+	}
+	manifest := []manifestEntry{}
+	for _, imageName := range imageNames {
+		info, err := es.photoManager.GetPhotoInfo(imageName)
+		if err != nil {
+			// Foto eliminata nel frattempo
+			continue
+		}
+
+		record := records[imageName]
+		entryPath := imageName
+		if options.Layout == ExportLayoutUploader {
+			entryPath = path.Join(uploaderFolder(record.UploaderName), imageName)
+		}
+
+		archive.entries = append(archive.entries, manager.ArchiveEntry{
+			Path:      entryPath,
+			ImageName: imageName,
+			Size:      info.Size(),
+			Modified:  info.ModTime(),
+		})
+		if info.ModTime().After(archive.ModTime) {
+			archive.ModTime = info.ModTime()
+		}
+
+		photoTags, people := splitTags(tags[imageName])
+		manifest = append(manifest, manifestEntry{
+			Path:         entryPath,
+			ImageName:    imageName,
+			OriginalName: record.OriginalName,
+			UploaderName: record.UploaderName,
+			Caption:      record.Caption,
+			Camera:       record.Camera,
+			TakenAt:      timeOrNil(record.TakenAt),
+			CreatedAt:    timeOrNil(record.CreatedAt),
+			Tags:         photoTags,
+			People:       people,
+			Size:         info.Size(),
+		})
+	}
+
+	if options.Manifest != "" {
+		content, err := encodeManifest(manifest, options.Manifest)
+		if err != nil {
+			return nil, err
+		}
+		archive.entries = append(archive.entries, manager.ArchiveEntry{
+			Path:     "manifest." + options.Manifest,
+			Content:  content,
+			Size:     int64(len(content)),
+			Modified: archive.ModTime,
+		})
+	}
+
+	if archive.Size, err = es.archiveManager.ArchiveSize(archive.entries); err != nil {
+		return nil, err
+	}
+
+	// L'archivio cambia solo se cambiano i file inclusi o il manifest
+	hash := sha256.New()
+	for _, entry := range archive.entries {
+		fmt.Fprintf(hash, "%s\x00%d\x00%d\x00", entry.Path, entry.Size, entry.Modified.Unix())
+		hash.Write(entry.Content)
+	}
+	archive.ETag = `"` + hex.EncodeToString(hash.Sum(nil))[:32] + `"`
+
+	return archive, nil
+}
+
+// CreateExport accoda un'esportazione asincrona, per gallerie troppo grandi da scaricare in una richiesta;
+// il worker riporta nei log l'identificativo della richiesta contenuto in ctx
+func (es *ExportService) CreateExport(ctx context.Context, options ExportOptions) (*model.ExportJob, error) {
+	if err := normalizeExportOptions(&options); err != nil {
+		return nil, err
+	}
+	if options.AlbumID != 0 {
+		if _, err := es.albumService.GetAlbumPhotoNames(options.AlbumID); err != nil {
+			return nil, err
+		}
+	}
+
+	job := &manager.ExportJob{
+		Status:       ExportStatusPending,
+		AlbumID:      options.AlbumID,
+		Manifest:     options.Manifest,
+		Layout:       options.Layout,
+		RequestID:    logging.RequestID(ctx),
+		CreatedAt:    time.Now().UTC(),
+		TraceContext: tracing.Inject(ctx),
+	}
+	if err := es.exportManager.CreateJob(job); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Esportazione accodata", "export_id", job.ID, "album_id", job.AlbumID)
+	return es.toExportJob(job), nil
+}
+
+// GetExport restituisce lo stato di un'esportazione asincrona
+func (es *ExportService) GetExport(id string) (*model.ExportJob, error) {
+	job, err := es.getJob(id)
+	if err != nil {
+		return nil, err
+	}
+	return es.toExportJob(job), nil
+}
+
+// OpenExport apre l'archivio di un'esportazione completata
+func (es *ExportService) OpenExport(id string) (*os.File, *model.ExportJob, error) {
+	job, err := es.getJob(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != ExportStatusReady {
+		return nil, nil, ErrExportNotReady
+	}
+
+	file, err := os.Open(es.exportManager.FilePath(job.ID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, ErrExportNotFound
+		}
+		return nil, nil, fmt.Errorf("errore nell'apertura dell'archivio: %v", err)
+	}
+	return file, es.toExportJob(job), nil
+}
+
+// RunWorker elabora le esportazioni asincrone in coda ed elimina quelle scadute, fino alla cancellazione
+// del context; un'esportazione già iniziata viene completata prima di terminare
+func (es *ExportService) RunWorker(ctx context.Context) {
+	lastCleanup := time.Time{}
+	for ctx.Err() == nil {
+		if time.Since(lastCleanup) > time.Hour {
+			if err := es.exportManager.RemoveExpiredFiles(); err != nil {
+				slog.Error("Errore nella pulizia delle esportazioni", "error", err)
+			}
+			lastCleanup = time.Now()
+		}
+
+		job, err := es.exportManager.PopJob(ctx, 5*time.Second)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Errore nel recupero delle esportazioni", "error", err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(5 * time.Second):
+				}
+			}
+			continue
+		}
+		if job != nil {
+			es.runExport(tracing.Extract(logging.WithRequestID(ctx, job.RequestID), job.TraceContext), job)
+		}
+	}
+}
+
+// runExport scrive l'archivio di un'esportazione su disco e notifica il risultato; ctx porta
+// l'identificativo e la traccia della richiesta che ha creato l'esportazione
+func (es *ExportService) runExport(ctx context.Context, job *manager.ExportJob) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "ExportService.runExport",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.String("export.id", job.ID), attribute.Int64("export.album_id", job.AlbumID)))
+	slog.InfoContext(ctx, "Esportazione avviata", "export_id", job.ID, "album_id", job.AlbumID)
+	job.Status = ExportStatusRunning
+	if err := es.exportManager.SaveJob(job); err != nil {
+		slog.ErrorContext(ctx, "Errore nell'aggiornamento dell'esportazione", "export_id", job.ID, "error", err)
+	}
+
+	size, err := es.writeExport(job)
+	job.CompletedAt = time.Now().UTC()
+	if err != nil {
+		job.Status = ExportStatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = ExportStatusReady
+		job.Size = size
+	}
+	if err := es.exportManager.SaveJob(job); err != nil {
+		slog.ErrorContext(ctx, "Errore nell'aggiornamento dell'esportazione", "export_id", job.ID, "error", err)
+	}
+
+	var exportErr error
+	if job.Status == ExportStatusFailed {
+		exportErr = errors.New(job.Error)
+	}
+	span.SetAttributes(attribute.Int64("export.size", job.Size))
+	tracing.End(span, exportErr)
+
+	eventType, outcome := EventExportReady, metrics.OutcomeSuccess
+	if job.Status == ExportStatusFailed {
+		eventType, outcome = EventExportFailed, metrics.OutcomeError
+		slog.ErrorContext(ctx, "Esportazione non riuscita", "export_id", job.ID, "duration", time.Since(start).String(), "error", job.Error)
+	} else {
+		slog.InfoContext(ctx, "Esportazione completata", "export_id", job.ID, "duration", time.Since(start).String(), "size", job.Size)
+	}
+	metrics.ExportDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+	es.eventService.PublishExport(eventType, es.toExportJob(job))
+}
+
+// writeExport scrive l'archivio di un'esportazione e ne restituisce la dimensione
+func (es *ExportService) writeExport(job *manager.ExportJob) (int64, error) {
+	archive, err := es.PrepareArchive(ExportOptions{
+		AlbumID:  job.AlbumID,
+		Manifest: job.Manifest,
+		Layout:   job.Layout,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := es.exportManager.CreateFile(job.ID)
+	if err != nil {
+		return 0, err
+	}
+	err = archive.Write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err := es.exportManager.CompleteFile(job.ID, err == nil); err != nil {
+		return 0, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("errore nella scrittura dell'archivio: %v", err)
+	}
+	return archive.Size, nil
+}
+
+// getJob recupera un'esportazione restituendo ErrExportNotFound se non esiste
+func (es *ExportService) getJob(id string) (*manager.ExportJob, error) {
+	job, err := es.exportManager.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrExportNotFound
+	}
+	return job, nil
+}
+
+// toExportJob converte un'esportazione nel formato restituito ai client
+func (es *ExportService) toExportJob(job *manager.ExportJob) *model.ExportJob {
+	export := &model.ExportJob{
+		ID:        job.ID,
+		Status:    job.Status,
+		AlbumID:   job.AlbumID,
+		Manifest:  job.Manifest,
+		Layout:    job.Layout,
+		Size:      job.Size,
+		Error:     job.Error,
+		CreatedAt: job.CreatedAt,
+	}
+	export.CompletedAt = timeOrNil(job.CompletedAt)
+	if job.Status == ExportStatusReady {
+		export.DownloadUrl = es.urlManager.GetExportDownloadUrl(job.ID)
+	}
+	return export
+}
+
+// normalizeExportOptions applica i valori di default e verifica le opzioni
+func normalizeExportOptions(options *ExportOptions) error {
+	if options.Layout == "" {
+		options.Layout = ExportLayoutFlat
+	}
+	if options.Layout != ExportLayoutFlat && options.Layout != ExportLayoutUploader {
+		return ErrInvalidExportOptions
+	}
+	if options.Manifest != "" && options.Manifest != ExportManifestJSON && options.Manifest != ExportManifestCSV {
+		return ErrInvalidExportOptions
+	}
+	return nil
+}
+
+// uploaderFolder restituisce il nome della cartella di un ospite, senza caratteri non validi nei percorsi
+func uploaderFolder(uploaderName string) string {
+	folder := strings.Map(func(r rune) rune {
+		switch {
+		case r < 32, strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(uploaderName))
+	folder = strings.Trim(folder, ". ")
+	if folder == "" {
+		return unknownUploaderFolder
+	}
+	return folder
+}
+
+// encodeManifest codifica il manifest nel formato richiesto
+func encodeManifest(entries []manifestEntry, format string) ([]byte, error) {
+	if format == ExportManifestJSON {
+		return json.MarshalIndent(entries, "", "  ")
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Write([]string{"path", "image_name", "original_name", "uploader_name", "caption", "camera",
+		"taken_at", "created_at", "tags", "people", "size"})
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	for _, entry := range entries {
+		writer.Write([]string{entry.Path, entry.ImageName, entry.OriginalName, entry.UploaderName, entry.Caption,
+			entry.Camera, formatTime(entry.TakenAt), formatTime(entry.CreatedAt), strings.Join(entry.Tags, ";"),
+			strings.Join(entry.People, ";"), strconv.FormatInt(entry.Size, 10)})
+	}
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
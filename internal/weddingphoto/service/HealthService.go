@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/lifecycle"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/model"
+)
+
+const (
+	// ReadinessReady indica un'istanza pronta a ricevere richieste
+	ReadinessReady = "ready"
+	// ReadinessNotReady indica un'istanza con almeno una verifica non superata
+	ReadinessNotReady = "not_ready"
+	// ReadinessShuttingDown indica un'istanza in arresto, che completa le richieste in corso
+	ReadinessShuttingDown = "shutting_down"
+
+	// CheckOK e CheckFail sono gli esiti delle singole verifiche
+	CheckOK   = "ok"
+	CheckFail = "fail"
+)
+
+// HealthService verifica lo stato dell'istanza e delle sue dipendenze
+type HealthService struct {
+	photoManager         *manager.PhotoManager
+	redisManager         *manager.RedisManager
+	queue                manager.Queue
+	metadataManager      *manager.MetadataManager
+	photoMetadataManager *manager.PhotoMetadataManager
+	lifecycle            *lifecycle.Lifecycle
+	minFreeDisk          uint64
+	checkTimeout         time.Duration
+}
+
+// NewHealthService crea una nuova istanza del service; redisManager è nil nelle installazioni senza
+// Redis, minFreeDisk è lo spazio libero minimo, in byte, sotto il quale l'istanza non è pronta,
+// checkTimeout il tempo massimo di ogni verifica
+func NewHealthService(photoManager *manager.PhotoManager, redisManager *manager.RedisManager, queue manager.Queue, metadataManager *manager.MetadataManager, photoMetadataManager *manager.PhotoMetadataManager, lifecycle *lifecycle.Lifecycle, minFreeDisk uint64, checkTimeout time.Duration) *HealthService {
+	return &HealthService{
+		photoManager:         photoManager,
+		redisManager:         redisManager,
+		queue:                queue,
+		metadataManager:      metadataManager,
+		photoMetadataManager: photoMetadataManager,
+		lifecycle:            lifecycle,
+		minFreeDisk:          minFreeDisk,
+		checkTimeout:         checkTimeout,
+	}
+}
+
+// Uptime restituisce il tempo trascorso dall'avvio
+func (hs *HealthService) Uptime() time.Duration {
+	return hs.lifecycle.Uptime()
+}
+
+// Readiness esegue in parallelo le verifiche delle dipendenze e restituisce lo stato complessivo;
+// durante l'arresto l'istanza non è pronta e le verifiche non vengono eseguite
+func (hs *HealthService) Readiness(ctx context.Context) (string, []model.HealthCheck) {
+	if hs.lifecycle.Draining() {
+		return ReadinessShuttingDown, nil
+	}
+
+	type check struct {
+		name string
+		run  func(ctx context.Context) (map[string]interface{}, error)
+	}
+	var checks []check
+	// Senza Redis, con la coda nel processo, reazioni, eventi, slideshow ed esportazioni non lo usano
+	if hs.redisManager != nil {
+		checks = append(checks, check{"redis", func(ctx context.Context) (map[string]interface{}, error) {
+			return nil, hs.redisManager.Ping(ctx)
+		}})
+	}
+	checks = append(checks, []check{
+		{"queue", func(ctx context.Context) (map[string]interface{}, error) {
+			return nil, hs.queue.Ping(ctx)
+		}},
+		{"media", func(ctx context.Context) (map[string]interface{}, error) {
+			return nil, hs.photoManager.CheckWritable()
+		}},
+		{"disk", hs.checkDisk},
+		{"metadata", func(ctx context.Context) (map[string]interface{}, error) {
+			return nil, hs.metadataManager.Ping(ctx)
+		}},
+	}...)
+
+	results := make([]model.HealthCheck, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, name string, run func(ctx context.Context) (map[string]interface{}, error)) {
+			defer wg.Done()
+			results[i] = hs.runCheck(ctx, name, run)
+		}(i, check.name, check.run)
+	}
+	wg.Wait()
+
+	status := ReadinessReady
+	for _, result := range results {
+		if result.Status != CheckOK {
+			status = ReadinessNotReady
+		}
+	}
+	return status, results
+}
+
+// runCheck esegue una verifica entro il tempo massimo, che vale anche per le verifiche che non usano il context
+func (hs *HealthService) runCheck(ctx context.Context, name string, run func(ctx context.Context) (map[string]interface{}, error)) model.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, hs.checkTimeout)
+	defer cancel()
+
+	type outcome struct {
+		details map[string]interface{}
+		err     error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		details, err := run(ctx)
+		done <- outcome{details, err}
+	}()
+
+	var result outcome
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result.err = fmt.Errorf("nessuna risposta entro %s", hs.checkTimeout)
+	}
+
+	check := model.HealthCheck{
+		Name:       name,
+		Status:     CheckOK,
+		DurationMs: time.Since(start).Milliseconds(),
+		Details:    result.details,
+	}
+	if result.err != nil {
+		check.Status = CheckFail
+		check.Error = result.err.Error()
+	}
+	return check
+}
+
+// checkDisk verifica che lo spazio libero per le foto sia sopra la soglia
+func (hs *HealthService) checkDisk(ctx context.Context) (map[string]interface{}, error) {
+	usage, err := hs.photoManager.DiskUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]interface{}{"free_bytes": usage.Free, "min_free_bytes": hs.minFreeDisk}
+	if usage.Free < hs.minFreeDisk {
+		return details, fmt.Errorf("spazio libero insufficiente: %d byte disponibili, minimo %d", usage.Free, hs.minFreeDisk)
+	}
+	return details, nil
+}
+
+// Status raccoglie lo stato dell'istanza per gli amministratori; le informazioni non disponibili
+// sono segnalate dalle verifiche invece di far fallire la richiesta
+func (hs *HealthService) Status(ctx context.Context) (*model.StatusResponse, error) {
+	status, checks := hs.Readiness(ctx)
+	workers := hs.lifecycle.Workers()
+	response := &model.StatusResponse{
+		Status:        status,
+		UptimeSeconds: int64(hs.Uptime().Seconds()),
+		QueueLength:   -1,
+		Workers:       workers,
+		WorkerCount:   len(workers),
+		Checks:        checks,
+	}
+
+	// Con la coda non raggiungibile la lettura della lunghezza attenderebbe il timeout di connessione
+	if checkPassed(checks, "queue") {
+		if lanes, err := hs.queue.GetLaneLengths(); err == nil {
+			response.QueueLength = 0
+			for _, length := range lanes {
+				response.QueueLength += length
+			}
+			response.QueueLanes = lanes
+		}
+	}
+
+	pending, err := hs.photoMetadataManager.CountUnprocessed()
+	if err != nil {
+		return nil, err
+	}
+	response.PendingRenditions = pending
+
+	if usage, err := hs.photoManager.DiskUsage(); err == nil {
+		response.Disk = &model.DiskUsage{
+			TotalBytes: usage.Total,
+			FreeBytes:  usage.Free,
+			UsedBytes:  usage.Used,
+		}
+		originals, thumbnails, previews, err := hs.photoManager.MediaSizes()
+		if err != nil {
+			return nil, err
+		}
+		response.Disk.OriginalsBytes = originals
+		response.Disk.ThumbnailsBytes = thumbnails
+		response.Disk.PreviewsBytes = previews
+	}
+
+	return response, nil
+}
+
+// checkPassed indica se la verifica indicata ha avuto esito positivo
+func checkPassed(checks []model.HealthCheck, name string) bool {
+	for _, check := range checks {
+		if check.Name == name {
+			return check.Status == CheckOK
+		}
+	}
+	return false
+}
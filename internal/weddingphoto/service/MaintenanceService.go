@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/manager"
+)
+
+// ReindexResult riassume la ricostruzione dei metadati dalle foto su disco
+type ReindexResult struct {
+	Added   int // Foto su disco registrate nel database
+	Removed int // Metadati eliminati perché l'originale non è più su disco
+	Updated int // Foto di cui sono stati riletti i dati EXIF e aggiornato l'indice di ricerca
+}
+
+// RegenerateOptions seleziona le foto di cui rigenerare le versioni ridotte
+type RegenerateOptions struct {
+	ImageNames  []string  // Foto indicate per nome, tutte se vuoto
+	MissingOnly bool      // Solo thumbnail e preview mancanti
+	Since       time.Time // Solo foto caricate da questo istante, se indicato
+	Uploader    string    // Solo foto caricate da chi ha questo nome, se indicato
+}
+
+// VerifyProblem è un problema trovato nella verifica di una foto
+type VerifyProblem struct {
+	ImageName string
+	Problem   string
+}
+
+// ImportOptions contiene i dati delle foto importate da una directory
+type ImportOptions struct {
+	UploaderName string // Nome riportato come autore delle foto importate
+}
+
+// ImportFailure è un file non importato
+type ImportFailure struct {
+	Path   string
+	Reason string
+}
+
+// ImportResult riassume un'importazione da una directory
+type ImportResult struct {
+	Imported int             // Foto salvate e accodate per l'elaborazione
+	Skipped  int             // File ignorati perché non hanno l'estensione di un'immagine
+	Failed   []ImportFailure // File non importati con il motivo
+}
+
+// MaintenanceService raccoglie le operazioni di manutenzione eseguite dai sottocomandi
+type MaintenanceService struct {
+	photoManager         *manager.PhotoManager
+	photoMetadataManager *manager.PhotoMetadataManager
+	searchManager        *manager.SearchManager
+	queue                manager.Queue
+	photoService         *PhotoService
+}
+
+// NewMaintenanceService crea una nuova istanza del service
+func NewMaintenanceService(photoManager *manager.PhotoManager, photoMetadataManager *manager.PhotoMetadataManager, searchManager *manager.SearchManager, queue manager.Queue, photoService *PhotoService) *MaintenanceService {
+	return &MaintenanceService{
+		photoManager:         photoManager,
+		photoMetadataManager: photoMetadataManager,
+		searchManager:        searchManager,
+		queue:                queue,
+		photoService:         photoService,
+	}
+}
+
+// Reindex ricostruisce i metadati dalle foto su disco: registra le foto mancanti, elimina i metadati
+// delle foto non più presenti, rilegge i dati EXIF, registra come elaborate le foto con thumbnail e
+// preview pronte e aggiorna l'indice di ricerca. Didascalie, tag, commenti e album restano invariati
+func (ms *MaintenanceService) Reindex(ctx context.Context) (*ReindexResult, error) {
+	result := &ReindexResult{}
+	added, err := ms.photoService.SyncMetadata()
+	result.Added = added
+	if err != nil {
+		return result, err
+	}
+
+	imageNames, err := ms.photoMetadataManager.GetImageNames()
+	if err != nil {
+		return result, err
+	}
+	records, err := ms.photoMetadataManager.GetPhotos(imageNames)
+	if err != nil {
+		return result, err
+	}
+
+	for _, imageName := range imageNames {
+		if !ms.photoManager.PhotoExists(imageName) {
+			if err := ms.photoMetadataManager.DeletePhoto(imageName); err != nil {
+				return result, err
+			}
+			slog.InfoContext(ctx, "Metadati eliminati, originale non trovato", "image_name", imageName)
+			result.Removed++
+			continue
+		}
+
+		camera, takenAt, err := ms.photoManager.ReadExif(imageName)
+		if err != nil {
+			slog.WarnContext(ctx, "Errore nella lettura dei dati EXIF", "image_name", imageName, "error", err)
+		} else if err := ms.photoMetadataManager.UpdateExif(imageName, camera, takenAt); err != nil {
+			return result, err
+		}
+		if records[imageName].ProcessedAt.IsZero() && ms.photoService.renditionsExist(imageName) {
+			if err := ms.photoMetadataManager.MarkProcessed(imageName, time.Now()); err != nil {
+				return result, err
+			}
+		}
+		if err := ms.searchManager.IndexPhoto(imageName); err != nil {
+			return result, err
+		}
+		result.Updated++
+	}
+	return result, nil
+}
+
+// Regenerate accoda nella corsia background la rigenerazione delle versioni ridotte delle foto
+// selezionate e restituisce quante foto ha accodato
+func (ms *MaintenanceService) Regenerate(ctx context.Context, options RegenerateOptions) (int, error) {
+	imageNames := options.ImageNames
+	if len(imageNames) == 0 {
+		onDisk, err := ms.photoManager.GetPhotoList()
+		if err != nil {
+			return 0, fmt.Errorf("errore nel recupero della lista delle immagini: %v", err)
+		}
+		imageNames = onDisk
+	}
+	sort.Strings(imageNames)
+
+	var records map[string]manager.PhotoRecord
+	if !options.Since.IsZero() || options.Uploader != "" {
+		var err error
+		if records, err = ms.photoMetadataManager.GetPhotos(imageNames); err != nil {
+			return 0, err
+		}
+	}
+
+	queued := 0
+	for _, imageName := range imageNames {
+		if !ms.photoManager.PhotoExists(imageName) {
+			return queued, fmt.Errorf("%w: %s", ErrPhotoNotFound, imageName)
+		}
+		if records != nil {
+			record := records[imageName]
+			if !options.Since.IsZero() && record.CreatedAt.Before(options.Since) {
+				continue
+			}
+			if options.Uploader != "" && !strings.EqualFold(record.UploaderName, options.Uploader) {
+				continue
+			}
+		}
+
+		operations := manager.RenditionOperations
+		if options.MissingOnly {
+			if operations = ms.photoManager.MissingRenditions(imageName); len(operations) == 0 {
+				continue
+			}
+		}
+		job := manager.NewRenditionJob(ctx, imageName, manager.LaneBackground, operations)
+		if err := ms.queue.AddImageToQueue(ctx, job); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// Verify controlla che ogni originale si possa decodificare e abbia thumbnail e preview leggibili, e
+// che metadati e file su disco corrispondano; restituisce il numero di foto controllate e i problemi
+func (ms *MaintenanceService) Verify(ctx context.Context) (int, []VerifyProblem, error) {
+	onDisk, err := ms.photoManager.GetPhotoList()
+	if err != nil {
+		return 0, nil, fmt.Errorf("errore nel recupero della lista delle immagini: %v", err)
+	}
+	registered, err := ms.photoMetadataManager.GetImageNames()
+	if err != nil {
+		return 0, nil, err
+	}
+	known := make(map[string]bool, len(registered))
+	for _, imageName := range registered {
+		known[imageName] = true
+	}
+
+	var problems []VerifyProblem
+	sort.Strings(onDisk)
+	for _, imageName := range onDisk {
+		if ctx.Err() != nil {
+			return 0, problems, ctx.Err()
+		}
+		for _, problem := range ms.photoManager.VerifyPhoto(imageName) {
+			problems = append(problems, VerifyProblem{ImageName: imageName, Problem: problem})
+		}
+		if !known[imageName] {
+			problems = append(problems, VerifyProblem{ImageName: imageName, Problem: "metadati mancanti"})
+		}
+		delete(known, imageName)
+	}
+	for _, imageName := range registered {
+		if known[imageName] {
+			problems = append(problems, VerifyProblem{ImageName: imageName, Problem: "originale mancante"})
+		}
+	}
+	return len(onDisk), problems, nil
+}
+
+// Import carica le immagini di una directory e delle sue sottodirectory come se fossero state caricate
+// dagli ospiti, già approvate, accodandole nella corsia bulk per non ritardare gli upload della festa
+func (ms *MaintenanceService) Import(ctx context.Context, dir string, options ImportOptions) (*ImportResult, error) {
+	result := &ImportResult{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+		mimeType := ms.photoManager.GetMimeTypeFromExtension(entry.Name())
+		if !ms.photoManager.IsValidImageMimeType(mimeType) {
+			result.Skipped++
+			return nil
+		}
+
+		if err := ms.importFile(ctx, path, mimeType, options); err != nil {
+			slog.WarnContext(ctx, "Foto non importata", "path", path, "error", err)
+			result.Failed = append(result.Failed, ImportFailure{Path: path, Reason: err.Error()})
+			return nil
+		}
+		result.Imported++
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("errore nella lettura della directory %s: %v", dir, err)
+	}
+	return result, nil
+}
+
+// importFile carica un singolo file della directory importata
+func (ms *MaintenanceService) importFile(ctx context.Context, path, mimeType string, options ImportOptions) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	_, err = ms.photoService.AddPhoto(ctx, file, filepath.Base(path), mimeType, info.Size(), UploadDetails{
+		UploaderName: options.UploaderName,
+		Lane:         manager.LaneBulk,
+		Approved:     true,
+	})
+	return err
+}
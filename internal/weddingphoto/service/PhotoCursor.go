@@ -0,0 +1,135 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"sort"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/model"
+)
+
+// ErrInvalidCursor indica un cursore di paginazione non valido o creato con un altro ordinamento
+var ErrInvalidCursor = apperror.New(apperror.KindInvalid, "invalid_cursor", "cursore non valido")
+
+// SortAlbum indica l'ordine scelto dagli sposi per le foto di un album, usato solo nei cursori
+const SortAlbum = "album"
+
+// photoCursor identifica la posizione di una foto nella lista ordinata: il valore di ordinamento
+// e il nome della foto, che rende la posizione univoca
+type photoCursor struct {
+	Sort string `json:"s"`
+	Key  int    `json:"k"`
+	Name string `json:"n"`
+}
+
+// sortKey restituisce il valore su cui è ordinata la foto, oltre al nome
+func sortKey(photo model.Photo, sortBy string) int {
+	if sortBy == SortPopular {
+		return totalReactions(photo)
+	}
+	return 0
+}
+
+// encodeCursor restituisce il cursore opaco che punta alla foto indicata
+func encodeCursor(photo model.Photo, sortBy string) string {
+	return photoCursor{Sort: sortBy, Key: sortKey(photo, sortBy), Name: photo.ImageName}.encode()
+}
+
+// encode restituisce il cursore in forma opaca
+func (cursor photoCursor) encode() string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor legge un cursore verificando che sia stato creato con lo stesso ordinamento
+func decodeCursor(value, sortBy string) (photoCursor, error) {
+	var cursor photoCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Name == "" || cursor.Sort != sortBy {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// precedes indica se la foto, con il valore di ordinamento key, viene prima della posizione del
+// cursore nella lista ordinata (valore di ordinamento decrescente, poi nome decrescente)
+func (cursor photoCursor) precedes(photo model.Photo, key int) bool {
+	if key != cursor.Key {
+		return key > cursor.Key
+	}
+	return photo.ImageName > cursor.Name
+}
+
+// paginateByCursor restituisce la finestra di foto richiesta, a partire dalla pagina o dai cursori
+// after e before, con i dati di paginazione della risposta. Le foto devono essere già ordinate.
+func paginateByCursor(photos []model.Photo, page, perPage int, sortBy, after, before string) (*model.GetPhotosResponse, error) {
+	return paginateByKey(photos, page, perPage, sortBy, func(photo model.Photo) int {
+		return sortKey(photo, sortBy)
+	}, after, before)
+}
+
+// paginateByKey è paginateByCursor con il valore di ordinamento di ogni foto restituito da key, per
+// gli ordinamenti che non dipendono solo dalla foto come quello degli album. Le foto devono essere
+// ordinate per valore decrescente e poi per nome decrescente
+func paginateByKey(photos []model.Photo, page, perPage int, sortBy string, key func(model.Photo) int, after, before string) (*model.GetPhotosResponse, error) {
+	if after != "" && before != "" {
+		return nil, ErrInvalidCursor
+	}
+
+	var start, end int
+	switch {
+	case after != "":
+		cursor, err := decodeCursor(after, sortBy)
+		if err != nil {
+			return nil, err
+		}
+		// Prima foto successiva al cursore: le foto aggiunte nel frattempo in cima non spostano la finestra
+		start = sort.Search(len(photos), func(i int) bool {
+			return !cursor.precedes(photos[i], key(photos[i]))
+		})
+		if start < len(photos) && photos[start].ImageName == cursor.Name {
+			start++
+		}
+		end = min(start+perPage, len(photos))
+		page = 0
+	case before != "":
+		cursor, err := decodeCursor(before, sortBy)
+		if err != nil {
+			return nil, err
+		}
+		end = sort.Search(len(photos), func(i int) bool {
+			return !cursor.precedes(photos[i], key(photos[i]))
+		})
+		start = max(end-perPage, 0)
+		page = 0
+	default:
+		start = min((page-1)*perPage, len(photos))
+		end = min(start+perPage, len(photos))
+	}
+
+	paginatedPhotos := photos[start:end]
+
+	response := &model.GetPhotosResponse{
+		Photos:     paginatedPhotos,
+		Page:       page,
+		TotalPages: int(math.Ceil(float64(len(photos)) / float64(perPage))),
+		TotalCount: len(photos),
+		HasMore:    end < len(photos),
+	}
+	if len(paginatedPhotos) > 0 {
+		if end < len(photos) {
+			last := paginatedPhotos[len(paginatedPhotos)-1]
+			response.NextCursor = photoCursor{Sort: sortBy, Key: key(last), Name: last.ImageName}.encode()
+		}
+		if start > 0 {
+			first := paginatedPhotos[0]
+			response.PrevCursor = photoCursor{Sort: sortBy, Key: key(first), Name: first.ImageName}.encode()
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"wedding-photo-backend/internal/weddingphoto/model"
+)
+
+// testPhotos crea foto con il nome e il numero di reazioni indicati, nell'ordine dato
+func testPhotos(names []string, hearts ...int) []model.Photo {
+	photos := make([]model.Photo, len(names))
+	for i, name := range names {
+		photos[i] = model.Photo{ImageName: name, Reactions: map[string]int{}}
+		if i < len(hearts) {
+			photos[i].Reactions["heart"] = hearts[i]
+		}
+	}
+	return photos
+}
+
+func photoNames(photos []model.Photo) []string {
+	names := []string{}
+	for _, photo := range photos {
+		names = append(names, photo.ImageName)
+	}
+	return names
+}
+
+func TestPaginateByCursor(t *testing.T) {
+	recent := testPhotos([]string{"e", "d", "c", "b", "a"})
+	// d e b hanno le stesse reazioni: a parità vale il nome decrescente
+	popular := testPhotos([]string{"c", "d", "b", "e", "a"}, 5, 3, 3, 1, 0)
+	cursorOf := func(photos []model.Photo, name, sortBy string) string {
+		for _, photo := range photos {
+			if photo.ImageName == name {
+				return encodeCursor(photo, sortBy)
+			}
+		}
+		panic("foto " + name + " non trovata")
+	}
+
+	tests := []struct {
+		name     string
+		photos   []model.Photo
+		page     int
+		sort     string
+		after    string
+		before   string
+		want     []string
+		wantPage int
+		wantMore bool
+		wantNext bool
+		wantPrev bool
+		wantErr  bool
+	}{
+		{
+			name: "prima pagina", photos: recent, page: 1, sort: SortRecent,
+			want: []string{"e", "d"}, wantPage: 1, wantMore: true, wantNext: true,
+		},
+		{
+			name: "pagina intermedia", photos: recent, page: 2, sort: SortRecent,
+			want: []string{"c", "b"}, wantPage: 2, wantMore: true, wantNext: true, wantPrev: true,
+		},
+		{
+			name: "pagina oltre la fine", photos: recent, page: 4, sort: SortRecent,
+			want: []string{}, wantPage: 4,
+		},
+		{
+			name: "dopo il cursore", photos: recent, sort: SortRecent, after: cursorOf(recent, "d", SortRecent),
+			want: []string{"c", "b"}, wantMore: true, wantNext: true, wantPrev: true,
+		},
+		{
+			name: "dopo il cursore con nuove foto in cima", photos: testPhotos([]string{"g", "f", "e", "d", "c", "b", "a"}), sort: SortRecent,
+			after: cursorOf(recent, "d", SortRecent),
+			want:  []string{"c", "b"}, wantMore: true, wantNext: true, wantPrev: true,
+		},
+		{
+			name: "dopo il cursore di una foto eliminata", photos: testPhotos([]string{"e", "c", "b", "a"}), sort: SortRecent,
+			after: cursorOf(recent, "d", SortRecent),
+			want:  []string{"c", "b"}, wantMore: true, wantNext: true, wantPrev: true,
+		},
+		{
+			name: "ultima finestra", photos: recent, sort: SortRecent, after: cursorOf(recent, "c", SortRecent),
+			want: []string{"b", "a"}, wantPrev: true,
+		},
+		{
+			name: "prima del cursore", photos: recent, sort: SortRecent, before: cursorOf(recent, "b", SortRecent),
+			want: []string{"d", "c"}, wantMore: true, wantNext: true, wantPrev: true,
+		},
+		{
+			name: "prima del cursore vicino all'inizio", photos: recent, sort: SortRecent, before: cursorOf(recent, "d", SortRecent),
+			want: []string{"e"}, wantMore: true, wantNext: true,
+		},
+		{
+			name: "popolari dopo il cursore", photos: popular, sort: SortPopular, after: cursorOf(popular, "d", SortPopular),
+			want: []string{"b", "e"}, wantMore: true, wantNext: true, wantPrev: true,
+		},
+		{
+			name: "popolari prima del cursore", photos: popular, sort: SortPopular, before: cursorOf(popular, "b", SortPopular),
+			want: []string{"c", "d"}, wantMore: true, wantNext: true,
+		},
+		{
+			name: "after e before insieme", photos: recent, sort: SortRecent,
+			after: cursorOf(recent, "d", SortRecent), before: cursorOf(recent, "b", SortRecent), wantErr: true,
+		},
+		{name: "cursore non in base64", photos: recent, sort: SortRecent, after: "%%%", wantErr: true},
+		{name: "cursore non JSON", photos: recent, sort: SortRecent, after: "bm9u", wantErr: true},
+		{
+			name: "cursore di un altro ordinamento", photos: recent, sort: SortRecent,
+			after: cursorOf(popular, "d", SortPopular), wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := paginateByCursor(tt.photos, tt.page, 2, tt.sort, tt.after, tt.before)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Fatalf("paginateByCursor() error = %v, atteso %v", err, ErrInvalidCursor)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("paginateByCursor(): %v", err)
+			}
+
+			if got := photoNames(response.Photos); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("foto %v, attese %v", got, tt.want)
+			}
+			if response.Page != tt.wantPage {
+				t.Errorf("Page = %d, atteso %d", response.Page, tt.wantPage)
+			}
+			if response.TotalCount != len(tt.photos) || response.TotalPages != (len(tt.photos)+1)/2 {
+				t.Errorf("TotalCount = %d, TotalPages = %d con %d foto", response.TotalCount, response.TotalPages, len(tt.photos))
+			}
+			if response.HasMore != tt.wantMore {
+				t.Errorf("HasMore = %v, atteso %v", response.HasMore, tt.wantMore)
+			}
+			if (response.NextCursor != "") != tt.wantNext || (response.PrevCursor != "") != tt.wantPrev {
+				t.Errorf("NextCursor = %q, PrevCursor = %q, attesi next %v e prev %v", response.NextCursor, response.PrevCursor, tt.wantNext, tt.wantPrev)
+			}
+		})
+	}
+}
+
+// TestPaginateByCursorWalk percorre tutte le foto con next_cursor e torna indietro con prev_cursor
+func TestPaginateByCursorWalk(t *testing.T) {
+	photos := testPhotos([]string{"g", "f", "e", "d", "c", "b", "a"})
+
+	var forward []string
+	response, err := paginateByCursor(photos, 1, 3, SortRecent, "", "")
+	for err == nil {
+		forward = append(forward, photoNames(response.Photos)...)
+		if response.NextCursor == "" {
+			break
+		}
+		response, err = paginateByCursor(photos, 0, 3, SortRecent, response.NextCursor, "")
+	}
+	if err != nil {
+		t.Fatalf("paginateByCursor(): %v", err)
+	}
+	if want := photoNames(photos); !reflect.DeepEqual(forward, want) {
+		t.Fatalf("foto in avanti %v, attese %v", forward, want)
+	}
+
+	var backward []string
+	for response.PrevCursor != "" {
+		response, err = paginateByCursor(photos, 0, 3, SortRecent, "", response.PrevCursor)
+		if err != nil {
+			t.Fatalf("paginateByCursor(): %v", err)
+		}
+		backward = append(photoNames(response.Photos), backward...)
+	}
+	if want := []string{"g", "f", "e", "d", "c", "b"}; !reflect.DeepEqual(backward, want) {
+		t.Errorf("foto all'indietro %v, attese %v", backward, want)
+	}
+}
+
+// TestPaginateByKeyAlbum verifica che i cursori di un album seguano le posizioni e non il nome
+func TestPaginateByKeyAlbum(t *testing.T) {
+	positions := map[string]int{"c": 0, "a": 1, "e": 2, "b": 3}
+	key := func(photo model.Photo) int { return -positions[photo.ImageName] }
+	photos := testPhotos([]string{"c", "a", "e", "b"})
+
+	response, err := paginateByKey(photos, 1, 2, SortAlbum, key, "", "")
+	if err != nil {
+		t.Fatalf("paginateByKey(): %v", err)
+	}
+	if got := photoNames(response.Photos); !reflect.DeepEqual(got, []string{"c", "a"}) || !response.HasMore || response.TotalCount != 4 {
+		t.Fatalf("prima pagina %v, HasMore = %v, TotalCount = %d", got, response.HasMore, response.TotalCount)
+	}
+
+	// Una foto aggiunta in coda non sposta la finestra successiva
+	positions["d"] = 4
+	photos = testPhotos([]string{"c", "a", "e", "b", "d"})
+	response, err = paginateByKey(photos, 0, 2, SortAlbum, key, response.NextCursor, "")
+	if err != nil {
+		t.Fatalf("paginateByKey(): %v", err)
+	}
+	if got := photoNames(response.Photos); !reflect.DeepEqual(got, []string{"e", "b"}) || !response.HasMore {
+		t.Errorf("dopo il cursore %v, HasMore = %v, attese [e b] con altre foto", got, response.HasMore)
+	}
+
+	// Il cursore della galleria non vale per l'album
+	if _, err := paginateByKey(photos, 0, 2, SortAlbum, key, encodeCursor(photos[0], SortRecent), ""); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursore della galleria: error = %v, atteso %v", err, ErrInvalidCursor)
+	}
+}
@@ -1,44 +1,166 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"math"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/logging"
 	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/metrics"
 	"wedding-photo-backend/internal/weddingphoto/model"
+	"wedding-photo-backend/internal/weddingphoto/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// SortRecent ordina le foto dalla più recente
+	SortRecent = "recent"
+	// SortPopular ordina le foto per numero totale di reazioni
+	SortPopular = "popular"
 )
 
+// MaxCaptionLength è la lunghezza massima della didascalia di una foto, in caratteri
+const MaxCaptionLength = 300
+
+// RenditionCheckInterval indica ogni quanto vengono cercate le foto appena elaborate
+const RenditionCheckInterval = 2 * time.Second
+
+// RenditionTimeout è il tempo dopo il quale una versione ridotta non ancora generata è considerata fallita
+const RenditionTimeout = 10 * time.Minute
+
+// UploadDetails raccoglie i dati forniti dall'ospite insieme al file caricato
+type UploadDetails struct {
+	Caption      string // Didascalia della foto
+	UploaderName string // Nome di chi carica la foto
+	GuestToken   string // Token che identifica l'ospite, vuoto se assente
+	Lane         string // Corsia della coda di elaborazione, interactive se vuota
+	Approved     bool   // Approva subito la foto anche se è richiesta l'approvazione, per le importazioni
+}
+
+var (
+	// ErrInvalidSort indica un criterio di ordinamento non previsto
+	ErrInvalidSort = apperror.New(apperror.KindInvalid, "invalid_sort", "ordinamento non valido")
+	// ErrUnsupportedImage indica un file che non è un'immagine in un formato supportato
+	ErrUnsupportedImage = apperror.New(apperror.KindUnsupported, "unsupported_image", "il file non è un'immagine valida o il formato non è supportato")
+	// ErrUploadUnreadable indica un file caricato che non è stato possibile leggere
+	ErrUploadUnreadable = apperror.New(apperror.KindInvalid, "upload_unreadable", "errore nella lettura del file")
+	// ErrOriginalDownloadDisabled indica che gli originali sono scaricabili solo da amministratori e autori
+	ErrOriginalDownloadDisabled = apperror.New(apperror.KindForbidden, "original_download_disabled", "il download delle foto originali non è consentito")
+)
+
+// OriginalPhoto è un'immagine originale aperta in lettura, con il nome scelto dall'ospite
+type OriginalPhoto struct {
+	File         *os.File
+	Info         os.FileInfo
+	MimeType     string
+	DownloadName string // Nome del file caricato dall'ospite, da usare nel Content-Disposition
+}
+
+// PhotoListQuery raccoglie ordinamento e filtri per la lista delle foto
+type PhotoListQuery struct {
+	Sort     string   // Ordinamento: SortRecent o SortPopular
+	Tags     []string // Tag liberi richiesti
+	People   []string // Persone richieste
+	MatchAll bool     // Se true la foto deve avere tutti i tag e le persone richiesti, altrimenti almeno uno
+	After    string   // Cursore: restituisce le foto successive, al posto della pagina
+	Before   string   // Cursore: restituisce le foto precedenti, al posto della pagina
+}
+
 // PhotoService gestisce la logica di business per le foto
 type PhotoService struct {
-	photoManager *manager.PhotoManager
-	urlManager   *manager.UrlManager
-	queueManager *manager.QueueManager
+	photoManager         *manager.PhotoManager
+	urlManager           *manager.UrlManager
+	queue                manager.Queue
+	reactionStore        manager.ReactionStore
+	photoMetadataManager *manager.PhotoMetadataManager
+	tagManager           *manager.TagManager
+	searchManager        *manager.SearchManager
+	eventService         *EventService
+	contentFilter        ContentFilter
+	requireApproval      bool
+	allowOriginals       bool
+	// renditionFailures contiene le versioni ridotte già conteggiate come fallite, usato solo dal watcher
+	renditionFailures map[string]bool
 }
 
 // NewPhotoService crea una nuova istanza del service
-func NewPhotoService(photoManager *manager.PhotoManager, urlManager *manager.UrlManager, queueManager *manager.QueueManager) *PhotoService {
+func NewPhotoService(photoManager *manager.PhotoManager, urlManager *manager.UrlManager, queue manager.Queue, reactionStore manager.ReactionStore, photoMetadataManager *manager.PhotoMetadataManager, tagManager *manager.TagManager, searchManager *manager.SearchManager, eventService *EventService, contentFilter ContentFilter, requireApproval, allowOriginals bool) *PhotoService {
 	return &PhotoService{
-		photoManager: photoManager,
-		urlManager:   urlManager,
-		queueManager: queueManager,
+		photoManager:         photoManager,
+		urlManager:           urlManager,
+		queue:                queue,
+		reactionStore:        reactionStore,
+		photoMetadataManager: photoMetadataManager,
+		tagManager:           tagManager,
+		searchManager:        searchManager,
+		eventService:         eventService,
+		contentFilter:        contentFilter,
+		requireApproval:      requireApproval,
+		allowOriginals:       allowOriginals,
+		renditionFailures:    map[string]bool{},
 	}
 }
 
-// GetPhotoList restituisce la lista delle immagini salvate con paginazione
-func (ps *PhotoService) GetPhotoList(page, perPage int) ([]model.Photo, int, error) {
-	// Recupera la lista delle immagini dal manager
-	imageNames, err := ps.photoManager.GetPhotoList()
+// GetPhotoList restituisce la lista delle immagini salvate con paginazione per pagina o per cursore,
+// filtrata e ordinata secondo la query
+func (ps *PhotoService) GetPhotoList(page, perPage int, query PhotoListQuery) (*model.GetPhotosResponse, error) {
+	if query.Sort != SortRecent && query.Sort != SortPopular {
+		return nil, ErrInvalidSort
+	}
+
+	// Recupera la lista delle immagini dal database dei metadati, usando l'indice dei tag se richiesto
+	var imageNames []string
+	var err error
+	if labels := tagLabels(query.Tags, query.People); len(labels) > 0 {
+		imageNames, err = ps.tagManager.FindPhotos(labels, query.MatchAll)
+	} else {
+		imageNames, err = ps.photoMetadataManager.GetImageNames()
+	}
 	if err != nil {
-		return nil, 0, fmt.Errorf("errore nel recupero della lista delle immagini: %v", err)
+		return nil, fmt.Errorf("errore nel recupero della lista delle immagini: %v", err)
+	}
+
+	photos := ps.GetPhotosByName(imageNames)
+
+	// Ordina le foto per nome file in ordine decrescente (assumendo che i nomi file contengano timestamp)
+	sort.Slice(photos, func(i, j int) bool {
+		return photos[i].ImageName > photos[j].ImageName
+	})
+
+	// Le foto più apprezzate per prime, a parità di reazioni la più recente
+	if query.Sort == SortPopular {
+		sort.SliceStable(photos, func(i, j int) bool {
+			return totalReactions(photos[i]) > totalReactions(photos[j])
+		})
 	}
 
-	var photos []model.Photo
+	return paginateByCursor(photos, page, perPage, query.Sort, query.After, query.Before)
+}
+
+// GetPhotosByName restituisce le foto indicate nello stesso ordine, saltando quelle non ancora elaborate
+// o in attesa di approvazione
+func (ps *PhotoService) GetPhotosByName(imageNames []string) []model.Photo {
+	return ps.getPhotos(imageNames, false)
+}
+
+// getPhotos restituisce le foto indicate già elaborate, includendo quelle da approvare se richiesto
+func (ps *PhotoService) getPhotos(imageNames []string, includePending bool) []model.Photo {
+	photos := []model.Photo{}
 	for _, imageName := range imageNames {
 		// Verifica se thumbnail e preview esistono
-		if !ps.photoManager.ThumbnailExists(imageName) || !ps.photoManager.PreviewExists(imageName) {
+		if !ps.photoManager.ThumbnailExists(imageName) || !ps.photoManager.PreviewExists(imageName) ||
+			!ps.photoManager.PhotoExists(imageName) {
 			// Salta la foto se thumbnail o preview non esistono
 			continue
 		}
@@ -51,14 +173,194 @@ func (ps *PhotoService) GetPhotoList(page, perPage int) ([]model.Photo, int, err
 			// ImageUrl:     ps.urlManager.GetImageUrl(imageName),
 			ThumbnailUrl: thumbnailUrl,
 			PreviewUrl:   previewUrl,
+			MediaType:    mediaType(ps.photoManager.GetMimeTypeFromExtension(imageName)),
+			Tags:         []string{},
+			People:       []string{},
+			Reactions:    map[string]int{},
 		})
 	}
 
-	// Ordina le foto per nome file in ordine decrescente (assumendo che i nomi file contengano timestamp)
-	sort.Slice(photos, func(i, j int) bool {
-		return photos[i].ImageName > photos[j].ImageName
-	})
+	records, err := ps.attachMetadata(photos)
+	if err != nil {
+		slog.Error("Errore nel recupero dei metadati delle foto", "error", err)
+		if ps.requireApproval && !includePending {
+			// Senza metadati non si può sapere quali foto sono approvate: meglio non mostrarne nessuna
+			return []model.Photo{}
+		}
+	}
+	if !includePending {
+		// Le foto senza metadati sono state caricate prima della moderazione
+		visible := photos[:0]
+		for _, photo := range photos {
+			if record, ok := records[photo.ImageName]; !ok || record.CreatedAt.IsZero() || record.Approved {
+				visible = append(visible, photo)
+			}
+		}
+		photos = visible
+	}
+	ps.attachReactions(photos)
+	return photos
+}
+
+// attachMetadata aggiunge alle foto didascalia e numero di commenti e restituisce i metadati letti
+func (ps *PhotoService) attachMetadata(photos []model.Photo) (map[string]manager.PhotoRecord, error) {
+	if len(photos) == 0 {
+		return nil, nil
+	}
+
+	imageNames := make([]string, len(photos))
+	for i, photo := range photos {
+		imageNames[i] = photo.ImageName
+	}
 
+	records, err := ps.photoMetadataManager.GetPhotos(imageNames)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := ps.tagManager.GetTags(imageNames)
+	if err != nil {
+		slog.Error("Errore nel recupero dei tag delle foto", "error", err)
+		tags = map[string][]manager.TagRecord{}
+	}
+
+	for i := range photos {
+		if record, ok := records[photos[i].ImageName]; ok {
+			photos[i].Caption = record.Caption
+			photos[i].UploaderName = record.UploaderName
+			photos[i].Camera = record.Camera
+			photos[i].TakenAt = timeOrNil(record.TakenAt)
+			if record.MediaType != "" {
+				photos[i].MediaType = record.MediaType
+			}
+			photos[i].CommentCount = record.CommentCount
+		}
+		if photoTags, ok := tags[photos[i].ImageName]; ok {
+			photos[i].Tags, photos[i].People = splitTags(photoTags)
+		}
+	}
+	return records, nil
+}
+
+// attachReactions aggiunge alle foto il numero di reazioni per tipo
+func (ps *PhotoService) attachReactions(photos []model.Photo) {
+	if len(photos) == 0 {
+		return
+	}
+
+	imageNames := make([]string, len(photos))
+	for i, photo := range photos {
+		imageNames[i] = photo.ImageName
+	}
+
+	counts, err := ps.reactionStore.GetCounts(imageNames, reactionTypeList)
+	if err != nil {
+		// Le reazioni non sono essenziali per mostrare le foto
+		slog.Error("Errore nel recupero delle reazioni", "error", err)
+		return
+	}
+
+	for i := range photos {
+		if photoCounts, ok := counts[photos[i].ImageName]; ok {
+			photos[i].Reactions = photoCounts
+		}
+	}
+}
+
+// mediaType restituisce il tipo di contenuto (es. "image") dal MIME type
+func mediaType(mimeType string) string {
+	if kind, _, found := strings.Cut(mimeType, "/"); found && kind != "" {
+		return kind
+	}
+	return "image"
+}
+
+// timeOrNil restituisce nil per la data vuota, per ometterla nelle risposte JSON
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// totalReactions restituisce il numero totale di reazioni di una foto
+func totalReactions(photo model.Photo) int {
+	total := 0
+	for _, n := range photo.Reactions {
+		total += n
+	}
+	return total
+}
+
+// SyncMetadata registra nel database dei metadati le foto presenti su disco ma non ancora registrate,
+// ad esempio quelle caricate prima dell'introduzione del database
+func (ps *PhotoService) SyncMetadata() (int, error) {
+	onDisk, err := ps.photoManager.GetPhotoList()
+	if err != nil {
+		return 0, fmt.Errorf("errore nel recupero della lista delle immagini: %v", err)
+	}
+
+	registered, err := ps.photoMetadataManager.GetImageNames()
+	if err != nil {
+		return 0, err
+	}
+	known := make(map[string]bool, len(registered))
+	for _, imageName := range registered {
+		known[imageName] = true
+	}
+
+	added := 0
+	for _, imageName := range onDisk {
+		if known[imageName] {
+			continue
+		}
+
+		createdAt, err := ps.photoManager.GetPhotoModTime(imageName)
+		if err != nil {
+			return added, err
+		}
+		record := &manager.PhotoRecord{
+			ImageName:    imageName,
+			OriginalName: imageName,
+			MediaType:    mediaType(ps.photoManager.GetMimeTypeFromExtension(imageName)),
+			CreatedAt:    createdAt,
+			Approved:     true,
+		}
+		if ps.renditionsExist(imageName) {
+			record.ProcessedAt = createdAt
+		}
+		err = ps.saveMetadata(context.Background(), record)
+		if err != nil {
+			return added, err
+		}
+		added++
+	}
+
+	return added, nil
+}
+
+// saveMetadata completa il record con i dati EXIF, lo salva e aggiorna l'indice di ricerca
+func (ps *PhotoService) saveMetadata(ctx context.Context, record *manager.PhotoRecord) error {
+	camera, takenAt, err := ps.photoManager.ReadExif(record.ImageName)
+	if err != nil {
+		slog.WarnContext(ctx, "Errore nella lettura dei dati EXIF", "image_name", record.ImageName, "error", err)
+	}
+	record.Camera = camera
+	record.TakenAt = takenAt
+
+	if err := ps.photoMetadataManager.SavePhoto(record); err != nil {
+		return err
+	}
+	return ps.searchManager.IndexPhoto(record.ImageName)
+}
+
+// PhotoExists verifica se una foto è stata caricata
+func (ps *PhotoService) PhotoExists(imageName string) bool {
+	return ps.photoManager.PhotoExists(imageName)
+}
+
+// paginatePhotos restituisce la finestra di foto della pagina richiesta e il numero totale di pagine
+func paginatePhotos(photos []model.Photo, page, perPage int) ([]model.Photo, int) {
 	// Calcola il numero totale di pagine
 	totalPhotos := len(photos)
 	totalPages := int(math.Ceil(float64(totalPhotos) / float64(perPage)))
@@ -69,7 +371,7 @@ func (ps *PhotoService) GetPhotoList(page, perPage int) ([]model.Photo, int, err
 
 	// Verifica i limiti
 	if startIndex >= totalPhotos {
-		return []model.Photo{}, totalPages, nil
+		return []model.Photo{}, totalPages
 	}
 
 	if endIndex > totalPhotos {
@@ -77,53 +379,340 @@ func (ps *PhotoService) GetPhotoList(page, perPage int) ([]model.Photo, int, err
 	}
 
 	// Ritorna la finestra di foto richiesta
-	paginatedPhotos := photos[startIndex:endIndex]
-
-	return paginatedPhotos, totalPages, nil
+	return photos[startIndex:endIndex], totalPages
 }
 
-// AddPhoto salva una foto da multipart form data e aggiunge alla coda di elaborazione
-func (ps *PhotoService) AddPhoto(fileReader io.Reader, imageName string, contentType string, fileSize int64) (*model.Photo, error) {
+// AddPhoto salva una foto da multipart form data con i dati forniti dall'ospite e aggiunge alla coda di elaborazione;
+// l'identificativo della richiesta contenuto in ctx finisce nei log, nei metadati e nell'elemento della coda
+func (ps *PhotoService) AddPhoto(ctx context.Context, fileReader io.Reader, imageName string, contentType string, fileSize int64, details UploadDetails) (photo *model.Photo, err error) {
+	start := time.Now()
+	mimeLabel := metrics.MimeUnknown
+	ctx, span := tracing.Start(ctx, "PhotoService.AddPhoto", trace.WithAttributes(attribute.Int64("upload.size", fileSize)))
+	defer func() {
+		span.SetAttributes(attribute.String("upload.mime_type", mimeLabel))
+		tracing.End(span, err)
+		outcome := metrics.Outcome(err)
+		metrics.UploadsTotal.WithLabelValues(outcome, mimeLabel).Inc()
+		metrics.UploadBytesTotal.WithLabelValues(outcome, mimeLabel).Add(float64(fileSize))
+		metrics.UploadDuration.WithLabelValues(outcome, mimeLabel).Observe(time.Since(start).Seconds())
+	}()
+
+	// Verifica i testi dell'ospite prima di salvare il file
+	caption := strings.TrimSpace(details.Caption)
+	if err := checkText(ps.contentFilter, caption, MaxCaptionLength, fieldCaption); err != nil {
+		return nil, err
+	}
+	uploaderName := strings.TrimSpace(details.UploaderName)
+	if err := checkText(ps.contentFilter, uploaderName, MaxAuthorNameLength, fieldName); err != nil {
+		return nil, err
+	}
+
 	// Rileva il MIME type reale dal contenuto del file
+	_, stage := startStage(ctx, "sniff")
 	realMimeType, newReader, err := ps.photoManager.DetectMimeTypeFromBytes(fileReader)
+	stage.end(err)
 	if err != nil {
-		return nil, fmt.Errorf("errore nella lettura del file: %v", err)
+		return nil, apperror.WithDetails(fmt.Errorf("%w: %v", ErrUploadUnreadable, err), map[string]any{"reason": err.Error()})
 	}
 
 	// Verifica che il MIME type reale sia un'immagine supportata
 	if !ps.photoManager.IsValidImageMimeType(realMimeType) {
-		return nil, fmt.Errorf("il file non è un'immagine valida o il formato non è supportato")
+		mimeLabel = metrics.MimeOther
+		if realMimeType == "" {
+			return nil, ErrUnsupportedImage
+		}
+		return nil, apperror.WithDetails(ErrUnsupportedImage, map[string]any{"mime_type": realMimeType})
 	}
 
+	mimeLabel = realMimeType
+
 	// Verifica che il MIME type dichiarato corrisponda a quello reale (opzionale, per maggiore sicurezza)
 	if !ps.isImageMimeType(contentType) || !ps.mimeTypesMatch(contentType, realMimeType) {
 		// Log di warning ma usa il MIME type reale
-		fmt.Printf("Warning: MIME type dichiarato (%s) diverso da quello reale (%s)\n", contentType, realMimeType)
+		slog.WarnContext(ctx, "MIME type dichiarato diverso da quello reale", "declared_mime_type", contentType, "mime_type", realMimeType)
 	}
 
 	// Usa il MIME type reale per il salvataggio
+	_, stage = startStage(ctx, "save")
 	fileName, err := ps.photoManager.SavePhotoFromBytes(newReader, imageName, realMimeType, fileSize)
+	stage.end(err)
 	if err != nil {
 		return nil, err
 	}
 
-	// Aggiunge l'immagine alla coda di elaborazione
-	if err := ps.queueManager.AddImageToQueue(fileName); err != nil {
-		fmt.Printf("Errore nell'aggiunta dell'immagine alla coda: %v\n", err)
+	// Salva i dati forniti dall'ospite insieme ai dati EXIF e li indicizza per la ricerca
+	record := &manager.PhotoRecord{
+		ImageName:     fileName,
+		OriginalName:  imageName,
+		Caption:       caption,
+		UploaderToken: details.GuestToken,
+		UploaderName:  uploaderName,
+		MediaType:     mediaType(realMimeType),
+		CreatedAt:     time.Now(),
+		Approved:      !ps.requireApproval || details.Approved,
+		RequestID:     logging.RequestID(ctx),
+	}
+	stageCtx, stage := startStage(ctx, "metadata")
+	err = ps.saveMetadata(stageCtx, record)
+	stage.end(err)
+	if err != nil {
+		slog.ErrorContext(ctx, "Errore nel salvataggio dei metadati", "image_name", fileName, "error", err)
+		// Non restituiamo errore, il file è stato comunque salvato
+	}
+
+	// Aggiunge l'immagine alla coda di elaborazione, nella corsia degli upload salvo indicazione diversa
+	lane := details.Lane
+	if lane == "" {
+		lane = manager.LaneInteractive
+	}
+	stageCtx, stage = startStage(ctx, "enqueue")
+	err = ps.queue.AddImageToQueue(stageCtx, manager.NewRenditionJob(stageCtx, fileName, lane, manager.RenditionOperations))
+	stage.end(err)
+	if err != nil {
+		metrics.EnqueueFailuresTotal.Inc()
+		slog.ErrorContext(ctx, "Errore nell'aggiunta dell'immagine alla coda", "image_name", fileName, "error", err)
 		// Non restituiamo errore, il file è stato comunque salvato
 	}
 
 	// Crea e restituisce l'oggetto Photo con URL completo
-	photo := &model.Photo{
+	photo = &model.Photo{
 		ImageName:    fileName,
 		ImageUrl:     ps.urlManager.GetImageUrl(fileName),
 		ThumbnailUrl: ps.urlManager.GetThumbnailUrl(fileName),
 		PreviewUrl:   ps.urlManager.GetPreviewUrl(fileName),
+		Caption:      caption,
+		UploaderName: uploaderName,
+		Camera:       record.Camera,
+		TakenAt:      timeOrNil(record.TakenAt),
+		MediaType:    record.MediaType,
+		Tags:         []string{},
+		People:       []string{},
+		Reactions:    map[string]int{},
 	}
 
+	// Le foto da approvare vengono annunciate solo all'approvazione
+	if record.Approved {
+		ps.eventService.Publish(EventPhotoAdded, fileName, ps.visiblePhoto(fileName))
+	}
+
+	slog.InfoContext(ctx, "Foto caricata", "image_name", fileName, "mime_type", realMimeType, "size", fileSize, "approved", record.Approved)
 	return photo, nil
 }
 
+// ApprovePhoto rende visibile una foto in attesa di approvazione; approvare una foto già visibile non ha effetto
+func (ps *PhotoService) ApprovePhoto(imageName string) error {
+	if !ps.PhotoExists(imageName) {
+		return ErrPhotoNotFound
+	}
+
+	approved, err := ps.photoMetadataManager.SetApproved(imageName)
+	if err != nil {
+		return err
+	}
+	if approved {
+		ps.eventService.Publish(EventPhotoApproved, imageName, ps.visiblePhoto(imageName))
+	}
+	return nil
+}
+
+// OpenOriginal apre l'immagine originale di una foto. Gli amministratori e l'ospite che l'ha caricata
+// possono sempre scaricarla; gli altri ospiti solo se la foto è visibile in galleria e il download
+// degli originali è abilitato.
+func (ps *PhotoService) OpenOriginal(imageName, guestToken string, isAdmin bool) (*OriginalPhoto, error) {
+	if !ps.PhotoExists(imageName) {
+		return nil, ErrPhotoNotFound
+	}
+
+	record, err := ps.photoMetadataManager.GetPhoto(imageName)
+	if err != nil {
+		return nil, err
+	}
+	isUploader := record != nil && guestToken != "" && record.UploaderToken == guestToken
+	if !isAdmin && !isUploader {
+		// Le foto da approvare non devono risultare esistenti
+		if record != nil && !record.Approved {
+			return nil, ErrPhotoNotFound
+		}
+		if !ps.allowOriginals {
+			return nil, ErrOriginalDownloadDisabled
+		}
+	}
+
+	file, info, err := ps.photoManager.OpenPhoto(imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	downloadName := imageName
+	if record != nil {
+		downloadName = originalDownloadName(record.OriginalName, imageName)
+	}
+	return &OriginalPhoto{
+		File:         file,
+		Info:         info,
+		MimeType:     ps.photoManager.GetMimeTypeFromExtension(imageName),
+		DownloadName: downloadName,
+	}, nil
+}
+
+// originalDownloadName ricava dal nome caricato dall'ospite un nome di file sicuro,
+// con l'estensione dell'immagine salvata
+func originalDownloadName(originalName, imageName string) string {
+	name := strings.TrimSpace(filepath.Base(strings.ReplaceAll(originalName, "\\", "/")))
+	name = strings.Map(func(r rune) rune {
+		if r < 32 || r == 127 {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		return imageName
+	}
+
+	ext := filepath.Ext(imageName)
+	if !strings.EqualFold(filepath.Ext(name), ext) {
+		name += ext
+	}
+	return name
+}
+
+// GetPendingPhotos restituisce con paginazione le foto elaborate in attesa di approvazione, dalla più recente
+func (ps *PhotoService) GetPendingPhotos(page, perPage int) (*model.GetPhotosResponse, error) {
+	imageNames, err := ps.photoMetadataManager.GetPendingApprovalNames()
+	if err != nil {
+		return nil, err
+	}
+
+	return paginateByCursor(ps.getPhotos(imageNames, true), page, perPage, SortRecent, "", "")
+}
+
+// DeletePhoto elimina una foto con le sue versioni ridotte e tutti i dati collegati
+func (ps *PhotoService) DeletePhoto(imageName string) error {
+	if !ps.PhotoExists(imageName) {
+		return ErrPhotoNotFound
+	}
+
+	if err := ps.photoManager.DeletePhoto(imageName); err != nil {
+		return err
+	}
+	if err := ps.photoMetadataManager.DeletePhoto(imageName); err != nil {
+		return err
+	}
+	if err := ps.reactionStore.DeletePhoto(imageName, reactionTypeList); err != nil {
+		// Le reazioni rimaste in Redis non sono più raggiungibili senza la foto
+		slog.Error("Errore nella rimozione delle reazioni", "image_name", imageName, "error", err)
+	}
+
+	ps.eventService.Publish(EventPhotoDeleted, imageName, nil)
+	return nil
+}
+
+// RunRenditionWatcher controlla periodicamente le foto non ancora elaborate e, quando thumbnail e
+// preview sono pronte, le registra come elaborate e pubblica l'evento photo.processed, fino alla
+// cancellazione del context
+func (ps *PhotoService) RunRenditionWatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ps.checkRenditions(ctx); err != nil {
+				slog.Error("Errore nel controllo delle foto elaborate", "error", err)
+			}
+		}
+	}
+}
+
+// uploadStage è una fase dell'upload, misurata da una metrica e da uno span figlio di quello dell'upload
+type uploadStage struct {
+	name  string
+	start time.Time
+	span  trace.Span
+}
+
+// startStage inizia la misura di una fase dell'upload
+func startStage(ctx context.Context, name string) (context.Context, *uploadStage) {
+	ctx, span := tracing.Start(ctx, "upload."+name)
+	return ctx, &uploadStage{name: name, start: time.Now(), span: span}
+}
+
+// end registra la durata della fase e chiude lo span
+func (us *uploadStage) end(err error) {
+	metrics.UploadStageDuration.WithLabelValues(us.name).Observe(time.Since(us.start).Seconds())
+	tracing.End(us.span, err)
+}
+
+// checkRenditions registra come elaborate le foto di cui thumbnail e preview sono pronte; i log riportano
+// l'identificativo della richiesta di upload della foto
+func (ps *PhotoService) checkRenditions(ctx context.Context) error {
+	imageNames, err := ps.photoMetadataManager.GetUnprocessedNames()
+	if err != nil {
+		return err
+	}
+	records, err := ps.photoMetadataManager.GetPhotos(imageNames)
+	if err != nil {
+		return err
+	}
+
+	for _, imageName := range imageNames {
+		thumbnailTime, previewTime := ps.photoManager.GetRenditionModTimes(imageName)
+		record, hasRecord := records[imageName]
+		photoCtx := ctx
+		if hasRecord {
+			photoCtx = logging.WithRequestID(ctx, record.RequestID)
+		}
+		if thumbnailTime.IsZero() || previewTime.IsZero() {
+			if hasRecord && time.Since(record.CreatedAt) > RenditionTimeout {
+				ps.countRenditionFailure(photoCtx, imageName, metrics.KindThumbnail, thumbnailTime)
+				ps.countRenditionFailure(photoCtx, imageName, metrics.KindPreview, previewTime)
+			}
+			continue
+		}
+		if err := ps.photoMetadataManager.MarkProcessed(imageName, time.Now()); err != nil {
+			return err
+		}
+		if hasRecord {
+			metrics.RenditionDuration.WithLabelValues(metrics.KindThumbnail).Observe(thumbnailTime.Sub(record.CreatedAt).Seconds())
+			metrics.RenditionDuration.WithLabelValues(metrics.KindPreview).Observe(previewTime.Sub(record.CreatedAt).Seconds())
+		}
+		slog.InfoContext(photoCtx, "Thumbnail e preview pronte", "image_name", imageName)
+		delete(ps.renditionFailures, imageName+"/"+metrics.KindThumbnail)
+		delete(ps.renditionFailures, imageName+"/"+metrics.KindPreview)
+		// visiblePhoto è nil per le foto da approvare, che vengono annunciate solo all'approvazione
+		if photo := ps.visiblePhoto(imageName); photo != nil {
+			ps.eventService.Publish(EventPhotoProcessed, imageName, photo)
+		}
+	}
+	return nil
+}
+
+// countRenditionFailure conteggia una sola volta una versione ridotta mancante oltre il tempo massimo
+func (ps *PhotoService) countRenditionFailure(ctx context.Context, imageName, rendition string, modTime time.Time) {
+	key := imageName + "/" + rendition
+	if !modTime.IsZero() || ps.renditionFailures[key] {
+		return
+	}
+	ps.renditionFailures[key] = true
+	metrics.RenditionFailuresTotal.WithLabelValues(rendition).Inc()
+	slog.WarnContext(ctx, "Versione ridotta non generata entro il tempo massimo", "image_name", imageName, "rendition", rendition, "timeout", RenditionTimeout.String())
+}
+
+// renditionsExist verifica se thumbnail e preview di una foto sono state generate
+func (ps *PhotoService) renditionsExist(imageName string) bool {
+	return ps.photoManager.ThumbnailExists(imageName) && ps.photoManager.PreviewExists(imageName)
+}
+
+// visiblePhoto restituisce la foto se è elaborata e approvata, altrimenti nil
+func (ps *PhotoService) visiblePhoto(imageName string) *model.Photo {
+	if photos := ps.GetPhotosByName([]string{imageName}); len(photos) > 0 {
+		return &photos[0]
+	}
+	return nil
+}
+
 // mimeTypesMatch verifica se i MIME types sono compatibili
 func (ps *PhotoService) mimeTypesMatch(declared, real string) bool {
 	// Normalizza i MIME types
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/logging"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/metrics"
+	"wedding-photo-backend/internal/weddingphoto/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ProcessingMaxAttempts è il numero massimo di tentativi di un job di elaborazione; i job che
+// falliscono anche l'ultimo tentativo vengono conservati tra quelli non riusciti
+const ProcessingMaxAttempts = 3
+
+// ProcessingService genera le versioni ridotte delle foto prelevando i job dalla coda di elaborazione
+type ProcessingService struct {
+	queue        manager.Queue
+	photoManager *manager.PhotoManager
+}
+
+// NewProcessingService crea una nuova istanza del service
+func NewProcessingService(queue manager.Queue, photoManager *manager.PhotoManager) *ProcessingService {
+	return &ProcessingService{
+		queue:        queue,
+		photoManager: photoManager,
+	}
+}
+
+// RunWorker elabora i job della coda fino alla cancellazione del context; un job già prelevato
+// viene completato prima di terminare
+func (ps *ProcessingService) RunWorker(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := ps.queue.GetNextImageFromQueue(ctx, 5*time.Second)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Errore nel recupero dei job di elaborazione", "error", err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(5 * time.Second):
+				}
+			}
+			continue
+		}
+		if job != nil {
+			ps.process(tracing.Extract(logging.WithRequestID(context.WithoutCancel(ctx), job.RequestID), job.TraceContext), job)
+		}
+	}
+}
+
+// QueueLane è lo stato di una corsia della coda di elaborazione, o dei job non riusciti
+type QueueLane struct {
+	Name   string
+	Length int64
+	Jobs   []*manager.QueueJob // primi job in attesa, dal più vecchio
+}
+
+// InspectQueue restituisce lo stato delle corsie indicate, o di tutte le corsie e dei job non
+// riusciti, con al massimo limit job per corsia
+func (ps *ProcessingService) InspectQueue(lanes []string, limit int) ([]QueueLane, error) {
+	if len(lanes) == 0 {
+		lanes = append(append([]string(nil), manager.QueueLanes...), manager.QueueFailed)
+	}
+	result := make([]QueueLane, 0, len(lanes))
+	for _, lane := range lanes {
+		jobs, length, err := ps.queue.ListJobs(lane, limit)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, QueueLane{Name: lane, Length: length, Jobs: jobs})
+	}
+	return result, nil
+}
+
+// RequeueFailed rimette nelle loro corsie i job non riusciti, dal primo tentativo, e restituisce anche
+// quanti non sono decodificabili e restano tra i non riusciti
+func (ps *ProcessingService) RequeueFailed(ctx context.Context) (requeued, skipped int, err error) {
+	return ps.queue.RequeueFailed(ctx)
+}
+
+// PurgeQueue elimina i job in attesa nelle corsie indicate, o nei job non riusciti, e restituisce
+// quanti ne ha eliminati
+func (ps *ProcessingService) PurgeQueue(lanes []string) (int64, error) {
+	var removed int64
+	for _, lane := range lanes {
+		count, err := ps.queue.Purge(lane)
+		removed += count
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// process esegue un job di elaborazione e lo rimette nella sua corsia se fallisce prima dell'ultimo
+// tentativo; ctx porta l'identificativo e la traccia della richiesta che ha creato il job
+func (ps *ProcessingService) process(ctx context.Context, job *manager.QueueJob) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "ProcessingService.process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("photo.id", job.PhotoID),
+			attribute.String("job.type", job.Type),
+			attribute.String("job.lane", job.Lane),
+			attribute.Int("job.attempt", job.Attempt),
+		))
+
+	err := ps.generateRenditions(job)
+	tracing.End(span, err)
+	if err == nil {
+		slog.InfoContext(ctx, "Elaborazione completata", "image_name", job.PhotoID, "lane", job.Lane, "operations", job.Operations, "attempt", job.Attempt, "duration", time.Since(start).String())
+		return
+	}
+
+	if job.Attempt >= ProcessingMaxAttempts {
+		slog.ErrorContext(ctx, "Elaborazione non riuscita", "image_name", job.PhotoID, "lane", job.Lane, "attempt", job.Attempt, "error", err)
+		failed := *job
+		failed.Error = err.Error()
+		if err := ps.queue.AddFailedJob(ctx, &failed); err != nil {
+			slog.ErrorContext(ctx, "Errore nel salvataggio del job non riuscito", "image_name", job.PhotoID, "error", err)
+		}
+		return
+	}
+	slog.WarnContext(ctx, "Elaborazione non riuscita, nuovo tentativo in coda", "image_name", job.PhotoID, "lane", job.Lane, "attempt", job.Attempt, "error", err)
+	retry := *job
+	retry.Attempt++
+	retry.EnqueuedAt = time.Now().UTC()
+	if err := ps.queue.AddImageToQueue(ctx, &retry); err != nil {
+		slog.ErrorContext(ctx, "Errore nel reinserimento del job in coda", "image_name", job.PhotoID, "error", err)
+	}
+}
+
+// generateRenditions genera le versioni ridotte richieste dal job, misurando la durata di ognuna e
+// contando quelle non riuscite; se l'originale non si apre tutte le versioni richieste sono non riuscite
+func (ps *ProcessingService) generateRenditions(job *manager.QueueJob) error {
+	src, err := ps.photoManager.OpenOriginal(job.PhotoID)
+	if err != nil {
+		for _, operation := range job.Operations {
+			metrics.JobFailuresTotal.WithLabelValues(operation).Inc()
+		}
+		return err
+	}
+
+	for _, operation := range job.Operations {
+		start := time.Now()
+		err := ps.photoManager.GenerateRendition(src, job.PhotoID, operation)
+		metrics.JobDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+		if err != nil {
+			metrics.JobFailuresTotal.WithLabelValues(operation).Inc()
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/model"
+)
+
+// ErrRateLimitBudgetNotFound indica un budget di richieste inesistente
+var ErrRateLimitBudgetNotFound = apperror.New(apperror.KindNotFound, "rate_limit_budget_not_found", "budget di richieste non trovato")
+
+// RateLimitService gestisce la consultazione e la modifica dei limiti di richieste
+type RateLimitService struct {
+	rateLimitManager *manager.RateLimitManager
+}
+
+// NewRateLimitService crea una nuova istanza del service
+func NewRateLimitService(rateLimitManager *manager.RateLimitManager) *RateLimitService {
+	return &RateLimitService{
+		rateLimitManager: rateLimitManager,
+	}
+}
+
+// GetLimits restituisce i limiti in vigore per tutti i budget
+func (rs *RateLimitService) GetLimits() []model.RateLimit {
+	limits := []model.RateLimit{}
+	for _, budget := range rs.rateLimitManager.Budgets() {
+		limit, _ := rs.GetLimit(budget)
+		limits = append(limits, *limit)
+	}
+	return limits
+}
+
+// GetLimit restituisce il limite in vigore per un budget
+func (rs *RateLimitService) GetLimit(budget string) (*model.RateLimit, error) {
+	limit, overridden, ok := rs.rateLimitManager.GetLimit(budget)
+	if !ok {
+		return nil, ErrRateLimitBudgetNotFound
+	}
+	return &model.RateLimit{
+		Budget:        budget,
+		Requests:      limit.Requests,
+		PeriodSeconds: int(limit.Period / time.Second),
+		IPRequests:    limit.Requests * rs.rateLimitManager.IPMultiplier(),
+		Overridden:    overridden,
+	}, nil
+}
+
+// UpdateLimit modifica il limite di un budget per questo evento, sostituendo la configurazione
+func (rs *RateLimitService) UpdateLimit(budget string, request model.UpdateRateLimitRequest) (*model.RateLimit, error) {
+	if _, _, ok := rs.rateLimitManager.GetLimit(budget); !ok {
+		return nil, ErrRateLimitBudgetNotFound
+	}
+
+	limit := manager.RateLimit{
+		Requests: request.Requests,
+		Period:   time.Duration(request.PeriodSeconds) * time.Second,
+	}
+	if err := rs.rateLimitManager.SetOverride(budget, limit); err != nil {
+		return nil, err
+	}
+	return rs.GetLimit(budget)
+}
+
+// ResetLimit ripristina il limite di un budget definito nella configurazione
+func (rs *RateLimitService) ResetLimit(budget string) (*model.RateLimit, error) {
+	if _, _, ok := rs.rateLimitManager.GetLimit(budget); !ok {
+		return nil, ErrRateLimitBudgetNotFound
+	}
+	if err := rs.rateLimitManager.ClearOverride(budget); err != nil {
+		return nil, err
+	}
+	return rs.GetLimit(budget)
+}
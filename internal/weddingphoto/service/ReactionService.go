@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+)
+
+// ReactionPersistInterval indica ogni quanto le reazioni conservate in Redis vengono copiate nel database
+const ReactionPersistInterval = 30 * time.Second
+
+// ReactionTypes elenca i tipi di reazione ammessi con l'emoji corrispondente
+var ReactionTypes = map[string]string{
+	"heart": "❤️",
+	"laugh": "😂",
+	"wow":   "😮",
+	"cry":   "😢",
+	"clap":  "👏",
+}
+
+// reactionTypeList contiene le chiavi di ReactionTypes, usate per interrogare lo store delle reazioni
+var reactionTypeList = func() []string {
+	types := make([]string, 0, len(ReactionTypes))
+	for reactionType := range ReactionTypes {
+		types = append(types, reactionType)
+	}
+	return types
+}()
+
+// ErrInvalidReactionType indica un tipo di reazione non previsto
+var ErrInvalidReactionType = apperror.New(apperror.KindInvalid, "invalid_reaction_type", "tipo di reazione non valido")
+
+// ReactionService gestisce la logica di business per le reazioni alle foto
+type ReactionService struct {
+	reactionStore manager.ReactionStore
+	photoService  *PhotoService
+}
+
+// NewReactionService crea una nuova istanza del service
+func NewReactionService(reactionStore manager.ReactionStore, photoService *PhotoService) *ReactionService {
+	return &ReactionService{
+		reactionStore: reactionStore,
+		photoService:  photoService,
+	}
+}
+
+// AddReaction registra la reazione di un ospite e restituisce i conteggi aggiornati della foto
+func (rs *ReactionService) AddReaction(imageName, reactionType, guestToken string) (map[string]int, error) {
+	if err := rs.validate(imageName, reactionType); err != nil {
+		return nil, err
+	}
+
+	if _, err := rs.reactionStore.AddReaction(imageName, reactionType, guestToken); err != nil {
+		return nil, err
+	}
+
+	return rs.GetCounts(imageName)
+}
+
+// RemoveReaction rimuove la reazione di un ospite e restituisce i conteggi aggiornati della foto
+func (rs *ReactionService) RemoveReaction(imageName, reactionType, guestToken string) (map[string]int, error) {
+	if err := rs.validate(imageName, reactionType); err != nil {
+		return nil, err
+	}
+
+	if _, err := rs.reactionStore.RemoveReaction(imageName, reactionType, guestToken); err != nil {
+		return nil, err
+	}
+
+	return rs.GetCounts(imageName)
+}
+
+// GetCounts restituisce il numero di reazioni per tipo di una foto
+func (rs *ReactionService) GetCounts(imageName string) (map[string]int, error) {
+	counts, err := rs.reactionStore.GetCounts([]string{imageName}, reactionTypeList)
+	if err != nil {
+		return nil, err
+	}
+	if counts[imageName] == nil {
+		return map[string]int{}, nil
+	}
+	return counts[imageName], nil
+}
+
+// RestoreReactions ricarica in Redis le reazioni salvate nel database, se Redis è vuoto; senza Redis
+// le reazioni sono già nel database
+func (rs *ReactionService) RestoreReactions() {
+	if err := rs.reactionStore.RestoreIfEmpty(); err != nil {
+		slog.Error("Errore nel ripristino delle reazioni", "error", err)
+	}
+}
+
+// RunPersistence salva periodicamente le reazioni nel database, fino alla cancellazione del context
+// (con un ultimo salvataggio finale)
+func (rs *ReactionService) RunPersistence(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := rs.reactionStore.PersistDirty(reactionTypeList); err != nil {
+				slog.Error("Errore nel salvataggio delle reazioni", "error", err)
+			}
+			return
+		case <-ticker.C:
+			// Se Redis è stato svuotato nel frattempo, ricarica prima le reazioni salvate
+			if err := rs.reactionStore.RestoreIfEmpty(); err != nil {
+				slog.Error("Errore nel ripristino delle reazioni", "error", err)
+				continue
+			}
+			if err := rs.reactionStore.PersistDirty(reactionTypeList); err != nil {
+				slog.Error("Errore nel salvataggio delle reazioni", "error", err)
+			}
+		}
+	}
+}
+
+// validate verifica che il tipo di reazione sia ammesso e che la foto esista
+func (rs *ReactionService) validate(imageName, reactionType string) error {
+	if _, ok := ReactionTypes[reactionType]; !ok {
+		return ErrInvalidReactionType
+	}
+	if !rs.photoService.PhotoExists(imageName) {
+		return ErrPhotoNotFound
+	}
+	return nil
+}
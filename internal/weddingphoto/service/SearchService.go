@@ -0,0 +1,149 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/model"
+)
+
+// ErrInvalidSearchDate indica una data di ricerca in un formato non riconosciuto
+var ErrInvalidSearchDate = apperror.New(apperror.KindInvalid, "invalid_search_date", "data non valida, usare il formato AAAA-MM-GG o RFC3339")
+
+// ErrInvalidMediaType indica un tipo di contenuto non previsto
+var ErrInvalidMediaType = apperror.New(apperror.KindInvalid, "invalid_media_type", "tipo di contenuto non valido, usare image o video")
+
+// SearchQuery raccoglie i parametri di ricerca così come ricevuti dal client
+type SearchQuery struct {
+	Text      string // Testo cercato in didascalie, tag e autori
+	Uploader  string // Nome di chi ha caricato la foto
+	From      string // Data iniziale, inclusa (AAAA-MM-GG o RFC3339)
+	To        string // Data finale, inclusa se indicata come giorno (AAAA-MM-GG o RFC3339)
+	Camera    string // Fotocamera
+	MediaType string // Tipo di contenuto: image o video
+}
+
+// SearchService gestisce la ricerca delle foto
+type SearchService struct {
+	searchManager *manager.SearchManager
+	photoService  *PhotoService
+}
+
+// NewSearchService crea una nuova istanza del service
+func NewSearchService(searchManager *manager.SearchManager, photoService *PhotoService) *SearchService {
+	return &SearchService{
+		searchManager: searchManager,
+		photoService:  photoService,
+	}
+}
+
+// Search restituisce la pagina di foto che soddisfano la ricerca, il numero di pagine, il numero
+// totale di risultati e i conteggi per filtro calcolati su tutti i risultati
+func (ss *SearchService) Search(query SearchQuery, page, perPage int) ([]model.Photo, int, int, model.SearchFacets, error) {
+	params := manager.SearchParams{
+		Text:      strings.TrimSpace(query.Text),
+		Uploader:  strings.TrimSpace(query.Uploader),
+		Camera:    strings.TrimSpace(query.Camera),
+		MediaType: strings.TrimSpace(query.MediaType),
+	}
+
+	if params.MediaType != "" && params.MediaType != "image" && params.MediaType != "video" {
+		return nil, 0, 0, model.SearchFacets{}, ErrInvalidMediaType
+	}
+
+	var err error
+	if params.From, err = parseSearchDate(query.From, false); err != nil {
+		return nil, 0, 0, model.SearchFacets{}, err
+	}
+	if params.To, err = parseSearchDate(query.To, true); err != nil {
+		return nil, 0, 0, model.SearchFacets{}, err
+	}
+
+	records, err := ss.searchManager.Search(params)
+	if err != nil {
+		return nil, 0, 0, model.SearchFacets{}, err
+	}
+
+	imageNames := make([]string, len(records))
+	for i, record := range records {
+		imageNames[i] = record.ImageName
+	}
+
+	// Solo le foto già elaborate compaiono tra i risultati e nei conteggi
+	photos := ss.photoService.GetPhotosByName(imageNames)
+	facets := buildFacets(photos)
+
+	paginatedPhotos, totalPages := paginatePhotos(photos, page, perPage)
+	return paginatedPhotos, totalPages, len(photos), facets, nil
+}
+
+// parseSearchDate interpreta una data nel formato AAAA-MM-GG o RFC3339; se endOfDay è true
+// una data senza orario include l'intera giornata
+func parseSearchDate(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, ErrInvalidSearchDate
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// buildFacets conta autori, fotocamere, tipi di contenuto e tag delle foto indicate
+func buildFacets(photos []model.Photo) model.SearchFacets {
+	uploaders := map[string]int{}
+	cameras := map[string]int{}
+	mediaTypes := map[string]int{}
+	tags := map[string]int{}
+
+	for _, photo := range photos {
+		if photo.UploaderName != "" {
+			uploaders[photo.UploaderName]++
+		}
+		if photo.Camera != "" {
+			cameras[photo.Camera]++
+		}
+		mediaTypes[photo.MediaType]++
+		for _, tag := range photo.Tags {
+			tags[tag]++
+		}
+	}
+
+	return model.SearchFacets{
+		Uploaders:  facetValues(uploaders),
+		Cameras:    facetValues(cameras),
+		MediaTypes: facetValues(mediaTypes),
+		Tags:       facetValues(tags),
+	}
+}
+
+// facetValues converte i conteggi in un elenco ordinato dal valore più frequente
+func facetValues(counts map[string]int) []model.FacetValue {
+	values := make([]model.FacetValue, 0, len(counts))
+	for value, count := range counts {
+		values = append(values, model.FacetValue{
+			Value: value,
+			Count: count,
+		})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	return values
+}
@@ -0,0 +1,501 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/model"
+)
+
+const (
+	// DefaultSlideDuration è la durata di ogni slide se non configurata
+	DefaultSlideDuration = 8 * time.Second
+	// DefaultPinEvery indica ogni quante slide viene mostrata una foto fissata se non configurato
+	DefaultPinEvery = 5
+	// DefaultRepeatWindow è il numero di slide entro cui una foto non viene ripetuta se non configurato
+	DefaultRepeatWindow = 50
+	// DefaultUpcomingSlides è il numero di prossime foto restituite se non indicato
+	DefaultUpcomingSlides = 10
+
+	// MinSlideDuration e MaxSlideDuration limitano la durata configurabile delle slide
+	MinSlideDuration = 2 * time.Second
+	MaxSlideDuration = 10 * time.Minute
+
+	// slideshowLockTTL è la durata massima del lock sullo stato dello slideshow
+	slideshowLockTTL = 5 * time.Second
+)
+
+const (
+	SlideshowPause  = "pause"
+	SlideshowResume = "resume"
+	SlideshowSkip   = "skip"
+	SlideshowPin    = "pin"
+	SlideshowUnpin  = "unpin"
+	SlideshowHide   = "hide"
+	SlideshowUnhide = "unhide"
+)
+
+var (
+	// ErrInvalidSlideshowAction indica un comando dello slideshow non previsto
+	ErrInvalidSlideshowAction = apperror.New(apperror.KindInvalid, "invalid_slideshow_action", "comando dello slideshow non valido")
+	// ErrInvalidSlideshowSettings indica impostazioni dello slideshow fuori dai limiti
+	ErrInvalidSlideshowSettings = apperror.New(apperror.KindInvalid, "invalid_slideshow_settings", "impostazioni dello slideshow non valide")
+	// ErrSlideshowBusy indica che lo stato dello slideshow è in modifica da parte di un'altra richiesta
+	ErrSlideshowBusy = apperror.New(apperror.KindConflict, "slideshow_busy", "slideshow occupato, riprovare")
+)
+
+// SlideshowService gestisce lo slideshow proiettato durante il ricevimento: la sequenza di foto,
+// calcolata a turno dalle richieste degli schermi, e i comandi degli sposi
+type SlideshowService struct {
+	slideshowStore       manager.SlideshowStore
+	photoMetadataManager *manager.PhotoMetadataManager
+	photoService         *PhotoService
+}
+
+// NewSlideshowService crea una nuova istanza del service
+func NewSlideshowService(slideshowStore manager.SlideshowStore, photoMetadataManager *manager.PhotoMetadataManager, photoService *PhotoService) *SlideshowService {
+	return &SlideshowService{
+		slideshowStore:       slideshowStore,
+		photoMetadataManager: photoMetadataManager,
+		photoService:         photoService,
+	}
+}
+
+// slideshowContext raccoglie le foto disponibili per lo slideshow al momento della richiesta
+type slideshowContext struct {
+	photos   map[string]model.Photo // Foto visibili e non nascoste, per nome
+	eligible []string               // Nomi delle foto disponibili, dalla più recente
+	hidden   map[string]bool        // Foto escluse dallo slideshow
+	pinned   []string               // Foto fissate disponibili
+	recent   []string               // Ultime foto mostrate, dalla più recente
+	blocked  map[string]bool        // Foto mostrate troppo di recente per essere ripetute
+	seen     map[string]bool        // Foto mostrate almeno una volta
+}
+
+// GetSlideshow restituisce lo stato dello slideshow con la foto da mostrare e le prossime previste,
+// passando alla slide successiva se quella corrente è scaduta
+func (ss *SlideshowService) GetSlideshow(upcoming int) (*model.SlideshowResponse, error) {
+	state, err := ss.getState()
+	if err != nil {
+		return nil, err
+	}
+	sc, err := ss.loadContext(state)
+	if err != nil {
+		return nil, err
+	}
+
+	if ss.needsAdvance(state, sc, time.Now()) {
+		// Solo una richiesta alla volta fa avanzare lo slideshow, le altre leggono lo stato attuale
+		locked, err := ss.slideshowStore.Lock(slideshowLockTTL)
+		if err != nil {
+			return nil, err
+		}
+		if locked {
+			err := func() error {
+				defer ss.slideshowStore.Unlock()
+				if state, err = ss.getState(); err != nil {
+					return err
+				}
+				if sc, err = ss.loadContext(state); err != nil {
+					return err
+				}
+				if ss.needsAdvance(state, sc, time.Now()) {
+					return ss.advance(state, sc, time.Now())
+				}
+				return nil
+			}()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return ss.toResponse(state, sc, upcoming)
+}
+
+// Control esegue un comando degli sposi e restituisce lo stato aggiornato
+func (ss *SlideshowService) Control(action, imageName string) (*model.SlideshowResponse, error) {
+	err := ss.withLock(func(state *manager.SlideshowState, sc *slideshowContext) error {
+		now := time.Now()
+		if imageName == "" {
+			imageName = state.Current
+		}
+
+		switch action {
+		case SlideshowPause:
+			if !state.Paused {
+				state.Paused = true
+				state.PausedRemaining = max(state.StartedAt.Add(state.SlideDuration).Sub(now), 0)
+			}
+		case SlideshowResume:
+			if state.Paused {
+				state.Paused = false
+				state.StartedAt = now.Add(state.PausedRemaining - state.SlideDuration)
+				state.PausedRemaining = 0
+			}
+		case SlideshowSkip:
+			return ss.advance(state, sc, now)
+		case SlideshowPin, SlideshowUnpin:
+			if _, ok := sc.photos[imageName]; action == SlideshowPin && !ok {
+				return ErrPhotoNotFound
+			}
+			return ss.slideshowStore.SetPinned(imageName, action == SlideshowPin)
+		case SlideshowHide, SlideshowUnhide:
+			if !ss.photoService.PhotoExists(imageName) {
+				return ErrPhotoNotFound
+			}
+			if err := ss.slideshowStore.SetHidden(imageName, action == SlideshowHide); err != nil {
+				return err
+			}
+			if action == SlideshowHide && imageName == state.Current {
+				delete(sc.photos, imageName)
+				sc.eligible = removeName(sc.eligible, imageName)
+				sc.pinned = removeName(sc.pinned, imageName)
+				return ss.advance(state, sc, now)
+			}
+			return nil
+		default:
+			return ErrInvalidSlideshowAction
+		}
+		return ss.slideshowStore.SaveState(state)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ss.GetSlideshow(DefaultUpcomingSlides)
+}
+
+// UpdateSettings modifica durata delle slide, frequenza delle foto fissate e finestra di non ripetizione
+func (ss *SlideshowService) UpdateSettings(request model.SlideshowSettingsRequest) (*model.SlideshowResponse, error) {
+	if request.SlideDuration != nil {
+		duration := time.Duration(*request.SlideDuration) * time.Second
+		if duration < MinSlideDuration || duration > MaxSlideDuration {
+			minSeconds, maxSeconds := int(MinSlideDuration/time.Second), int(MaxSlideDuration/time.Second)
+			return nil, apperror.WithDetails(fmt.Errorf("%w: slide_duration deve essere tra %d e %d secondi", ErrInvalidSlideshowSettings, minSeconds, maxSeconds),
+				map[string]any{"field": "slide_duration", "min": minSeconds, "max": maxSeconds})
+		}
+	}
+	if request.PinEvery != nil && *request.PinEvery < 0 {
+		return nil, apperror.WithDetails(fmt.Errorf("%w: pin_every deve essere almeno 0", ErrInvalidSlideshowSettings),
+			map[string]any{"field": "pin_every", "min": 0})
+	}
+	if request.RepeatWindow != nil && *request.RepeatWindow < 1 {
+		return nil, apperror.WithDetails(fmt.Errorf("%w: repeat_window deve essere almeno 1", ErrInvalidSlideshowSettings),
+			map[string]any{"field": "repeat_window", "min": 1})
+	}
+
+	err := ss.withLock(func(state *manager.SlideshowState, sc *slideshowContext) error {
+		if request.SlideDuration != nil {
+			state.SlideDuration = time.Duration(*request.SlideDuration) * time.Second
+		}
+		if request.PinEvery != nil {
+			// Nello stato salvato 0 indica il valore di default, -1 disattiva le foto fissate
+			state.PinEvery = *request.PinEvery
+			if state.PinEvery == 0 {
+				state.PinEvery = -1
+			}
+		}
+		if request.RepeatWindow != nil {
+			state.RepeatWindow = *request.RepeatWindow
+		}
+		return ss.slideshowStore.SaveState(state)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ss.GetSlideshow(DefaultUpcomingSlides)
+}
+
+// withLock esegue fn con il lock sullo stato dello slideshow, attendendo se è già acquisito
+func (ss *SlideshowService) withLock(fn func(state *manager.SlideshowState, sc *slideshowContext) error) error {
+	deadline := time.Now().Add(slideshowLockTTL)
+	for {
+		locked, err := ss.slideshowStore.Lock(slideshowLockTTL)
+		if err != nil {
+			return err
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			return ErrSlideshowBusy
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	defer ss.slideshowStore.Unlock()
+
+	state, err := ss.getState()
+	if err != nil {
+		return err
+	}
+	sc, err := ss.loadContext(state)
+	if err != nil {
+		return err
+	}
+	return fn(state, sc)
+}
+
+// getState restituisce lo stato dello slideshow con i valori di default applicati
+func (ss *SlideshowService) getState() (*manager.SlideshowState, error) {
+	state, err := ss.slideshowStore.GetState()
+	if err != nil {
+		return nil, err
+	}
+	applyDefaults(state)
+	return state, nil
+}
+
+// applyDefaults sostituisce le impostazioni non configurate con i valori di default
+func applyDefaults(state *manager.SlideshowState) {
+	if state.SlideDuration <= 0 {
+		state.SlideDuration = DefaultSlideDuration
+	}
+	if state.PinEvery == 0 {
+		state.PinEvery = DefaultPinEvery
+	}
+	if state.RepeatWindow <= 0 {
+		state.RepeatWindow = DefaultRepeatWindow
+	}
+}
+
+// loadContext legge le foto visibili, quelle nascoste, fissate e mostrate di recente
+func (ss *SlideshowService) loadContext(state *manager.SlideshowState) (*slideshowContext, error) {
+	imageNames, err := ss.photoMetadataManager.GetImageNames()
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := ss.slideshowStore.GetHidden()
+	if err != nil {
+		return nil, err
+	}
+	pinned, err := ss.slideshowStore.GetPinned()
+	if err != nil {
+		return nil, err
+	}
+	seen, err := ss.slideshowStore.GetSeen()
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &slideshowContext{
+		photos: make(map[string]model.Photo),
+		hidden: hidden,
+		seen:   seen,
+	}
+
+	// GetPhotosByName esclude le foto non ancora elaborate o da approvare
+	sort.Sort(sort.Reverse(sort.StringSlice(imageNames)))
+	for _, photo := range ss.photoService.GetPhotosByName(imageNames) {
+		if hidden[photo.ImageName] {
+			continue
+		}
+		sc.photos[photo.ImageName] = photo
+		sc.eligible = append(sc.eligible, photo.ImageName)
+	}
+	for _, imageName := range pinned {
+		if _, ok := sc.photos[imageName]; ok {
+			sc.pinned = append(sc.pinned, imageName)
+		}
+	}
+
+	// Con poche foto la finestra si riduce, così lo slideshow continua a scorrere
+	window := min(state.RepeatWindow, len(sc.eligible)-1)
+	recent, err := ss.slideshowStore.GetRecent(max(state.RepeatWindow, DefaultRepeatWindow))
+	if err != nil {
+		return nil, err
+	}
+	sc.recent = recent
+	sc.blocked = make(map[string]bool, len(recent))
+	for _, imageName := range recent[:min(max(window, 0), len(recent))] {
+		sc.blocked[imageName] = true
+	}
+
+	return sc, nil
+}
+
+// needsAdvance indica se la slide corrente è scaduta o non è più disponibile
+func (ss *SlideshowService) needsAdvance(state *manager.SlideshowState, sc *slideshowContext, now time.Time) bool {
+	if _, ok := sc.photos[state.Current]; !ok {
+		return len(sc.eligible) > 0 || state.Current != ""
+	}
+	return !state.Paused && !now.Before(state.StartedAt.Add(state.SlideDuration))
+}
+
+// advance passa alla slide successiva e salva lo stato
+func (ss *SlideshowService) advance(state *manager.SlideshowState, sc *slideshowContext, now time.Time) error {
+	next, err := ss.pickNext(state, sc)
+	if err != nil {
+		return err
+	}
+
+	state.Current = next
+	state.StartedAt = now
+	state.SlideCount++
+	if state.Paused {
+		state.PausedRemaining = state.SlideDuration
+	}
+	if next != "" {
+		if err := ss.slideshowStore.AddShown(next, max(state.RepeatWindow, DefaultRepeatWindow)); err != nil {
+			return err
+		}
+	}
+	return ss.slideshowStore.SaveState(state)
+}
+
+// pickNext sceglie la prossima foto: una foto fissata a intervalli regolari, altrimenti le foto mai
+// mostrate dalla più recente, poi le altre in ordine casuale senza ripetere quelle mostrate di recente
+func (ss *SlideshowService) pickNext(state *manager.SlideshowState, sc *slideshowContext) (string, error) {
+	if pinned := pinnedSlide(state, sc, state.SlideCount+1); pinned != "" && pinned != state.Current {
+		return pinned, nil
+	}
+
+	for _, imageName := range sc.eligible {
+		if !sc.seen[imageName] && !sc.blocked[imageName] {
+			return imageName, nil
+		}
+	}
+
+	for refilled := false; ; refilled = true {
+		for {
+			imageName, err := ss.slideshowStore.PopQueue()
+			if err != nil {
+				return "", err
+			}
+			if imageName == "" {
+				break
+			}
+			if _, ok := sc.photos[imageName]; ok && !sc.blocked[imageName] {
+				return imageName, nil
+			}
+		}
+		if refilled {
+			break
+		}
+
+		var backlog []string
+		for _, imageName := range sc.eligible {
+			if !sc.blocked[imageName] {
+				backlog = append(backlog, imageName)
+			}
+		}
+		if err := ss.slideshowStore.FillQueue(backlog); err != nil {
+			return "", err
+		}
+	}
+
+	// Tutte le foto sono state mostrate di recente: ripete la più vecchia diversa da quella corrente
+	for i := len(sc.eligible) - 1; i >= 0; i-- {
+		if sc.eligible[i] != state.Current {
+			return sc.eligible[i], nil
+		}
+	}
+	if len(sc.eligible) > 0 {
+		return sc.eligible[0], nil
+	}
+	return "", nil
+}
+
+// pinnedSlide restituisce la foto fissata da mostrare nella slide indicata, o una stringa vuota
+func pinnedSlide(state *manager.SlideshowState, sc *slideshowContext, slide int64) string {
+	if state.PinEvery <= 0 || len(sc.pinned) == 0 || slide%int64(state.PinEvery) != 0 {
+		return ""
+	}
+	return sc.pinned[int(slide/int64(state.PinEvery))%len(sc.pinned)]
+}
+
+// toResponse converte lo stato nella risposta, con la previsione delle prossime slide
+func (ss *SlideshowService) toResponse(state *manager.SlideshowState, sc *slideshowContext, upcoming int) (*model.SlideshowResponse, error) {
+	response := &model.SlideshowResponse{
+		Paused:        state.Paused,
+		SlideDuration: int(state.SlideDuration / time.Second),
+		PinEvery:      max(state.PinEvery, 0),
+		RepeatWindow:  state.RepeatWindow,
+		Upcoming:      []model.Photo{},
+		Pinned:        append([]string{}, sc.pinned...),
+		Hidden:        make([]string, 0, len(sc.hidden)),
+	}
+	for imageName := range sc.hidden {
+		response.Hidden = append(response.Hidden, imageName)
+	}
+	sort.Strings(response.Hidden)
+
+	if photo, ok := sc.photos[state.Current]; ok {
+		response.Current = &photo
+		startedAt := state.StartedAt
+		response.StartedAt = &startedAt
+		if state.Paused {
+			response.RemainingMs = state.PausedRemaining.Milliseconds()
+		} else {
+			response.RemainingMs = max(time.Until(state.StartedAt.Add(state.SlideDuration)).Milliseconds(), 0)
+		}
+	}
+
+	queue, err := ss.slideshowStore.GetQueue()
+	if err != nil {
+		return nil, err
+	}
+
+	// Simula le prossime scelte senza modificare lo stato: la previsione può cambiare con i nuovi caricamenti
+	planned := map[string]bool{state.Current: true}
+	var fresh, queued []string
+	for _, imageName := range sc.eligible {
+		if !sc.seen[imageName] && !sc.blocked[imageName] {
+			fresh = append(fresh, imageName)
+		}
+	}
+	for _, imageName := range queue {
+		if _, ok := sc.photos[imageName]; ok && !sc.blocked[imageName] {
+			queued = append(queued, imageName)
+		}
+	}
+	// Dopo la coda vengono rimescolate le foto non bloccate, poi si liberano le meno recenti
+	candidates := append(fresh, queued...)
+	for _, imageName := range sc.eligible {
+		if !sc.blocked[imageName] {
+			candidates = append(candidates, imageName)
+		}
+	}
+	for i := len(sc.recent) - 1; i >= 0; i-- {
+		if _, ok := sc.photos[sc.recent[i]]; ok {
+			candidates = append(candidates, sc.recent[i])
+		}
+	}
+
+	previous := state.Current
+	for slide := state.SlideCount + 1; len(response.Upcoming) < upcoming; slide++ {
+		next := pinnedSlide(state, sc, slide)
+		if next == previous {
+			next = ""
+		}
+		for next == "" && len(candidates) > 0 {
+			if !planned[candidates[0]] {
+				next = candidates[0]
+			}
+			candidates = candidates[1:]
+		}
+		if next == "" {
+			break
+		}
+		planned[next] = true
+		previous = next
+		response.Upcoming = append(response.Upcoming, sc.photos[next])
+	}
+
+	return response, nil
+}
+
+// removeName restituisce l'elenco senza il nome indicato
+func removeName(imageNames []string, imageName string) []string {
+	result := make([]string, 0, len(imageNames))
+	for _, name := range imageNames {
+		if name != imageName {
+			result = append(result, name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"strings"
+
+	"wedding-photo-backend/internal/weddingphoto/apperror"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+)
+
+const (
+	// TagKindTag identifica un tag libero (es. "torta", "balli")
+	TagKindTag = "tag"
+	// TagKindPerson identifica una persona riconosciuta nella foto (es. "nonna Maria")
+	TagKindPerson = "person"
+
+	// MaxTagLength è la lunghezza massima di un tag, in caratteri
+	MaxTagLength = 40
+	// MaxTagsPerRequest è il numero massimo di tag aggiungibili con una richiesta
+	MaxTagsPerRequest = 20
+)
+
+var (
+	// ErrInvalidTag indica un tag vuoto
+	ErrInvalidTag = apperror.New(apperror.KindInvalid, "invalid_tag", "tag non valido")
+	// ErrInvalidTagKind indica un tipo di tag non previsto
+	ErrInvalidTagKind = apperror.New(apperror.KindInvalid, "invalid_tag_kind", "tipo di tag non valido, usare tag o person")
+	// ErrTooManyTags indica una richiesta con troppi tag
+	ErrTooManyTags = apperror.New(apperror.KindInvalid, "too_many_tags", "troppi tag nella stessa richiesta")
+	// ErrTagNotFound indica che il tag non è associato alla foto
+	ErrTagNotFound = apperror.New(apperror.KindNotFound, "tag_not_found", "tag non trovato")
+	// ErrTagForbidden indica che l'ospite non può modificare i tag della foto
+	ErrTagForbidden = apperror.New(apperror.KindForbidden, "tag_forbidden", "i tag possono essere modificati solo da chi ha caricato la foto o da un amministratore")
+)
+
+// TagService gestisce la logica di business per tag e persone associate alle foto
+type TagService struct {
+	tagManager           *manager.TagManager
+	photoMetadataManager *manager.PhotoMetadataManager
+	searchManager        *manager.SearchManager
+	photoService         *PhotoService
+	contentFilter        ContentFilter
+}
+
+// NewTagService crea una nuova istanza del service
+func NewTagService(tagManager *manager.TagManager, photoMetadataManager *manager.PhotoMetadataManager, searchManager *manager.SearchManager, photoService *PhotoService, contentFilter ContentFilter) *TagService {
+	return &TagService{
+		tagManager:           tagManager,
+		photoMetadataManager: photoMetadataManager,
+		searchManager:        searchManager,
+		photoService:         photoService,
+		contentFilter:        contentFilter,
+	}
+}
+
+// GetPhotoTags restituisce tag e persone di una foto
+func (ts *TagService) GetPhotoTags(imageName string) ([]string, []string, error) {
+	if !ts.photoService.PhotoExists(imageName) {
+		return nil, nil, ErrPhotoNotFound
+	}
+
+	records, err := ts.tagManager.GetTags([]string{imageName})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tags, people := splitTags(records[imageName])
+	return tags, people, nil
+}
+
+// AddTags aggiunge tag e persone a una foto; consentito a chi l'ha caricata e agli amministratori
+func (ts *TagService) AddTags(imageName string, tags, people []string, guestToken string, isAdmin bool) error {
+	if err := ts.checkPermission(imageName, guestToken, isAdmin); err != nil {
+		return err
+	}
+	if len(tags)+len(people) > MaxTagsPerRequest {
+		return ErrTooManyTags
+	}
+
+	records := make([]manager.TagRecord, 0, len(tags)+len(people))
+	for kind, names := range map[string][]string{TagKindTag: tags, TagKindPerson: people} {
+		for _, name := range names {
+			display, normalized := normalizeTag(name)
+			if normalized == "" {
+				return ErrInvalidTag
+			}
+			if err := checkText(ts.contentFilter, display, MaxTagLength, fieldTag); err != nil {
+				return err
+			}
+			records = append(records, manager.TagRecord{
+				ImageName:  imageName,
+				Kind:       kind,
+				Name:       display,
+				Normalized: normalized,
+				AddedBy:    guestToken,
+			})
+		}
+	}
+
+	if err := ts.tagManager.AddTags(records); err != nil {
+		return err
+	}
+	return ts.searchManager.IndexPhoto(imageName)
+}
+
+// RemoveTag rimuove un tag o una persona da una foto; consentito a chi l'ha caricata e agli amministratori
+func (ts *TagService) RemoveTag(imageName, kind, name string, guestToken string, isAdmin bool) error {
+	if kind != TagKindTag && kind != TagKindPerson {
+		return ErrInvalidTagKind
+	}
+	if err := ts.checkPermission(imageName, guestToken, isAdmin); err != nil {
+		return err
+	}
+
+	_, normalized := normalizeTag(name)
+	removed, err := ts.tagManager.RemoveTag(imageName, manager.TagLabel{Kind: kind, Normalized: normalized})
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrTagNotFound
+	}
+	return ts.searchManager.IndexPhoto(imageName)
+}
+
+// SuggestTags restituisce i tag esistenti che iniziano con il prefisso indicato, per l'autocompletamento
+func (ts *TagService) SuggestTags(prefix, kind string, limit int) ([]manager.TagSuggestion, error) {
+	if kind != "" && kind != TagKindTag && kind != TagKindPerson {
+		return nil, ErrInvalidTagKind
+	}
+
+	_, normalized := normalizeTag(prefix)
+	return ts.tagManager.SuggestTags(normalized, kind, limit)
+}
+
+// checkPermission verifica che la foto esista e che l'ospite possa modificarne i tag
+func (ts *TagService) checkPermission(imageName, guestToken string, isAdmin bool) error {
+	if !ts.photoService.PhotoExists(imageName) {
+		return ErrPhotoNotFound
+	}
+	if isAdmin {
+		return nil
+	}
+
+	record, err := ts.photoMetadataManager.GetPhoto(imageName)
+	if err != nil {
+		return err
+	}
+	if record == nil || record.UploaderToken == "" || record.UploaderToken != guestToken {
+		return ErrTagForbidden
+	}
+	return nil
+}
+
+// normalizeTag restituisce il tag con gli spazi ripuliti e la sua forma normalizzata usata per confronti e indici
+func normalizeTag(name string) (string, string) {
+	display := strings.Join(strings.Fields(name), " ")
+	return display, strings.ToLower(display)
+}
+
+// tagLabels converte i tag e le persone richiesti nei filtri in etichette normalizzate, ignorando quelli vuoti
+func tagLabels(tags, people []string) []manager.TagLabel {
+	labels := make([]manager.TagLabel, 0, len(tags)+len(people))
+	seen := make(map[manager.TagLabel]bool, len(tags)+len(people))
+	for kind, names := range map[string][]string{TagKindTag: tags, TagKindPerson: people} {
+		for _, name := range names {
+			_, normalized := normalizeTag(name)
+			label := manager.TagLabel{Kind: kind, Normalized: normalized}
+			if normalized == "" || seen[label] {
+				continue
+			}
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// splitTags separa i tag liberi dalle persone, mantenendo i nomi da mostrare
+func splitTags(records []manager.TagRecord) ([]string, []string) {
+	tags := []string{}
+	people := []string{}
+	for _, record := range records {
+		if record.Kind == TagKindPerson {
+			people = append(people, record.Name)
+		} else {
+			tags = append(tags, record.Name)
+		}
+	}
+	return tags, people
+}
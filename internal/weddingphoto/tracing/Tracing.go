@@ -0,0 +1,147 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ExporterNone disattiva l'esportazione delle tracce
+	ExporterNone = "none"
+	// ExporterOTLP invia le tracce a un collector OpenTelemetry tramite OTLP su HTTP
+	ExporterOTLP = "otlp"
+	// ExporterStdout scrive le tracce come JSON, una riga per span
+	ExporterStdout = "stdout"
+
+	// instrumentationName identifica gli span creati da questo server
+	instrumentationName = "wedding-photo-backend"
+)
+
+// Options descrive dove e quanto esportare le tracce
+type Options struct {
+	Exporter    string    // ExporterNone, ExporterOTLP o ExporterStdout
+	Endpoint    string    // host:porta del collector OTLP
+	Insecure    bool      // usa HTTP invece di HTTPS verso il collector
+	SampleRatio float64   // frazione delle tracce registrate, da 0 a 1
+	ServiceName string    // nome del servizio riportato nelle tracce
+	Output      io.Writer // destinazione di ExporterStdout
+}
+
+// Provider esporta gli span creati con Start e quelli delle chiamate a Redis
+type Provider struct {
+	provider      *sdktrace.TracerProvider
+	redisProvider *sdktrace.TracerProvider
+}
+
+// Setup configura la propagazione del contesto di traccia e, se è indicato un exporter, il TracerProvider
+// globale. Con ExporterNone gli span non vengono registrati ma il contesto ricevuto viene comunque propagato
+func Setup(options Options) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch options.Exporter {
+	case ExporterNone:
+		return &Provider{}, nil
+	case ExporterOTLP:
+		exporterOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(options.Endpoint)}
+		if options.Insecure {
+			exporterOptions = append(exporterOptions, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(context.Background(), exporterOptions...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(options.Output))
+	default:
+		return nil, fmt.Errorf("exporter %q non valido, valori ammessi none, otlp, stdout", options.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("errore nella creazione dell'exporter delle tracce: %v", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(options.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("errore nella descrizione del servizio per le tracce: %v", err)
+	}
+
+	processor := sdktrace.NewBatchSpanProcessor(exporter)
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	// Le chiamate a Redis fuori da una richiesta o da un job (polling dei worker, eventi) non aprono
+	// tracce proprie: vengono registrate solo come figlie di uno span già campionato
+	redisProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.NeverSample())),
+	)
+
+	return &Provider{provider: provider, redisProvider: redisProvider}, nil
+}
+
+// InstrumentRedis registra uno span per ogni comando inviato dal client Redis nel contesto di una traccia;
+// i valori dei comandi non vengono riportati perché contengono token e indirizzi degli ospiti
+func (p *Provider) InstrumentRedis(client *redis.Client) error {
+	if p.redisProvider == nil {
+		return nil
+	}
+	return redisotel.InstrumentTracing(client, redisotel.WithTracerProvider(p.redisProvider), redisotel.WithDBStatement(false))
+}
+
+// Shutdown invia gli span ancora in memoria e chiude l'exporter
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.provider == nil {
+		return nil
+	}
+	return errors.Join(p.redisProvider.Shutdown(ctx), p.provider.Shutdown(ctx))
+}
+
+// Start apre uno span figlio di quello contenuto nel context
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End chiude lo span, segnandolo come fallito se err non è nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject restituisce il contesto di traccia di ctx da inserire nel payload di un job, o nil se ctx non
+// appartiene a una traccia
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract restituisce un context che continua la traccia salvata nel payload di un job
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
@@ -1,16 +1,23 @@
 package main
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"time"
 	"wedding-photo-backend/docs"
 	"wedding-photo-backend/internal/weddingphoto/controller"
 	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/metrics"
+	"wedding-photo-backend/internal/weddingphoto/middleware"
 	"wedding-photo-backend/internal/weddingphoto/service"
-	"wedding-photo-backend/internal/weddingphoto/util"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"github.com/swaggo/gin-swagger/swaggerFiles"
 )
@@ -29,39 +36,81 @@ import (
 
 // @BasePath /
 
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+
 func main() {
+	// Senza argomenti avvia il server, altrimenti esegue il sottocomando indicato
+	if len(os.Args) < 2 {
+		runCommand([]string{"serve"})
+		return
+	}
+	runCommand(os.Args[1:])
+}
 
-	_ = godotenv.Load()
+// serve avvia il server HTTP con i worker in background fino alla ricezione di SIGINT o SIGTERM
+func serve(args []string) error {
+	flags := newFlagSet("serve", "")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return usageError(flags, err)
+	}
 
-	// Inizializza il router Gin
-	r := gin.Default()
+	// Log e tracce su stdout
+	app := newApplication(os.Stdout)
+	cfg := app.cfg
+	slog.Info("Coda di elaborazione pronta", "backend", cfg.Queue.Backend, "workers", cfg.Queue.Workers)
 
-	// Abilita CORS per consentire richieste da frontend
-	r.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	// Inizializza il router Gin: il log delle richieste è scritto da middleware.RequestLogger,
+	// le route registrate compaiono nei log di debug
+	gin.DefaultWriter = io.Discard
+	gin.DebugPrintRouteFunc = func(httpMethod, absolutePath, handlerName string, handlers int) {
+		slog.Debug("Route registrata", "method", httpMethod, "path", absolutePath, "handler", handlerName)
+	}
+	r := gin.New()
+	// L'indirizzo del client, usato dai limiti di richieste, viene letto da X-Forwarded-For solo se la
+	// richiesta arriva da un proxy fidato: altrimenti ogni client potrebbe indicarne uno diverso
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("TRUSTED_PROXIES non valido", err)
+	}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	// Assegna a ogni richiesta un identificativo e uno span, riportati nei log, nella risposta e nei job accodati
+	r.Use(middleware.RequestID(), middleware.Tracing(), middleware.RequestLogger(), middleware.Recovery())
 
-		c.Next()
+	// Abilita CORS per consentire richieste dai frontend indicati in CORS_ALLOWED_ORIGINS.
+	// Thumbnail e preview hanno una policy propria, senza credenziali, per poterle incorporare ovunque
+	cors, err := middleware.NewCors(middleware.CorsPolicy{
+		AllowedOrigins:   cfg.Cors.AllowedOrigins,
+		AllowCredentials: cfg.Cors.AllowCredentials,
+		AllowedHeaders:   cfg.Cors.AllowedHeaders,
+		ExposedHeaders:   cfg.Cors.ExposedHeaders,
+		MaxAge:           cfg.Cors.MaxAge,
+	})
+	if err != nil {
+		fatal("Errore nella configurazione CORS", err)
+	}
+	err = cors.AddRoute("/media", middleware.CorsPolicy{
+		AllowedOrigins: cfg.Cors.MediaAllowedOrigins,
+		AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodOptions},
+		AllowedHeaders: []string{"If-Modified-Since", "If-None-Match", "Range"},
+		ExposedHeaders: []string{"Content-Length", "Content-Range", "ETag"},
+		MaxAge:         cfg.Cors.MaxAge,
 	})
+	if err != nil {
+		fatal("Errore nella configurazione CORS", err)
+	}
+	r.Use(cors.Handler())
 
-	host := util.GetEnv("HOST", "0.0.0.0")
-	port := util.GetEnv("PORT", "8739")
-	baseUrl := util.GetEnv("BASE_URL", "http://localhost:8739")
-	photosDir := util.GetEnv("PHOTOS_DIR", "media")
-	redisAddr := util.GetEnv("REDIS_ADDR", "localhost:6379")
-	redisPassword := util.GetEnv("REDIS_PASSWORD", "")
-	redisDB := 0 // util.GetEnvAsInt("REDIS_DB", 0) se hai una funzione per int
+	// Conta e misura le richieste per route, esposte su /metrics
+	r.Use(middleware.RequestMetrics())
+
+	// Sceglie la lingua dei messaggi di errore da ?lang= o Accept-Language
+	r.Use(middleware.Locale())
 
 	// use net/url to parse the baseUrl and set the swagger Host, Scheme and BasePath
-	parsedUrl, err := url.Parse(baseUrl)
+	parsedUrl, err := url.Parse(cfg.Server.BaseURL)
 	if err != nil {
-		log.Fatal("Error parsing BASE_URL:", err)
+		fatal("BASE_URL non valido", err)
 	}
 
 	docs.SwaggerInfo.Host = parsedUrl.Host
@@ -72,33 +121,136 @@ func main() {
 		docs.SwaggerInfo.BasePath = "/"
 	}
 
-	photoManager := manager.NewPhotoManager(photosDir)
-	urlManager := manager.NewUrlManager(baseUrl)
-	queueManager := manager.NewQueueManager(redisAddr, redisPassword, redisDB)
+	if cfg.Admin.Token == "" {
+		slog.Warn("ADMIN_TOKEN non impostato, le operazioni amministrative sono accessibili a tutti")
+	}
+	adminAuth := middleware.NewAdminAuth(cfg.Admin.Token)
+	rateLimiter := middleware.NewRateLimiter(app.rateLimitManager, adminAuth)
+	signedMedia := middleware.NewSignedMedia(app.urlManager)
 
-	// Testa la connessione Redis
-	if err := queueManager.TestConnection(); err != nil {
-		log.Printf("Attenzione: errore nella connessione a Redis: %v", err)
-	} else {
-		log.Println("Connessione a Redis stabilita con successo")
+	photoController := controller.NewPhotoController(app.photoService, app.albumService, adminAuth, rateLimiter, int64(cfg.Upload.MaxSize))
+	albumController := controller.NewAlbumController(app.albumService, adminAuth)
+	reactionController := controller.NewReactionController(app.reactionService)
+	commentController := controller.NewCommentController(app.commentService, adminAuth)
+	tagController := controller.NewTagController(app.tagService, adminAuth)
+	searchController := controller.NewSearchController(app.searchService)
+	eventController := controller.NewEventController(app.eventService, cors)
+	slideshowController := controller.NewSlideshowController(app.slideshowService, adminAuth)
+	exportController := controller.NewExportController(app.exportService, adminAuth)
+	rateLimitController := controller.NewRateLimitController(app.rateLimitService, adminAuth)
+	healthController := controller.NewHealthController(app.healthService, adminAuth)
+
+	// Elimina i file parziali degli upload interrotti, lasciando quelli ancora in scrittura su altre istanze
+	if removed, err := app.photoManager.RemoveIncompleteUploads(); err != nil {
+		slog.Warn("Errore nella pulizia degli upload incompleti", "error", err)
+	} else if removed > 0 {
+		slog.Info("Eliminati upload incompleti", "count", removed)
 	}
 
-	photoService := service.NewPhotoService(photoManager, urlManager, queueManager)
-	photoController := controller.NewPhotoController(photoService)
+	// Converte le versioni ridotte delle foto WebP salvate con il nome dell'originale
+	if migrated, err := app.photoManager.MigrateLegacyRenditions(); err != nil {
+		slog.Warn("Errore nella conversione delle versioni ridotte WebP", "error", err)
+	} else if migrated > 0 {
+		slog.Info("Convertite le versioni ridotte WebP con il nome dell'originale", "count", migrated)
+	}
+
+	// Registra nel database dei metadati le foto già presenti su disco
+	if added, err := app.photoService.SyncMetadata(); err != nil {
+		slog.Warn("Errore nella sincronizzazione dei metadati", "error", err)
+	} else if added > 0 {
+		slog.Info("Registrate nel database dei metadati le foto presenti su disco", "count", added)
+	}
+
+	// Copia periodicamente le reazioni da Redis al database dei metadati, con un ultimo salvataggio all'arresto
+	app.reactionService.RestoreReactions()
+	app.lifecycle.Go("reazioni", func(ctx context.Context) {
+		app.reactionService.RunPersistence(ctx, service.ReactionPersistInterval)
+	})
+
+	// Inoltra ai client connessi gli eventi pubblicati da tutte le istanze e rileva le foto elaborate
+	app.lifecycle.Go("eventi", app.eventService.Run)
+	app.lifecycle.Go("elaborazioni", func(ctx context.Context) {
+		app.photoService.RunRenditionWatcher(ctx, service.RenditionCheckInterval)
+	})
+
+	// Genera thumbnail e preview delle foto in coda; con QUEUE_WORKERS=0 se ne occupano worker esterni
+	for i := 1; i <= cfg.Queue.Workers; i++ {
+		app.lifecycle.Go(fmt.Sprintf("elaborazione-%d", i), app.processingService.RunWorker)
+	}
+
+	// Prepara in background le esportazioni ZIP richieste dagli amministratori
+	app.lifecycle.Go("esportazioni", app.exportService.RunWorker)
+
+	// Stato dell'istanza per load balancer e orchestratori
+	healthController.SetupProbeRoutes(r)
+
+	// Metriche Prometheus, protette da METRICS_TOKEN se impostato
+	if cfg.Metrics.Enabled {
+		metrics.RegisterLabeledGauge("queue_depth", "Immagini in coda di elaborazione per corsia.", "lane", func() (map[string]float64, error) {
+			lengths, err := app.queue.GetLaneLengths()
+			if err != nil {
+				return nil, err
+			}
+			values := make(map[string]float64, len(lengths))
+			for lane, length := range lengths {
+				values[lane] = float64(length)
+			}
+			return values, nil
+		})
+		metrics.RegisterGauge("pending_renditions", "Foto di cui thumbnail e preview non sono ancora pronte.", func() (float64, error) {
+			count, err := app.photoMetadataManager.CountUnprocessed()
+			return float64(count), err
+		})
+
+		metricsHandlers := []gin.HandlerFunc{gin.WrapH(metrics.Handler())}
+		if cfg.Metrics.Token != "" {
+			metricsHandlers = append([]gin.HandlerFunc{middleware.NewAdminAuth(cfg.Metrics.Token).Require()}, metricsHandlers...)
+		}
+		r.GET("/metrics", metricsHandlers...)
+	}
 
 	// Definisce le route API
-	api := r.Group("/api")
+	api := r.Group("/api", rateLimiter.RequireReads(manager.RateLimitRead))
 	photoController.SetupRoutes(api)
+	albumController.SetupRoutes(api)
+	reactionController.SetupRoutes(api)
+	commentController.SetupRoutes(api)
+	tagController.SetupRoutes(api)
+	searchController.SetupRoutes(api)
+	eventController.SetupRoutes(api)
+	slideshowController.SetupRoutes(api)
+	exportController.SetupRoutes(api)
+	rateLimitController.SetupRoutes(api)
+	healthController.SetupRoutes(api)
 
 	// Route per Swagger
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// Set route /media as static file server
-	r.Static("/media", photosDir)
+	// Espone solo le versioni ridotte: gli originali passano da /api/photos/{name}/original
+	media := r.Group("/media", signedMedia.Require())
+	media.Group("/thumbnails", middleware.CountServedBytes(metrics.KindThumbnail)).
+		Static("", filepath.Join(cfg.Storage.PhotosDir, "thumbnails"))
+	media.Group("/previews", middleware.CountServedBytes(metrics.KindPreview)).
+		Static("", filepath.Join(cfg.Storage.PhotosDir, "previews"))
 
-	// Avvia il server sulla porta
-	log.Println("Server avviato su http://" + host + ":" + port)
-	if err := r.Run(host + ":" + port); err != nil {
-		log.Fatal("Errore nell'avvio del server:", err)
+	// Avvia il server sulla porta; all'arresto attende gli upload in corso prima di chiudere le risorse
+	server := &http.Server{
+		Addr:              cfg.Server.Address(),
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
 	}
+	// Gli stream di eventi restano aperti indefinitamente: vanno chiusi per non bloccare l'attesa
+	server.RegisterOnShutdown(app.eventService.CloseSubscribers)
+
+	slog.Info("Server avviato", "address", "http://"+cfg.Server.Address())
+	if err := app.lifecycle.Serve(server); err != nil {
+		fatal("Errore nell'arresto del server", err)
+	}
+	return nil
+}
+
+// fatal registra un errore che impedisce l'avvio o l'arresto ordinato del server e termina il processo
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
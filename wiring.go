@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"wedding-photo-backend/internal/weddingphoto/config"
+	"wedding-photo-backend/internal/weddingphoto/i18n"
+	"wedding-photo-backend/internal/weddingphoto/lifecycle"
+	"wedding-photo-backend/internal/weddingphoto/logging"
+	"wedding-photo-backend/internal/weddingphoto/manager"
+	"wedding-photo-backend/internal/weddingphoto/middleware"
+	"wedding-photo-backend/internal/weddingphoto/service"
+	"wedding-photo-backend/internal/weddingphoto/tracing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// application raccoglie configurazione, manager e service condivisi dal server e dai sottocomandi;
+// le risorse aperte vengono chiuse dal lifecycle all'arresto
+type application struct {
+	cfg       *config.Config
+	lifecycle *lifecycle.Lifecycle
+
+	redisManager         *manager.RedisManager
+	queue                manager.Queue
+	metadataManager      *manager.MetadataManager
+	photoManager         *manager.PhotoManager
+	urlManager           *manager.UrlManager
+	photoMetadataManager *manager.PhotoMetadataManager
+	rateLimitManager     *manager.RateLimitManager
+
+	eventService       *service.EventService
+	photoService       *service.PhotoService
+	albumService       *service.AlbumService
+	reactionService    *service.ReactionService
+	commentService     *service.CommentService
+	searchService      *service.SearchService
+	slideshowService   *service.SlideshowService
+	tagService         *service.TagService
+	rateLimitService   *service.RateLimitService
+	healthService      *service.HealthService
+	processingService  *service.ProcessingService
+	exportService      *service.ExportService
+	maintenanceService *service.MaintenanceService
+}
+
+// configChecks verifica i valori della configurazione interpretati da manager e middleware, riportati
+// insieme agli altri problemi della configurazione
+var configChecks = []config.Check{
+	{Key: "media.signing_keys", Check: func(c *config.Config) error {
+		if c.Media.SigningKeys == "" {
+			return nil
+		}
+		keys, err := manager.ParseSigningKeys(c.Media.SigningKeys)
+		if err != nil {
+			return err
+		}
+		return manager.NewUrlManager(c.Server.BaseURL).EnableSigning(keys, time.Hour)
+	}},
+	{Key: "rate_limit.upload", Check: func(c *config.Config) error {
+		_, err := parseRateLimit(c.RateLimit.Upload)
+		return err
+	}},
+	{Key: "rate_limit.read", Check: func(c *config.Config) error {
+		_, err := parseRateLimit(c.RateLimit.Read)
+		return err
+	}},
+	{Key: "cors.allowed_origins", Check: func(c *config.Config) error {
+		_, err := middleware.NewCors(middleware.CorsPolicy{AllowedOrigins: c.Cors.AllowedOrigins, AllowCredentials: c.Cors.AllowCredentials})
+		return err
+	}},
+	{Key: "cors.media_allowed_origins", Check: func(c *config.Config) error {
+		_, err := middleware.NewCors(middleware.CorsPolicy{AllowedOrigins: c.Cors.MediaAllowedOrigins})
+		return err
+	}},
+}
+
+// parseRateLimit interpreta un limite di richieste della configurazione; "off" lo disattiva e
+// restituisce nil
+func parseRateLimit(value string) (*manager.RateLimit, error) {
+	if strings.TrimSpace(value) == "off" {
+		return nil, nil
+	}
+	limit, err := manager.ParseRateLimit(value)
+	if err != nil {
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// rateLimits restituisce i limiti di richieste attivi per budget
+func rateLimits(rc config.RateLimitConfig) (map[string]manager.RateLimit, error) {
+	limits := map[string]manager.RateLimit{}
+	for budget, value := range map[string]string{manager.RateLimitUpload: rc.Upload, manager.RateLimitRead: rc.Read} {
+		limit, err := parseRateLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("limite di richieste %s non valido: %w", budget, err)
+		}
+		if limit != nil {
+			limits[budget] = *limit
+		}
+	}
+	return limits, nil
+}
+
+// newApplication legge la configurazione, configura log e tracce scritti su output e crea manager e
+// service; gli errori terminano il processo
+func newApplication(output io.Writer) *application {
+	// Legge la configurazione da valori di default, file YAML/TOML, .env e variabili d'ambiente
+	cfg, err := config.Load(config.Options{Checks: configChecks})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Log strutturati, in JSON salvo LOG_FORMAT=text; anche il package log passa da slog
+	logger, err := logging.New(output, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	// Tracce OpenTelemetry verso un collector OTLP o su output, secondo TRACING_EXPORTER
+	tracer, err := tracing.Setup(tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+		Output:      output,
+	})
+	if err != nil {
+		fatal("Errore nella configurazione delle tracce", err)
+	}
+
+	// Gestisce i worker in background e l'arresto ordinato alla ricezione di SIGINT o SIGTERM
+	app := &application{
+		cfg: cfg,
+		lifecycle: lifecycle.New(lifecycle.Options{
+			Delay:   cfg.Server.ShutdownDelay,
+			Timeout: cfg.Server.ShutdownTimeout,
+		}),
+	}
+
+	app.photoManager = manager.NewPhotoManager(cfg.Storage.PhotosDir)
+	// Traduzioni aggiuntive o personalizzate dei messaggi di errore, oltre a italiano e inglese
+	if cfg.Storage.LocalesDir != "" {
+		if err := i18n.LoadDir(cfg.Storage.LocalesDir); err != nil {
+			fatal("Errore nel caricamento delle traduzioni", err)
+		}
+	}
+
+	app.urlManager = manager.NewUrlManager(cfg.Server.BaseURL)
+	// Con MEDIA_SIGNING_KEYS impostato thumbnail e preview sono raggiungibili solo con URL firmati e a scadenza
+	if cfg.Media.SigningKeys != "" {
+		signingKeys, err := manager.ParseSigningKeys(cfg.Media.SigningKeys)
+		if err != nil {
+			fatal("MEDIA_SIGNING_KEYS non valido", err)
+		}
+		if err := app.urlManager.EnableSigning(signingKeys, cfg.Media.URLTTL); err != nil {
+			fatal("Errore nella configurazione della firma degli URL", err)
+		}
+	}
+	app.metadataManager, err = manager.NewMetadataManager(cfg.Storage.DataDir)
+	if err != nil {
+		fatal("Errore nell'apertura del database dei metadati", err)
+	}
+
+	// Con la coda su Redis anche reazioni, eventi, slideshow, esportazioni e limiti di richieste sono
+	// condivisi tramite Redis tra più istanze; con la coda nel processo l'installazione ha una sola
+	// istanza e Redis non viene usato
+	var (
+		reactionStore  manager.ReactionStore
+		eventBus       manager.EventBus
+		slideshowStore manager.SlideshowStore
+		exportJobs     manager.ExportJobStore
+		rateLimitRedis *redis.Client
+	)
+	switch cfg.Queue.Backend {
+	case config.QueueBackendMemory:
+		memoryQueue, err := manager.NewMemoryQueue(cfg.Queue.Directory(cfg.Storage.DataDir))
+		if err != nil {
+			fatal("Errore nell'apertura della coda di elaborazione", err)
+		}
+		app.queue = memoryQueue
+		memorySlideshow, err := manager.NewMemorySlideshowStore(cfg.Storage.DataDir)
+		if err != nil {
+			fatal("Errore nell'apertura dello stato dello slideshow", err)
+		}
+		slideshowStore = memorySlideshow
+		reactionStore = manager.NewDatabaseReactionStore(app.metadataManager)
+		eventBus = manager.NewMemoryEventBus()
+		exportJobs = manager.NewMemoryExportJobStore(service.ExportRetention)
+	default:
+		app.redisManager = manager.NewRedisManager(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB)
+		if err := tracer.InstrumentRedis(app.redisManager.Client()); err != nil {
+			fatal("Errore nella configurazione delle tracce di Redis", err)
+		}
+
+		// Testa la connessione Redis
+		if err := app.redisManager.TestConnection(); err != nil {
+			slog.Warn("Errore nella connessione a Redis", "addr", cfg.Redis.Addr, "error", err)
+		} else {
+			slog.Info("Connessione a Redis stabilita con successo", "addr", cfg.Redis.Addr)
+		}
+
+		client := app.redisManager.Client()
+		app.queue = manager.NewQueueManager(client)
+		reactionStore = manager.NewReactionManager(client, app.metadataManager)
+		eventBus = manager.NewEventManager(client)
+		slideshowStore = manager.NewSlideshowManager(client)
+		exportJobs = manager.NewRedisExportJobStore(client, service.ExportRetention)
+		rateLimitRedis = client
+	}
+
+	// Le risorse vengono chiuse in ordine inverso: prima la coda e Redis, poi il database dei metadati e
+	// per ultime le tracce, per inviare anche gli span dell'arresto
+	app.lifecycle.OnClose("tracce", func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return tracer.Shutdown(ctx)
+	})
+	app.lifecycle.OnClose("database dei metadati", app.metadataManager.Close)
+	if app.redisManager != nil {
+		app.lifecycle.OnClose("Redis", app.redisManager.Close)
+	}
+	app.lifecycle.OnClose("coda di elaborazione", app.queue.Close)
+	albumManager := manager.NewAlbumManager(app.metadataManager)
+	app.photoMetadataManager = manager.NewPhotoMetadataManager(app.metadataManager)
+	commentManager := manager.NewCommentManager(app.metadataManager)
+	tagManager := manager.NewTagManager(app.metadataManager)
+	searchManager := manager.NewSearchManager(app.metadataManager)
+	archiveManager := manager.NewArchiveManager(app.photoManager)
+	exportManager := manager.NewExportManager(exportJobs, cfg.Storage.DataDir, service.ExportRetention)
+
+	// Limiti di richieste per ospite nel formato richieste/durata, "off" per disattivarli
+	limits, err := rateLimits(cfg.RateLimit)
+	if err != nil {
+		fatal("Limiti di richieste non validi", err)
+	}
+	app.rateLimitManager = manager.NewRateLimitManager(rateLimitRedis, limits, cfg.RateLimit.IPMultiplier)
+
+	// Filtro applicato a didascalie e commenti con l'elenco di parole vietate
+	contentFilter := service.NewWordListFilter(cfg.Moderation.BlockedWords)
+
+	app.eventService = service.NewEventService(eventBus)
+	app.photoService = service.NewPhotoService(app.photoManager, app.urlManager, app.queue, reactionStore, app.photoMetadataManager, tagManager, searchManager, app.eventService, contentFilter, cfg.Moderation.RequireApproval, cfg.Media.AllowOriginalDownload)
+	app.albumService = service.NewAlbumService(albumManager, app.photoService)
+	app.reactionService = service.NewReactionService(reactionStore, app.photoService)
+	app.commentService = service.NewCommentService(commentManager, app.photoService, contentFilter)
+	app.searchService = service.NewSearchService(searchManager, app.photoService)
+	app.slideshowService = service.NewSlideshowService(slideshowStore, app.photoMetadataManager, app.photoService)
+	app.tagService = service.NewTagService(tagManager, app.photoMetadataManager, searchManager, app.photoService, contentFilter)
+	app.rateLimitService = service.NewRateLimitService(app.rateLimitManager)
+	app.healthService = service.NewHealthService(app.photoManager, app.redisManager, app.queue, app.metadataManager, app.photoMetadataManager, app.lifecycle, uint64(cfg.Health.MinFreeDisk), cfg.Health.CheckTimeout)
+	app.processingService = service.NewProcessingService(app.queue, app.photoManager)
+	app.exportService = service.NewExportService(archiveManager, exportManager, app.photoManager, app.photoMetadataManager, tagManager, app.urlManager, app.albumService, app.eventService)
+	app.maintenanceService = service.NewMaintenanceService(app.photoManager, app.photoMetadataManager, searchManager, app.queue, app.photoService)
+	return app
+}
+
+// close chiude le risorse al termine dei sottocomandi che non restano in esecuzione
+func (app *application) close() {
+	if err := app.lifecycle.Close(); err != nil {
+		slog.Error("Errore nella chiusura delle risorse", "error", err)
+	}
+}